@@ -13,6 +13,7 @@ import Link from 'next/link'
 import React, { useContext } from 'react'
 import { AuthContext } from '../../context/auth.context'
 import { CartContext } from '../../context/cart.context'
+import { CurrencyContext } from '../../context/currency.context'
 
 type Props = {
   children?: React.ReactNode
@@ -22,6 +23,7 @@ export const Layout: React.FC<Props> = (props) => {
   const { t } = useTranslation('common')
   const authCtx = useContext(AuthContext)
   const cartCtx = useContext(CartContext)
+  const currencyCtx = useContext(CurrencyContext)
   return (
     <div className="p-4">
       <nav className="flex justify-center items-center space-x-4">
@@ -56,6 +58,21 @@ export const Layout: React.FC<Props> = (props) => {
                 </a>
               </Tooltip>
             </Link>
+            {currencyCtx.currencies.length > 1 && (
+              <Tooltip title={t('display_currency')}>
+                <select
+                  className="px-2 py-2 rounded-md bg-gray-100"
+                  value={currencyCtx.currency}
+                  onChange={(e) => currencyCtx.setCurrency(e.target.value)}
+                >
+                  {currencyCtx.currencies.map((currency) => (
+                    <option key={currency} value={currency}>
+                      {currency}
+                    </option>
+                  ))}
+                </select>
+              </Tooltip>
+            )}
             <Tooltip title={t('logout_title')}>
               <a
                 title="logout"
@@ -19,6 +19,9 @@ export const PaymentForm: React.FC<PaymentFormProps> = (props) => {
         recipientPhone: yup.string().required(t('required_input')),
         recipientName: yup.string().required(t('required_input')),
         recipientAddress: yup.string().required(t('required_input')),
+        recipientCountry: yup
+          .string()
+          .matches(/^([a-zA-Z]{2})?$/, t('invalid_country')),
       })
       .required()
     return schema
@@ -39,6 +42,9 @@ export const PaymentForm: React.FC<PaymentFormProps> = (props) => {
       recipientAddress: data.recipientAddress,
       recipientPhone: data.recipientPhone,
       recipientName: data.recipientName,
+      recipientCountry: data.recipientCountry?.trim().toUpperCase() || undefined,
+      recipientRegion: data.recipientRegion?.trim().toUpperCase() || undefined,
+      couponCode: data.couponCode?.trim() || undefined,
     }
 
     props.onSubmit(payload)
@@ -102,6 +108,43 @@ export const PaymentForm: React.FC<PaymentFormProps> = (props) => {
           </p>
         )}
       </div>
+      <div className="mb-3">
+        <label className="form-label inline-block mb-2 text-gray-700 text-xl">
+          {t('payment_country')}
+        </label>
+        <input
+          type="text"
+          className={`form-control block w-full px-4 py-2 text-xl font-normal text-gray-700 bg-white bg-clip-padding border border-solid border-gray-300 rounded transition ease-in-out m-0 focus:text-gray-700 focus:bg-white focus:border-blue-600 focus:outline-none ${
+            errors.recipientCountry && 'border-red-400'
+          }`}
+          {...register('recipientCountry')}
+        />
+        {errors.recipientCountry && (
+          <p className="text-sm text-red-400 mt-1">
+            {errors.recipientCountry?.message as any}
+          </p>
+        )}
+      </div>
+      <div className="mb-3">
+        <label className="form-label inline-block mb-2 text-gray-700 text-xl">
+          {t('payment_region')}
+        </label>
+        <input
+          type="text"
+          className="form-control block w-full px-4 py-2 text-xl font-normal text-gray-700 bg-white bg-clip-padding border border-solid border-gray-300 rounded transition ease-in-out m-0 focus:text-gray-700 focus:bg-white focus:border-blue-600 focus:outline-none"
+          {...register('recipientRegion')}
+        />
+      </div>
+      <div className="mb-3">
+        <label className="form-label inline-block mb-2 text-gray-700 text-xl">
+          {t('payment_coupon_code')}
+        </label>
+        <input
+          type="text"
+          className="form-control block w-full px-4 py-2 text-xl font-normal text-gray-700 bg-white bg-clip-padding border border-solid border-gray-300 rounded transition ease-in-out m-0 focus:text-gray-700 focus:bg-white focus:border-blue-600 focus:outline-none"
+          {...register('couponCode')}
+        />
+      </div>
       <div className="mb-3">
         <label className="form-label inline-block mb-2 text-gray-700 text-xl">
           {t('payment_method')}
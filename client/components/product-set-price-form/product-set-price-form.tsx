@@ -12,6 +12,7 @@ import * as yup from 'yup'
 interface ProductSetPriceFormProps {
   productId: number
   currentPrice: number
+  currentCurrency: string
   onUpdated?: () => any
 }
 export const ProductSetPriceForm: React.FC<ProductSetPriceFormProps> = (
@@ -26,6 +27,9 @@ export const ProductSetPriceForm: React.FC<ProductSetPriceFormProps> = (
           .number()
           .moreThan(0, t('price_zero_error'))
           .required(t('required_input')),
+        currency: yup
+          .string()
+          .matches(/^([a-zA-Z]{3})?$/, t('invalid_currency')),
       })
       .required()
     return schema
@@ -43,12 +47,14 @@ export const ProductSetPriceForm: React.FC<ProductSetPriceFormProps> = (
   useEffect(() => {
     reset({
       price: props.currentPrice,
+      currency: props.currentCurrency,
     })
-  }, [props.currentPrice, reset])
+  }, [props.currentPrice, props.currentCurrency, reset])
 
   const onSubmit = (data: any) => {
     const payload: SetProductPrice = {
       price: data.price,
+      currency: data.currency?.toUpperCase(),
       productId: props.productId,
     }
 
@@ -89,6 +95,25 @@ export const ProductSetPriceForm: React.FC<ProductSetPriceFormProps> = (
         )}
       </div>
 
+      <div className="mb-3">
+        <label className="form-label inline-block mb-2 text-gray-700 text-xl">
+          {t('price_currency')}
+        </label>
+        <input
+          type="text"
+          placeholder="USD"
+          className={`form-control block w-full px-4 py-2 text-xl font-normal text-gray-700 bg-white bg-clip-padding border border-solid border-gray-300 rounded transition ease-in-out m-0 focus:text-gray-700 focus:bg-white focus:border-blue-600 focus:outline-none ${
+            errors.currency && 'border-red-400'
+          }`}
+          {...register('currency', {})}
+        />
+        {errors.currency && (
+          <p className="text-sm text-red-400 mt-1">
+            {errors.currency?.message as any}
+          </p>
+        )}
+      </div>
+
       <div className="mt-6">
         <Button type="primary" htmlType="submit">
           Update
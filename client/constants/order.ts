@@ -5,4 +5,6 @@ export enum OrderStatus {
   Shipping = 'SHIPPING',
   Shipped = 'SHIPPED',
   Cancelled = 'CANCELLED',
+  Returned = 'RETURNED',
+  Refunded = 'REFUNDED',
 }
@@ -0,0 +1,12 @@
+import React from 'react'
+
+export const CurrencyContext = React.createContext<{
+  // the currency the prices are shown in and the orders paid in
+  currency: string
+  currencies: string[]
+  setCurrency: (c: string) => void
+}>({
+  currency: '',
+  currencies: [],
+  setCurrency: (c) => {},
+})
@@ -15,6 +15,7 @@ export interface CartItem {
   productName: string
   productId: number
   productPrice: number
+  currency: string
   quantity: number
   productPriceId: number
   vendorId: number
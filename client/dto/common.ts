@@ -1,4 +1,11 @@
 export interface ErrorResponse {
   code: string
-  message: string
+  message: string | ErrorDetail
+}
+
+// structured error, e.g. the list of the products
+// which are out of stock when placing orders
+export interface ErrorDetail {
+  error: string
+  items?: unknown[]
 }
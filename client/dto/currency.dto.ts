@@ -0,0 +1,5 @@
+export interface Currencies {
+  base: string
+  // the base currency and the currencies with an exchange rate
+  currencies: string[]
+}
@@ -8,6 +8,14 @@ export interface Order {
   status: OrderStatus
   statusChangeTime: string
   total: string
+  subtotal: string
+  discount: string
+  tax: string
+  totalPrice: string
+  // the amounts are in the currency of the order, worth
+  // exchangeRate of the base currency when it was placed
+  currency: string
+  exchangeRate: string
   paymentMethodId: string
   paymentMethodName: string
   shippingAddress: string
@@ -23,6 +31,15 @@ export interface Order {
     quantity: number
     unitPrice: number
   }[]
+  adjustments?: OrderAdjustment[]
+}
+
+// e.g. the discount of a coupon, a negative amount, or a tax and its rate
+export interface OrderAdjustment {
+  type: string
+  description: string
+  amount: string
+  rate?: string
 }
 export interface OrdersCreate {
   orders: OrderCreate[]
@@ -30,6 +47,28 @@ export interface OrdersCreate {
   recipientAddress: string
   recipientName: string
   recipientPhone: string
+  recipientCountry?: string
+  recipientRegion?: string
+  acceptedPrices?: AcceptedPrice[]
+  couponCode?: string
+  currency?: string
+}
+
+export interface AcceptedPrice {
+  productId: number
+  productPriceId: number
+}
+
+// an ordered product whose price went up since it was added to the cart
+export interface PriceChangedItem {
+  productId: number
+  productName: string
+  quantity: string
+  previousPrice: string
+  currentPrice: string
+  productPriceId: number
+  previousCurrency: string
+  currentCurrency: string
 }
 
 export interface OrderCreate {
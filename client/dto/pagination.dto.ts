@@ -2,13 +2,18 @@ export interface PaginationQuery {
   pageIndex: number
   itemsPerPage: number
   filters?: Record<string, any>
+  sort?: string
+  cursor?: string
+  skipTotal?: boolean
 }
 
 export interface PaginationResponse<T> {
   items: T[]
   pageIndex: number
   itemsPerPage: number
+  // omitted when the query has skipTotal
   total: number
+  nextCursor?: string
 }
 
 export function buildPaginationRequest(
@@ -8,4 +8,7 @@ export interface PaymentInfo {
   recipientPhone: string
   recipientName: string
   recipientAddress: string
+  recipientCountry?: string
+  recipientRegion?: string
+  couponCode?: string
 }
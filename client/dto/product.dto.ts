@@ -6,13 +6,65 @@ export interface Product {
   description: string
   vendorId: number
   unit: string
+  sku?: string
+  barcode?: string
+  categoryId?: number
+  categories?: CategoryRef[]
+  images?: ProductImage[]
+  // sold through its variants, with their total stock and lowest price
+  hasVariants: boolean
+  options?: string[]
+  variants?: ProductVariant[]
+  rank?: number
+  nameHighlight?: string
+  descriptionHighlight?: string
   stockQuantity: number
   productPrice: number
+  currency: string
+}
+
+export interface ProductVariant {
+  id: number
+  parentId: number
+  name: string
+  sku?: string
+  barcode?: string
+  options: { name: string; value: string }[]
+  stockQuantity: number
+  productPrice: number
+  currency: string
+}
+
+export interface ProductImage {
+  id: number
+  url: string
+  thumbnailUrl: string
+  width: number
+  height: number
+  position: number
+  primary: boolean
+}
+
+export function primaryImage(p: Product): ProductImage | undefined {
+  return p.images?.find((image) => image.primary) ?? p.images?.[0]
+}
+
+export function mediaUrl(url: string): string {
+  return `${process.env.NEXT_PUBLIC_MEDIA_BASE_URL ?? ''}${url}`
+}
+
+export interface CategoryRef {
+  id: number
+  name: string
 }
 
 export interface CreateProduct {
   name: string
   descriptiton: string
+  unit?: string
+  sku?: string
+  barcode?: string
+  options?: string[]
 }
 
 export interface UpdateProductStock {
@@ -25,4 +77,9 @@ export interface UpdateProductStock {
 export interface SetProductPrice {
   productId: number
   price: number
+  // ISO 4217, the base currency when empty
+  currency?: string
+  // ISO 8601, right away and for good when empty
+  effectiveFrom?: string
+  effectiveUntil?: string
 }
@@ -6,6 +6,7 @@ import { cart } from 'services/cart'
 import { Layout } from '../components/layout/layout'
 import { AuthContext } from '../context/auth.context'
 import { CartContext } from '../context/cart.context'
+import { CurrencyContext } from '../context/currency.context'
 import { AddCartItem, CartItem } from '../dto/cart.dto'
 import { User } from '../dto/user.dto'
 import auth from '../services/auth'
@@ -14,6 +15,7 @@ import { Maybe } from '../types/maybe'
 import { handleApiError } from 'utils/error'
 import Router from 'next/router'
 import { websocket } from 'services/websocket'
+import { currency as currencyService } from 'services/currency'
 
 function MyApp({ Component, pageProps }: AppProps) {
   const { t } = useTranslation('common')
@@ -21,10 +23,12 @@ function MyApp({ Component, pageProps }: AppProps) {
   const [user, setUser] = useState<Maybe<User>>(null)
   const [cartItems, setCartItems] = useState<CartItem[]>([])
   const [checkoutItems, setCheckoutItems] = useState<CartItem[]>([])
+  const [currency, setCurrency] = useState('')
+  const [currencies, setCurrencies] = useState<string[]>([])
 
   const getCart = useCallback(async () => {
     try {
-      const userCart = await cart.getUserCart()
+      const userCart = await cart.getUserCart(currency)
       const newCheckoutItems = []
 
       for (let i of checkoutItems) {
@@ -38,7 +42,12 @@ function MyApp({ Component, pageProps }: AppProps) {
     } catch (error) {
       handleApiError(t, error)
     }
-  }, [checkoutItems, t])
+  }, [checkoutItems, currency, t])
+
+  function changeCurrency(c: string) {
+    currencyService.setDisplayCurrency(c)
+    setCurrency(c)
+  }
 
   async function addCartItem(c: AddCartItem) {
     try {
@@ -73,12 +82,20 @@ function MyApp({ Component, pageProps }: AppProps) {
     setUser(auth.user)
     setAuthenticated(true)
     getCart()
+    currencyService
+      .getCurrencies()
+      .then((result) => {
+        setCurrencies(result.currencies)
+        const picked = currencyService.getDisplayCurrency()
+        setCurrency(result.currencies.includes(picked) ? picked : result.base)
+      })
+      .catch((error) => handleApiError(t, error))
     websocket.init()
-    websocket.onMessage(() => {
-      console.log('asd')
-      getCart()
+    websocket.onMessage((data) => {
+      // other messages (e.g. stock alerts) come as { type, data }
+      if (data === 'reload') getCart()
     })
-  }, [getCart])
+  }, [getCart, t])
 
   useEffect(() => {
     auth.onLogin(() => {
@@ -94,19 +111,23 @@ function MyApp({ Component, pageProps }: AppProps) {
 
   return (
     <AuthContext.Provider value={{ authenticated, user, logout }}>
-      <CartContext.Provider
-        value={{
-          cartItems,
-          addCartItem,
-          checkoutItems,
-          toggleCheckoutItem,
-          refreshCart: getCart,
-        }}
+      <CurrencyContext.Provider
+        value={{ currency, currencies, setCurrency: changeCurrency }}
       >
-        <Layout>
-          <Component {...pageProps} />
-        </Layout>
-      </CartContext.Provider>
+        <CartContext.Provider
+          value={{
+            cartItems,
+            addCartItem,
+            checkoutItems,
+            toggleCheckoutItem,
+            refreshCart: getCart,
+          }}
+        >
+          <Layout>
+            <Component {...pageProps} />
+          </Layout>
+        </CartContext.Provider>
+      </CurrencyContext.Provider>
     </AuthContext.Provider>
   )
 }
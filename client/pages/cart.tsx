@@ -1,6 +1,7 @@
 import { Button, Checkbox, InputNumber } from 'antd'
 import { AuthContext } from 'context/auth.context'
 import { CartContext } from 'context/cart.context'
+import { CurrencyContext } from 'context/currency.context'
 import { Decimal } from 'decimal.js'
 import { CartItem } from 'dto/cart.dto'
 import _ from 'lodash'
@@ -14,7 +15,7 @@ import { useRouter } from 'next/router'
 import React, { useContext, useEffect, useMemo } from 'react'
 import auth from 'services/auth'
 import { cart } from 'services/cart'
-import { groupCartItemByVendor } from 'utils/common'
+import { formatPrice, groupCartItemByVendor } from 'utils/common'
 import { handleApiError } from 'utils/error'
 import { Delete24Regular } from '@fluentui/react-icons'
 
@@ -22,6 +23,7 @@ const CartPage: React.FC<any> = () => {
   const { t } = useTranslation('common')
   const cartCtx = useContext(CartContext)
   const authCtx = useContext(AuthContext)
+  const currencyCtx = useContext(CurrencyContext)
   const router = useRouter()
   const cartItemsGroupByVendor = useMemo(() => {
     return groupCartItemByVendor(cartCtx.cartItems)
@@ -155,14 +157,20 @@ const CartPage: React.FC<any> = () => {
                                   </td>
                                   <td className="text-right table-cell">
                                     <span className="text-sm lg:text-base font-medium">
-                                      {item.productPrice}
+                                      {formatPrice(
+                                        item.productPrice,
+                                        item.currency
+                                      )}
                                     </span>
                                   </td>
                                   <td className="text-right">
                                     <span className="text-sm lg:text-base font-medium">
-                                      {new Decimal(item.productPrice)
-                                        .times(item.quantity)
-                                        .toFixed(2)}
+                                      {formatPrice(
+                                        new Decimal(item.productPrice).times(
+                                          item.quantity
+                                        ),
+                                        item.currency
+                                      )}
                                     </span>
                                   </td>
                                   <td className="text-center">
@@ -195,15 +203,16 @@ const CartPage: React.FC<any> = () => {
                             {t('cart_order_total_text')}
                           </div>
                           <div className="lg:px-4 lg:py-2 m-2 lg:text-lg font-bold text-center text-gray-900">
-                            {cartCtx.checkoutItems
-                              .reduce(
+                            {formatPrice(
+                              cartCtx.checkoutItems.reduce(
                                 (s, i) =>
                                   new Decimal(i.quantity)
                                     .times(new Decimal(i.productPrice))
                                     .plus(s),
                                 new Decimal(0)
-                              )
-                              .toFixed(2)}
+                              ),
+                              currencyCtx.currency
+                            )}
                           </div>
                         </div>
                         <Link href="/checkout">
@@ -1,8 +1,11 @@
+import { Modal } from 'antd'
 import { PaymentForm } from 'components/payment-form/payment-form'
 import { AuthContext } from 'context/auth.context'
 import { CartContext } from 'context/cart.context'
+import { CurrencyContext } from 'context/currency.context'
 import { Decimal } from 'decimal.js'
-import { OrdersCreate } from 'dto/order.dto'
+import { ErrorResponse } from 'dto/common'
+import { OrdersCreate, PriceChangedItem } from 'dto/order.dto'
 import { PaymentInfo, PaymentMethod } from 'dto/payment.dto'
 import { GetServerSideProps } from 'next'
 import { useTranslation } from 'next-i18next'
@@ -15,13 +18,14 @@ import auth from 'services/auth'
 import { notification } from 'services/notification'
 import { order } from 'services/order'
 import { payment } from 'services/payment'
-import { groupCartItemByVendor } from 'utils/common'
+import { formatPrice, groupCartItemByVendor } from 'utils/common'
 import { handleApiError } from 'utils/error'
 
 const CheckoutPage: React.FC<any> = (props) => {
   const { t } = useTranslation('common')
   const cartCtx = useContext(CartContext)
   const authCtx = useContext(AuthContext)
+  const currencyCtx = useContext(CurrencyContext)
   const router = useRouter()
   const [paymentMethods, setPaymentMethods] = useState<PaymentMethod[]>([])
   const cartItemsGroupByVendor = useMemo(
@@ -56,6 +60,10 @@ const CheckoutPage: React.FC<any> = (props) => {
       recipientAddress: paymentInfo.recipientAddress,
       recipientName: paymentInfo.recipientName,
       recipientPhone: paymentInfo.recipientPhone,
+      recipientCountry: paymentInfo.recipientCountry,
+      recipientRegion: paymentInfo.recipientRegion,
+      couponCode: paymentInfo.couponCode,
+      currency: currencyCtx.currency,
     }
 
     for (let vendor of Object.values(groupedItemsByVendor)) {
@@ -66,6 +74,10 @@ const CheckoutPage: React.FC<any> = (props) => {
         })),
       })
     }
+    return placeOrders(ordersCreateRequest)
+  }
+
+  function placeOrders(ordersCreateRequest: OrdersCreate) {
     return order
       .createOrders(ordersCreateRequest)
       .then(() => {
@@ -73,7 +85,53 @@ const CheckoutPage: React.FC<any> = (props) => {
         router.push('/orders')
         cartCtx.refreshCart()
       })
-      .catch((error) => handleApiError(t, error))
+      .catch((error) => {
+        const detail = (error as ErrorResponse).message
+        if (typeof detail === 'object' && detail?.error === 'prices_changed') {
+          confirmPriceChanges(
+            ordersCreateRequest,
+            detail.items as PriceChangedItem[]
+          )
+          return
+        }
+
+        handleApiError(t, error)
+      })
+  }
+
+  // the buyer has to accept the new prices before the orders are placed
+  function confirmPriceChanges(
+    ordersCreateRequest: OrdersCreate,
+    items: PriceChangedItem[]
+  ) {
+    Modal.confirm({
+      title: t('prices_changed'),
+      content: (
+        <ul>
+          {items.map((i) => (
+            <li key={i.productId}>
+              {i.productName}:{' '}
+              {formatPrice(i.previousPrice, i.previousCurrency)} &rarr;{' '}
+              {formatPrice(i.currentPrice, i.currentCurrency)}
+            </li>
+          ))}
+        </ul>
+      ),
+      okText: t('confirm_ok_text'),
+      cancelText: t('confirm_cancel_text'),
+      onOk: () =>
+        placeOrders({
+          ...ordersCreateRequest,
+          acceptedPrices: [
+            ...(ordersCreateRequest.acceptedPrices ?? []),
+            ...items.map((i) => ({
+              productId: i.productId,
+              productPriceId: i.productPriceId,
+            })),
+          ],
+        }),
+      onCancel: () => cartCtx.refreshCart(),
+    })
   }
 
   if (cartCtx.checkoutItems.length === 0) {
@@ -166,14 +224,20 @@ const CheckoutPage: React.FC<any> = (props) => {
                                 </td>
                                 <td className="hidden text-right md:table-cell">
                                   <span className="text-sm lg:text-base font-medium">
-                                    {item.productPrice}
+                                    {formatPrice(
+                                      item.productPrice,
+                                      item.currency
+                                    )}
                                   </span>
                                 </td>
                                 <td className="text-right">
                                   <span className="text-sm lg:text-base font-medium">
-                                    {new Decimal(item.productPrice)
-                                      .times(item.quantity)
-                                      .toFixed(2)}
+                                    {formatPrice(
+                                      new Decimal(item.productPrice).times(
+                                        item.quantity
+                                      ),
+                                      item.currency
+                                    )}
                                   </span>
                                 </td>
                               </tr>
@@ -192,15 +256,16 @@ const CheckoutPage: React.FC<any> = (props) => {
                           {t('cart_order_total_text')}
                         </div>
                         <div className="lg:px-4 lg:py-2 m-2 lg:text-lg font-bold text-center text-gray-900">
-                          {cartCtx.checkoutItems
-                            .reduce(
+                          {formatPrice(
+                            cartCtx.checkoutItems.reduce(
                               (s, i) =>
                                 new Decimal(i.quantity)
                                   .times(new Decimal(i.productPrice))
                                   .plus(s),
                               new Decimal(0)
-                            )
-                            .toFixed(2)}
+                            ),
+                            currencyCtx.currency
+                          )}
                         </div>
                       </div>
                     </div>
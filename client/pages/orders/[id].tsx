@@ -15,6 +15,7 @@ import auth from 'services/auth'
 import { notification } from 'services/notification'
 import { order } from 'services/order'
 import { Maybe } from 'types/maybe'
+import { formatPrice } from 'utils/common'
 import { handleApiError } from 'utils/error'
 
 const OrderPage: NextPage = () => {
@@ -155,14 +156,20 @@ const OrderPage: NextPage = () => {
                             </td>
                             <td className="hidden text-right md:table-cell">
                               <span className="text-sm lg:text-base font-medium">
-                                {item.unitPrice}
+                                {formatPrice(
+                                  item.unitPrice,
+                                  currentOrder.currency
+                                )}
                               </span>
                             </td>
                             <td className="text-right">
                               <span className="text-sm lg:text-base font-medium">
-                                {new Decimal(item.unitPrice)
-                                  .times(item.quantity)
-                                  .toFixed(2)}
+                                {formatPrice(
+                                  new Decimal(item.unitPrice).times(
+                                    item.quantity
+                                  ),
+                                  currentOrder.currency
+                                )}
                               </span>
                             </td>
                           </tr>
@@ -179,17 +186,44 @@ const OrderPage: NextPage = () => {
                           {t('cart_order_total_text')}
                         </div>
                         <div className="lg:px-4 lg:py-2 m-2 lg:text-lg font-bold text-center text-gray-900">
-                          {(currentOrder.items || [])
-                            .reduce(
+                          {formatPrice(
+                            (currentOrder.items || []).reduce(
                               (s, i) =>
                                 new Decimal(i.quantity)
                                   .times(new Decimal(i.unitPrice))
                                   .plus(s),
                               new Decimal(0)
-                            )
-                            .toFixed(2)}
+                            ),
+                            currentOrder.currency
+                          )}
                         </div>
                       </div>
+                      {(currentOrder.adjustments || []).map((a, idx) => (
+                        <div
+                          key={idx}
+                          className="flex justify-between border-b"
+                        >
+                          <div className="lg:px-4 lg:py-2 m-2 text-gray-800">
+                            {a.description}
+                          </div>
+                          <div className="lg:px-4 lg:py-2 m-2 text-gray-900">
+                            {new Decimal(a.amount).toFixed(2)}
+                          </div>
+                        </div>
+                      ))}
+                      {!!currentOrder.adjustments?.length && (
+                        <div className="flex justify-between border-b">
+                          <div className="lg:px-4 lg:py-2 m-2 text-lg lg:text-xl font-bold text-center text-gray-800">
+                            {t('order_grand_total_text')}
+                          </div>
+                          <div className="lg:px-4 lg:py-2 m-2 lg:text-lg font-bold text-center text-gray-900">
+                            {formatPrice(
+                              currentOrder.totalPrice,
+                              currentOrder.currency
+                            )}
+                          </div>
+                        </div>
+                      )}
                     </div>
                   </div>
                 </div>
@@ -21,6 +21,7 @@ import { http } from 'services/http'
 import { notification } from 'services/notification'
 import { order } from 'services/order'
 import { Maybe } from 'types/maybe'
+import { formatPrice } from 'utils/common'
 import { handleApiError } from 'utils/error'
 
 const UserOrderManagePage: NextPage = () => {
@@ -152,6 +153,9 @@ const UserOrderManagePage: NextPage = () => {
                 dataIndex="totalPrice"
                 key="totalPrice"
                 width="20%"
+                render={(_: any, record: Order) => {
+                  return formatPrice(record.totalPrice, record.currency)
+                }}
               />
               <Column
                 title={t('order_status_label') as string}
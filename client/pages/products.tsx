@@ -12,7 +12,7 @@ import React, {
 } from 'react'
 import { ItemsPerPage } from '../constants/pagination'
 import { buildPaginationRequest } from '../dto/pagination.dto'
-import { Product } from '../dto/product.dto'
+import { mediaUrl, primaryImage, Product } from '../dto/product.dto'
 import { Add24Filled } from '@fluentui/react-icons'
 import { CartContext } from 'context/cart.context'
 import auth from 'services/auth'
@@ -20,14 +20,21 @@ import { AuthContext } from 'context/auth.context'
 import { useRouter } from 'next/router'
 import { product } from 'services/product'
 import { Pagination } from 'antd'
+import { CurrencyContext } from 'context/currency.context'
+import { formatPrice } from 'utils/common'
 
 const ProductList: React.FC<any> = (props) => {
   const { t } = useTranslation('common')
   const cartCtx = useContext(CartContext)
   const authCtx = useContext(AuthContext)
+  const currencyCtx = useContext(CurrencyContext)
   const router = useRouter()
   const [products, setProducts] = useState<Product[]>([])
   const [pageIndex, setPageIndex] = useState(0)
+  // product id -> the variant picked in its card
+  const [pickedVariants, setPickedVariants] = useState<Record<number, number>>(
+    {}
+  )
   const [total, setTotal] = useState(-1)
   const totalPage = useMemo(() => Math.ceil(total / ItemsPerPage), [total])
 
@@ -39,7 +46,10 @@ const ProductList: React.FC<any> = (props) => {
 
   const fetchPage = useCallback(() => {
     product
-      .getProducts(buildPaginationRequest(pageIndex, ItemsPerPage))
+      .getProducts(
+        buildPaginationRequest(pageIndex, ItemsPerPage),
+        currencyCtx.currency
+      )
       .then((data) => {
         if (total == -1) {
           setTotal(data.total)
@@ -47,15 +57,22 @@ const ProductList: React.FC<any> = (props) => {
 
         setProducts(data.items)
       })
-  }, [pageIndex, total])
+  }, [pageIndex, total, currencyCtx.currency])
 
   useEffect(() => {
     fetchPage()
   }, [fetchPage])
 
   function addProductToCart(p: Product) {
+    const productId = p.hasVariants
+      ? pickedVariants[p.id] ?? p.variants?.[0]?.id
+      : p.id
+    if (!productId) {
+      return
+    }
+
     cartCtx.addCartItem({
-      productId: p.id,
+      productId,
       quantity: 1,
     })
   }
@@ -74,6 +91,7 @@ const ProductList: React.FC<any> = (props) => {
           {products.length === 0 && 'No products'}
           {products.length > 0 &&
             products.map((product) => {
+              const image = primaryImage(product)
               return (
                 <div
                   key={product.id}
@@ -83,7 +101,12 @@ const ProductList: React.FC<any> = (props) => {
                     <div className="p-4">
                       <div>
                         <Image
-                          src="/images/default-product-image.png"
+                          src={
+                            image
+                              ? mediaUrl(image.thumbnailUrl)
+                              : '/images/default-product-image.png'
+                          }
+                          unoptimized={!!image}
                           width={200}
                           height={200}
                           layout="responsive"
@@ -92,7 +115,38 @@ const ProductList: React.FC<any> = (props) => {
                         />
                       </div>
                       <div>{product.name}</div>
-                      <div>${product.productPrice}</div>
+                      <div>
+                        {product.hasVariants && 'From '}
+                        {formatPrice(product.productPrice, product.currency)}
+                      </div>
+                      {product.hasVariants && (
+                        <select
+                          className="w-full mt-2 border border-gray-300"
+                          value={
+                            pickedVariants[product.id] ??
+                            product.variants?.[0]?.id
+                          }
+                          onChange={(e) =>
+                            setPickedVariants({
+                              ...pickedVariants,
+                              [product.id]: parseInt(e.target.value, 10),
+                            })
+                          }
+                        >
+                          {product.variants?.map((variant) => (
+                            <option key={variant.id} value={variant.id}>
+                              {variant.options
+                                .map((option) => option.value)
+                                .join(' / ')}{' '}
+                              -{' '}
+                              {formatPrice(
+                                variant.productPrice,
+                                variant.currency
+                              )}
+                            </option>
+                          ))}
+                        </select>
+                      )}
                     </div>
                     <a
                       className="flex justify-center items-center py-2 px-4 bg-blue-500 hover:bg-blue-600 text-white transition"
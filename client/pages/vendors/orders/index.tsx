@@ -22,6 +22,7 @@ import { http } from 'services/http'
 import { notification } from 'services/notification'
 import { order } from 'services/order'
 import { Maybe } from 'types/maybe'
+import { formatPrice } from 'utils/common'
 import { handleApiError } from 'utils/error'
 
 const VendorDashboardProducts: NextPage = () => {
@@ -156,6 +157,9 @@ const VendorDashboardProducts: NextPage = () => {
                   dataIndex="totalPrice"
                   key="totalPrice"
                   width="20%"
+                  render={(_: any, record: Order) => {
+                    return formatPrice(record.totalPrice, record.currency)
+                  }}
                 />
                 <Column
                   title={t('order_status_label') as string}
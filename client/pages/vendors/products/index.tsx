@@ -22,6 +22,7 @@ import auth from 'services/auth'
 import { http } from 'services/http'
 import { product } from 'services/product'
 import { Maybe } from 'types/maybe'
+import { formatPrice } from 'utils/common'
 
 enum ProductModalType {
   ModalUpdateStock,
@@ -135,6 +136,9 @@ const VendorDashboardProducts: NextPage = () => {
                     dataIndex="productPrice"
                     key="productPrice"
                     width="10%"
+                    render={(_: any, record: Product) => {
+                      return formatPrice(record.productPrice, record.currency)
+                    }}
                   />
                   <Column
                     title={t('product_stock_quantity_label') as string}
@@ -218,6 +222,7 @@ const VendorDashboardProducts: NextPage = () => {
           <ProductSetPriceForm
             productId={currentModalProduct?.id || -1}
             currentPrice={currentModalProduct?.productPrice || 0}
+            currentCurrency={currentModalProduct?.currency || ''}
             onUpdated={() => {
               setIsModalVisible(false)
               fetchData((current || 1) - 1)
@@ -4,8 +4,11 @@ import { ErrorResponse } from 'dto/common'
 import { http } from './http'
 
 export const cart = {
-  async getUserCart(): Promise<Cart> {
-    const response = await http.get<Cart>('/cart')
+  // the prices are shown in the given currency, or in their own one
+  async getUserCart(currency?: string): Promise<Cart> {
+    const response = await http.get<Cart>('/cart', {
+      params: currency ? { currency } : {},
+    })
 
     return response.data
   },
@@ -0,0 +1,20 @@
+import { Currencies } from 'dto/currency.dto'
+import { http } from './http'
+
+const displayCurrencyKey = 'display_currency'
+
+export const currency = {
+  getCurrencies() {
+    return http
+      .get<Currencies>('/currencies')
+      .then((response) => response.data)
+  },
+  // the currency the buyer picked to see the prices in
+  getDisplayCurrency(): string {
+    if (typeof window === 'undefined') return ''
+    return localStorage.getItem(displayCurrencyKey) ?? ''
+  },
+  setDisplayCurrency(value: string) {
+    localStorage.setItem(displayCurrencyKey, value)
+  },
+}
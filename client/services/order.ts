@@ -1,4 +1,6 @@
+import axios from 'axios'
 import { OrderStatus } from 'constants/order'
+import { ErrorResponse } from 'dto/common'
 import { Order, OrdersCreate } from 'dto/order.dto'
 import { Maybe } from 'types/maybe'
 import { http } from './http'
@@ -6,22 +8,27 @@ import { http } from './http'
 export const order = {
   isCancellableState(order: Order) {
     return (
-      order.status !== OrderStatus.Cancelled &&
-      order.status !== OrderStatus.Shipping &&
-      order.status !== OrderStatus.Shipped
+      order.status === OrderStatus.Placed || order.status === OrderStatus.Paid
     )
   },
   isFinalState(order: Order) {
     return (
       order.status !== OrderStatus.Cancelled &&
-      order.status !== OrderStatus.Shipped
+      order.status !== OrderStatus.Shipped &&
+      order.status !== OrderStatus.Returned &&
+      order.status !== OrderStatus.Refunded
     )
   },
   cancelOrder(orderId: number) {
     return http.post(`/orders/${orderId}/cancel`)
   },
-  createOrders(orders: OrdersCreate) {
-    return http.post('/orders', orders)
+  async createOrders(orders: OrdersCreate) {
+    try {
+      return await http.post('/orders', orders)
+    } catch (err) {
+      if (!axios.isAxiosError(err) || !err.response) throw err
+      throw err.response.data as ErrorResponse
+    }
   },
   orderNextStatus(orderId: number) {
     return http.put(`/vendors/orders/${orderId}`)
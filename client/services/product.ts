@@ -6,6 +6,7 @@ import {
 import {
   CreateProduct,
   Product,
+  ProductImage,
   SetProductPrice,
   UpdateProductStock,
 } from 'dto/product.dto'
@@ -21,10 +22,19 @@ export const product = {
   createProduct(data: CreateProduct) {
     return http.post(`/vendors/products`, data)
   },
-  getProducts(data: PaginationQuery): Promise<PaginationResponse<Product>> {
+  uploadProductImages(productId: number, files: File[]) {
+    const form = new FormData()
+    files.forEach((file) => form.append('images', file))
+    // the browser sets the multipart content type with its boundary
+    return http.post<ProductImage[]>(`/vendors/products/${productId}/images`, form)
+  },
+  getProducts(
+    data: PaginationQuery,
+    currency?: string
+  ): Promise<PaginationResponse<Product>> {
     return http
       .get<PaginationResponse<Product>>('/products', {
-        params: data,
+        params: currency ? { ...data, currency } : data,
       })
       .then(({ data }) => {
         return data
@@ -6,7 +6,7 @@ function buildWsEndpoint(jwtToken: string) {
     return `${process.env.NEXT_PUBLIC_WEBSOCKET_ENDPOINT}?jwt=${jwtToken}`
 }
 
-let onMessageFns: (() => void)[] = []
+let onMessageFns: ((data: any) => void)[] = []
 export const websocket = {
     initialized: false,
     init() {
@@ -15,8 +15,8 @@ export const websocket = {
         wsInstance = new WebSocket(buildWsEndpoint(auth.getAccessToken()!))
         
         wsInstance.addEventListener("message", event => {
-            console.log("message from server: ", )
-            onMessageFns.forEach(fn => fn())
+            const data = JSON.parse(event.data)
+            onMessageFns.forEach(fn => fn(data))
         })
         this.initialized = true
     },
@@ -24,7 +24,7 @@ export const websocket = {
         onMessageFns = []
         wsInstance.close()
     },
-    onMessage(fn: () => void) {
+    onMessage(fn: (data: any) => void) {
         onMessageFns.push(fn)
     }
 }
@@ -1,3 +1,4 @@
+import { Decimal } from 'decimal.js'
 import { CartItem } from 'dto/cart.dto'
 
 type VendorId = number
@@ -24,3 +25,8 @@ export function groupCartItemByVendor(
   })
   return vendorItems
 }
+
+// an amount with its currency, e.g. "12.50 EUR"
+export function formatPrice(amount: Decimal.Value, currency?: string): string {
+  return `${new Decimal(amount).toFixed(2)}${currency ? ` ${currency}` : ''}`
+}
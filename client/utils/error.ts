@@ -3,8 +3,10 @@ import { ErrorResponse } from 'dto/common'
 
 export function handleApiError(t: (k: string) => string, error: unknown) {
   const errorResponse = error as ErrorResponse
-  if (errorResponse.message) {
+  if (typeof errorResponse.message === 'string') {
     notification.error(t('action_error'), t(errorResponse.message))
+  } else if (errorResponse.message?.error) {
+    notification.error(t('action_error'), t(errorResponse.message.error))
   } else {
     notification.error(t('action_error'), t('unknown_error'))
   }
@@ -2,9 +2,11 @@ package common
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 )
 
 var (
@@ -17,11 +19,115 @@ var (
 	ErrorInsufficientQuantity   error = errors.New("insufficient_stock_quantity")
 	ErrorOrderFinalStateReached error = errors.New("order_final_status_reached")
 	ErrorResourceNotFound       error = errors.New("resource_not_found")
+	ErrorPaymentMethodInvalid   error = errors.New("payment_method_invalid")
+	ErrorPaymentNotRetryable    error = errors.New("payment_not_retryable")
+	ErrorOrderPaymentPending    error = errors.New("order_payment_pending")
+	ErrorOrderNotPaid           error = errors.New("order_not_paid")
+	ErrorTransitionNotAllowed   error = errors.New("order_transition_not_allowed")
+	ErrorStockTransferInvalid   error = errors.New("stock_transfer_invalid")
+	ErrorInvalidFilter          error = errors.New("invalid_filter")
+	ErrorInvalidSort            error = errors.New("invalid_sort")
+	ErrorInvalidCursor          error = errors.New("invalid_cursor")
+	ErrorInvalidCSV             error = errors.New("invalid_csv")
+	ErrorInvalidThreshold       error = errors.New("invalid_threshold")
+	ErrorInvalidUnit            error = errors.New("invalid_unit")
+	ErrorInvalidQuantity        error = errors.New("invalid_quantity")
+	ErrorInvalidBarcode         error = errors.New("invalid_barcode")
+	ErrorSKUExists              error = errors.New("sku_exists")
+	ErrorCategoryNotFound       error = errors.New("category_not_found")
+	ErrorCategoryCycle          error = errors.New("category_cycle")
+	ErrorInvalidRole            error = errors.New("invalid_role")
+	ErrorInvalidImage           error = errors.New("invalid_image")
+	ErrorImageTooLarge          error = errors.New("image_too_large")
+	ErrorInvalidImageOrder      error = errors.New("invalid_image_order")
+	ErrorInvalidBlobKey         error = errors.New("invalid_blob_key")
+	ErrorProductHasVariants     error = errors.New("product_has_variants")
+	ErrorInvalidVariant         error = errors.New("invalid_variant_options")
+	ErrorVariantExists          error = errors.New("variant_exists")
+	ErrorInvalidPriceWindow     error = errors.New("invalid_price_window")
+	ErrorPriceNotCancellable    error = errors.New("price_not_cancellable")
+	ErrorPricesChanged          error = errors.New("prices_changed")
+	ErrorPriceUnavailable       error = errors.New("price_unavailable")
+	ErrorInvalidCoupon          error = errors.New("invalid_coupon")
+	ErrorCouponCodeExists       error = errors.New("coupon_code_exists")
+	ErrorCouponNotApplicable    error = errors.New("coupon_not_applicable")
+	ErrorCouponUsageExceeded    error = errors.New("coupon_usage_exceeded")
+	ErrorInvalidTaxRule         error = errors.New("invalid_tax_rule")
+	ErrorTaxRuleExists          error = errors.New("tax_rule_exists")
+	ErrorInvalidTaxCategory     error = errors.New("invalid_tax_category")
+	ErrorInvalidCurrency        error = errors.New("invalid_currency")
+	ErrorInvalidExchangeRate    error = errors.New("invalid_exchange_rate")
+	ErrorExchangeRateMissing    error = errors.New("exchange_rate_missing")
+	ErrorNoFulfillingWarehouse  error = errors.New("no_fulfilling_warehouse")
 )
 
+// Returned when an order cannot go through a transition
+// from its current status, Err tells the reason
+type InvalidTransitionError struct {
+	Transition string
+	From       string
+	Err        error
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("%s: cannot %s an order in status %s", e.Err.Error(), e.Transition, e.From)
+}
+
+func (e *InvalidTransitionError) Unwrap() error {
+	return e.Err
+}
+
 var (
 	ErrorInternalServerError error = &echo.HTTPError{
 		Code:    http.StatusInternalServerError,
 		Message: "internal_server_error",
 	}
 )
+
+type InsufficientStockItem struct {
+	ProductID   uint            `json:"productId"`
+	ProductName string          `json:"productName"`
+	Requested   decimal.Decimal `json:"requested"`
+	Available   decimal.Decimal `json:"available"`
+}
+
+// Returned when some of the ordered products
+// do not have enough stock, Items lists all of them
+type InsufficientStockError struct {
+	Items []InsufficientStockItem `json:"items"`
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("%s: %d product(s)", ErrorInsufficientQuantity.Error(), len(e.Items))
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrorInsufficientQuantity
+}
+
+type PriceChangedItem struct {
+	ProductID      uint            `json:"productId"`
+	ProductName    string          `json:"productName"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	PreviousPrice  decimal.Decimal `json:"previousPrice"`
+	CurrentPrice   decimal.Decimal `json:"currentPrice"`
+	ProductPriceID uint            `json:"productPriceId"`
+	// the currencies of the previous and of the current price
+	PreviousCurrency string `json:"previousCurrency"`
+	CurrentCurrency  string `json:"currentCurrency"`
+}
+
+// Returned when the price of some of the ordered products went up since
+// they were added to the cart, Items lists all of them with the current
+// price to be accepted
+type PriceChangedError struct {
+	Items []PriceChangedItem `json:"items"`
+}
+
+func (e *PriceChangedError) Error() string {
+	return fmt.Sprintf("%s: %d product(s)", ErrorPricesChanged.Error(), len(e.Items))
+}
+
+func (e *PriceChangedError) Unwrap() error {
+	return ErrorPricesChanged
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -25,6 +26,28 @@ type Config struct {
 	AppEnv       AppEnv
 	AppURL       string
 	JwtSecretKey string
+
+	PaymentSimulatorDeclineRate float64
+	PaymentSimulatorDelay       time.Duration
+
+	StockReservationTTL time.Duration
+	FulfillmentRule     string
+
+	// the uploads are stored in StorageDir and served under StorageURL
+	StorageDir   string
+	StorageURL   string
+	ImageMaxSize int64
+
+	// a price in a cart is still honored at checkout
+	// for this long after the price of its product changed
+	PriceGracePeriod time.Duration
+
+	TaxEngine string
+
+	// the currency of the exchange rates and of the reports, the
+	// rates are loaded from ExchangeRatesFile with -loadrates
+	BaseCurrency      string
+	ExchangeRatesFile string
 }
 
 var config = Config{}
@@ -46,6 +69,12 @@ func LoadConfig() *Config {
 	loadDBConfig(&config)
 	loadAppConfig(&config)
 	loadJWTConfig(&config)
+	loadPaymentConfig(&config)
+	loadInventoryConfig(&config)
+	loadStorageConfig(&config)
+	loadPricingConfig(&config)
+	loadTaxConfig(&config)
+	loadCurrencyConfig(&config)
 
 	return &config
 }
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"log"
+
+	"github.com/asaskevich/govalidator"
+)
+
+func loadCurrencyConfig(config *Config) {
+	base := getEnvWithDefault("BASE_CURRENCY", "USD")
+	if !govalidator.IsISO4217(base) {
+		log.Fatalf("Invalid environment key: '%s'", "BASE_CURRENCY")
+	}
+
+	config.BaseCurrency = base
+	config.ExchangeRatesFile = getEnvWithDefault("EXCHANGE_RATES_FILE", "exchange_rates.csv")
+}
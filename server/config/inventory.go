@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	FulfillmentRulePriority  = "priority"
+	FulfillmentRuleMostStock = "most_stock"
+)
+
+func loadInventoryConfig(config *Config) {
+	ttl, err := time.ParseDuration(getEnvWithDefault("STOCK_RESERVATION_TTL", "15m"))
+	if err != nil || ttl <= 0 {
+		log.Fatalf("Invalid environment key: '%s'", "STOCK_RESERVATION_TTL")
+	}
+
+	rule := getEnvWithDefault("FULFILLMENT_RULE", FulfillmentRulePriority)
+	if rule != FulfillmentRulePriority && rule != FulfillmentRuleMostStock {
+		log.Fatalf("Invalid environment key: '%s'", "FULFILLMENT_RULE")
+	}
+
+	config.StockReservationTTL = ttl
+	config.FulfillmentRule = rule
+}
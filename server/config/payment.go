@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+func loadPaymentConfig(config *Config) {
+	declineRate, err := strconv.ParseFloat(getEnvWithDefault("PAYMENT_SIMULATOR_DECLINE_RATE", "0"), 64)
+	if err != nil || declineRate < 0 || declineRate > 1 {
+		log.Fatalf("Invalid environment key: '%s'", "PAYMENT_SIMULATOR_DECLINE_RATE")
+	}
+
+	delay, err := time.ParseDuration(getEnvWithDefault("PAYMENT_SIMULATOR_DELAY", "0s"))
+	if err != nil {
+		log.Fatalf("Invalid environment key: '%s'", "PAYMENT_SIMULATOR_DELAY")
+	}
+
+	config.PaymentSimulatorDeclineRate = declineRate
+	config.PaymentSimulatorDelay = delay
+}
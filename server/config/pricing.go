@@ -0,0 +1,15 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+func loadPricingConfig(config *Config) {
+	grace, err := time.ParseDuration(getEnvWithDefault("PRICE_GRACE_PERIOD", "0s"))
+	if err != nil || grace < 0 {
+		log.Fatalf("Invalid environment key: '%s'", "PRICE_GRACE_PERIOD")
+	}
+
+	config.PriceGracePeriod = grace
+}
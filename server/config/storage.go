@@ -0,0 +1,17 @@
+package config
+
+import (
+	"log"
+	"strconv"
+)
+
+func loadStorageConfig(config *Config) {
+	maxSize, err := strconv.ParseInt(getEnvWithDefault("IMAGE_MAX_SIZE", "5242880"), 10, 64)
+	if err != nil || maxSize <= 0 {
+		log.Fatalf("Invalid environment key: '%s'", "IMAGE_MAX_SIZE")
+	}
+
+	config.StorageDir = getEnvWithDefault("STORAGE_DIR", "uploads")
+	config.StorageURL = getEnvWithDefault("STORAGE_URL", "/media")
+	config.ImageMaxSize = maxSize
+}
@@ -0,0 +1,17 @@
+package config
+
+import "log"
+
+const (
+	TaxEngineRules = "rules"
+	TaxEngineNone  = "none"
+)
+
+func loadTaxConfig(config *Config) {
+	engine := getEnvWithDefault("TAX_ENGINE", TaxEngineRules)
+	if engine != TaxEngineRules && engine != TaxEngineNone {
+		log.Fatalf("Invalid environment key: '%s'", "TAX_ENGINE")
+	}
+
+	config.TaxEngine = engine
+}
@@ -98,6 +98,7 @@ func loadFixtures(db *gorm.DB) {
 				newProduct := models.Product{
 					Name:     fmt.Sprintf("product %s", user.Name),
 					VendorID: user.ID,
+					Unit:     models.UnitPiece,
 				}
 				newProduct.ID = i
 				productPrice := models.ProductPrice{
@@ -105,8 +106,9 @@ func loadFixtures(db *gorm.DB) {
 					Price:     decimal.NewFromFloat(100.0),
 				}
 				productTransaction := models.ProductTransaction{
-					Type:     models.TransactionTypeIn,
-					Quantity: 5,
+					ProductID: i,
+					Type:      models.TransactionTypeIn,
+					Quantity:  decimal.NewFromInt(5),
 				}
 				if err := tx.Create(&newProduct).Error; err != nil {
 					return err
@@ -141,6 +143,22 @@ func DropTestDB() {
 		&models.ProductPrice{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.PaymentIntent{},
+		&models.StockReservation{},
+		&models.ProductStock{},
+		&models.Warehouse{},
+		&models.WarehouseStock{},
+		&models.StockAlert{},
+		&models.Category{},
+		&models.ProductImage{},
+		&models.ProductOption{},
+		&models.ProductVariantValue{},
+		&models.Coupon{},
+		&models.CouponProduct{},
+		&models.CouponRedemption{},
+		&models.OrderAdjustment{},
+		&models.TaxRule{},
+		&models.ExchangeRate{},
 	)
 
 	if err != nil {
@@ -149,6 +167,11 @@ func DropTestDB() {
 }
 
 func AutoMigrate(db *gorm.DB) {
+	// trigram similarity for the misspelled product searches
+	if err := db.Exec("create extension if not exists pg_trgm").Error; err != nil {
+		log.Fatalln("failed to create pg_trgm extension", err)
+	}
+
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.Product{},
@@ -160,11 +183,94 @@ func AutoMigrate(db *gorm.DB) {
 		&models.ProductPrice{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.PaymentIntent{},
+		&models.StockReservation{},
+		&models.ProductStock{},
+		&models.Warehouse{},
+		&models.WarehouseStock{},
+		&models.StockAlert{},
+		&models.Category{},
+		&models.ProductImage{},
+		&models.ProductOption{},
+		&models.ProductVariantValue{},
+		&models.Coupon{},
+		&models.CouponProduct{},
+		&models.CouponRedemption{},
+		&models.OrderAdjustment{},
+		&models.TaxRule{},
+		&models.ExchangeRate{},
 	)
 
 	if err != nil {
 		log.Fatalln("failed to migrate database", err)
 	}
+
+	indexes := []string{
+		"create index if not exists idx_products_search on products using gin (" + models.ProductSearchDocument + ")",
+		"create index if not exists idx_products_name_trgm on products using gin (name gin_trgm_ops)",
+		"create unique index if not exists idx_product_images_primary on product_images (product_id) where is_primary",
+	}
+
+	for _, index := range indexes {
+		if err := db.Exec(index).Error; err != nil {
+			log.Fatalln("failed to create index", err)
+		}
+	}
+
+	// the prices recorded before the effective dates took effect when they were created
+	err = db.Exec("update product_prices set effective_from = created_at where effective_from is null").Error
+	if err != nil {
+		log.Fatalln("failed to backfill the start of the prices", err)
+	}
+
+	// the products created before the units of measure were controlled are counted by piece
+	units := []models.Unit{}
+	for unit := range models.Units {
+		units = append(units, unit)
+	}
+
+	err = db.Exec("update products set unit = ? where unit is null or unit not in (?)", models.UnitPiece, units).Error
+	if err != nil {
+		log.Fatalln("failed to backfill the units of the products", err)
+	}
+
+	if err := assignLegacyStock(db); err != nil {
+		log.Fatalln("failed to assign the stock to the warehouses", err)
+	}
+}
+
+// The stock recorded before the warehouses existed goes to the default warehouse
+// of its vendor (created when missing), as the stock changes without a location do,
+// and the balances by warehouse are rebuilt
+func assignLegacyStock(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`insert into warehouses (created_at, updated_at, vendor_id, name, address, priority, is_default)
+			select now(), now(), p.vendor_id, 'Main warehouse', '', 0, true
+			from products p
+			where exists (select 1 from product_transactions pt where pt.product_id = p.id and pt.warehouse_id is null)
+				and not exists (select 1 from warehouses w where w.vendor_id = p.vendor_id and w.deleted_at is null)
+			group by p.vendor_id`).Error
+
+		if err != nil {
+			return err
+		}
+
+		res := tx.Exec(`update product_transactions pt set warehouse_id = dw.id
+			from products p, (select distinct on (vendor_id) id, vendor_id from warehouses where deleted_at is null
+				order by vendor_id, is_default desc, priority asc, id asc) dw
+			where pt.warehouse_id is null and p.id = pt.product_id and dw.vendor_id = p.vendor_id`)
+
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		return tx.Exec(`insert into warehouse_stocks (product_id, warehouse_id, quantity, updated_at)
+			select product_id, warehouse_id, sum(quantity), now() from product_transactions
+			where warehouse_id is not null
+			group by product_id, warehouse_id
+			on conflict (product_id, warehouse_id) do update
+			set quantity = excluded.quantity, updated_at = excluded.updated_at`).Error
+	})
 }
 
 func SeedDB(db *gorm.DB) {
@@ -175,6 +281,7 @@ func SeedDB(db *gorm.DB) {
 	fmt.Println("default password: password")
 	fmt.Println("regular user email: email@example.com")
 	fmt.Println("vendor user email: email.vendor@example.com")
+	fmt.Println("admin user email: email.admin@example.com")
 }
 
 func SeedSampleData(db *gorm.DB) {
@@ -240,7 +347,35 @@ func seedDefaultUsers(db *gorm.DB) error {
 			return err
 		}
 
-		if err := seedProducts(tx, newVendorUser.ID, newVendorUser.Name); err != nil {
+		warehouse := models.Warehouse{VendorID: newVendorUser.ID, Name: "Main warehouse", IsDefault: true}
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+
+		if err := seedProducts(tx, newVendorUser.ID, newVendorUser.Name, warehouse.ID); err != nil {
+			return err
+		}
+
+		newAdminUser := models.User{
+			Email:    "email.admin@example.com",
+			Name:     "admin",
+			Password: string(encryptedPassword),
+			Role:     models.Admin,
+		}
+
+		if err := db.Clauses(clause.OnConflict{
+			UpdateAll: true,
+		}).Create(&newAdminUser).Error; err != nil {
+			return err
+		}
+
+		cart = models.Cart{
+			UserID: newAdminUser.ID,
+		}
+
+		if err := db.Clauses(clause.OnConflict{
+			UpdateAll: true,
+		}).Create(&cart).Error; err != nil {
 			return err
 		}
 
@@ -256,11 +391,11 @@ func seedDefaultUsers(db *gorm.DB) error {
 func seedPaymentMethod(db *gorm.DB) {
 	payments := []models.PaymentMethod{
 		{
-			ID:   "payment_cod",
+			ID:   models.PaymentMethodCOD,
 			Name: "cash_on_delivery",
 		},
 		{
-			ID:   "payment_credit",
+			ID:   models.PaymentMethodCredit,
 			Name: "credit_card",
 		},
 	}
@@ -306,7 +441,12 @@ func seedUsersAndProducts(db *gorm.DB) error {
 			}).Create(&cart).Error; err != nil {
 				return err
 			}
-			if err := seedProducts(tx, newUser.ID, newUser.Name); err != nil {
+			warehouse := models.Warehouse{VendorID: newUser.ID, Name: "Main warehouse", IsDefault: true}
+			if err := tx.Create(&warehouse).Error; err != nil {
+				return err
+			}
+
+			if err := seedProducts(tx, newUser.ID, newUser.Name, warehouse.ID); err != nil {
 				return err
 			}
 		}
@@ -321,13 +461,13 @@ func seedUsersAndProducts(db *gorm.DB) error {
 	return nil
 }
 
-func seedProducts(db *gorm.DB, vendorId uint, vendorName string) error {
+func seedProducts(db *gorm.DB, vendorId uint, vendorName string, warehouseId uint) error {
 	for j := 0; j < 10; j += 1 {
 		newProduct := models.Product{
 			VendorID:    vendorId,
 			Name:        fmt.Sprintf("(%s) product %d", vendorName, j),
 			Description: fmt.Sprintf("(%s) product %d", vendorName, j),
-			Unit:        "unit",
+			Unit:        models.UnitPiece,
 		}
 
 		if err := db.Create(&newProduct).Error; err != nil {
@@ -347,7 +487,8 @@ func seedProducts(db *gorm.DB, vendorId uint, vendorName string) error {
 			ProductID:   newProduct.ID,
 			Description: "import",
 			Type:        models.TransactionTypeIn,
-			Quantity:    50,
+			Quantity:    decimal.NewFromInt(50),
+			WarehouseID: &warehouseId,
 		}
 
 		if err := db.Create(&productTransaction).Error; err != nil {
@@ -1,6 +1,8 @@
 #!/bin/env sh
 
 ./go-app -migrate=true
+./go-app -reconcilestock=true
 ./go-app -seed=true
 ./go-app -seedsample=true
+./go-app -loadrates=true
 ./go-app
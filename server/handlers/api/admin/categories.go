@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/categories"
+	"order-system/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreateCategory godoc
+// @Summary      Create a new category
+// @Tags         admin-categories
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.CategoryRequestDto true "Category to be created"
+// @Success      200  {object}  models.Category
+// @Failure      400  "Invalid request / Parent category not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/categories [post]
+func CreateCategory(c echo.Context) error {
+	payload := new(dto.CategoryRequestDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	category, err := categories.CreateCategory(*payload)
+
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// UpdateCategory godoc
+// @Summary      Rename a category or move it under another parent
+// @Tags         admin-categories
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.CategoryRequestDto true "Update category request, a null parent moves the category to the root"
+// @Param id path int true "Category id"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request / Parent category not found / Category moved under itself" {object}  echo.HTTPError
+// @Failure      404  "Category not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/categories/:id [put]
+func UpdateCategory(c echo.Context) error {
+	payload := new(dto.CategoryRequestDto)
+	cIdParam := c.Param("id")
+
+	cId, err := strconv.ParseUint(cIdParam, 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	if err := categories.UpdateCategory(uint(cId), *payload); err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func categoryError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorResourceNotFound) {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorCategoryNotFound) || errors.Is(err, common.ErrorCategoryCycle) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/currencies"
+	"order-system/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetExchangeRates godoc
+// @Summary      Get the exchange rates, by currency and the latest start first
+// @Tags         admin-currencies
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param currency query string false "Only the rates of this currency"
+// @Success      200  {array}  models.ExchangeRate
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/exchange-rates [get]
+func GetExchangeRates(c echo.Context) error {
+	rates, err := currencies.FindExchangeRates(c.QueryParam("currency"))
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, rates)
+}
+
+// CreateExchangeRate godoc
+// @Summary      Record the exchange rate of a currency from a date
+// @Description  The rate is the value of one unit of the currency in the base currency (BASE_CURRENCY). A rate recorded for the same start replaces the previous one, the orders keep the rates they were placed with. The rates can also be loaded from EXCHANGE_RATES_FILE with -loadrates
+// @Tags         admin-currencies
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.ExchangeRateDto true "Exchange rate to be recorded"
+// @Success      200  {object}  models.ExchangeRate
+// @Failure      400  "Invalid request / Invalid currency / Invalid rate, or a rate of the base currency" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/exchange-rates [post]
+func CreateExchangeRate(c echo.Context) error {
+	payload := new(dto.ExchangeRateDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	rate, err := currencies.CreateExchangeRate(*payload)
+	if err != nil {
+		if errors.Is(err, common.ErrorInvalidCurrency) || errors.Is(err, common.ErrorInvalidExchangeRate) {
+			return &echo.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
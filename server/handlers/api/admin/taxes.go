@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/taxes"
+	"order-system/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTaxRules godoc
+// @Summary      Get the tax rules, by country and region
+// @Tags         admin-taxes
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Success      200  {array}  models.TaxRule
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/tax-rules [get]
+func GetTaxRules(c echo.Context) error {
+	rules, err := taxes.FindTaxRules()
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, rules)
+}
+
+// CreateTaxRule godoc
+// @Summary      Create a tax rule on the products shipped to a country or to a region of a country
+// @Description  The rate is a percentage. A rule without a tax category applies to the products of the categories without a rule of their own. The rule of a country and the rule of a region both apply
+// @Tags         admin-taxes
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.TaxRuleDto true "Tax rule to be created"
+// @Success      200  {object}  models.TaxRule
+// @Failure      400  "Invalid request / Invalid tax rule (country, region or rate) / Invalid tax category" {object}  echo.HTTPError
+// @Failure      409  "A rule already exists for the country, the region and the tax category" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/tax-rules [post]
+func CreateTaxRule(c echo.Context) error {
+	payload := new(dto.TaxRuleDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	rule, err := taxes.CreateTaxRule(*payload)
+	if err != nil {
+		return taxRuleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteTaxRule godoc
+// @Summary      Delete a tax rule, the taxes of the orders already placed are kept
+// @Tags         admin-taxes
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Tax rule id"
+// @Success      200  "Success"
+// @Failure      404  "Tax rule not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/admin/tax-rules/:id [delete]
+func DeleteTaxRule(c echo.Context) error {
+	ruleId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := taxes.DeleteTaxRule(uint(ruleId)); err != nil {
+		return taxRuleError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func taxRuleError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorInvalidTaxRule) || errors.Is(err, common.ErrorInvalidTaxCategory) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorTaxRuleExists) {
+		return &echo.HTTPError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorResourceNotFound) {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}
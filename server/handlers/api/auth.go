@@ -5,6 +5,7 @@ import (
 
 	"order-system/common"
 	"order-system/handlers/dto"
+	"order-system/models"
 	"order-system/services/users"
 	"order-system/utils"
 
@@ -87,6 +88,14 @@ func RegisterUser(c echo.Context) error {
 		}
 	}
 
+	// admins are not created through the sign up
+	if payload.Role != models.RegularUser && payload.Role != models.Vendor {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidRole.Error(),
+		}
+	}
+
 	if payload.ConfirmPassword != payload.Password {
 		return &echo.HTTPError{
 			Code:    http.StatusBadRequest,
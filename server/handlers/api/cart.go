@@ -7,8 +7,10 @@ import (
 	"order-system/handlers/dto"
 	"order-system/handlers/websocket"
 	"order-system/services/carts"
+	"order-system/services/currencies"
 	"order-system/services/products"
 	"order-system/utils"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -21,7 +23,8 @@ import (
 // @Param Authorization header string true "With the bearer started"
 // @Param payload body dto.AddCartItemDto true "The information of the item to be added"
 // @Success      200  "Success"
-// @Failure      401  "Insufficient stock quantity" {object} echo.HTTPError
+// @Failure      400  "Product with variants, one of its variants has to be added" {object} echo.HTTPError
+// @Failure      401  "Insufficient stock quantity / Quantity not allowed by the unit of the product" {object} echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/cart [post]
 func AddItemToCart(c echo.Context) error {
@@ -33,15 +36,23 @@ func AddItemToCart(c echo.Context) error {
 
 	currentUser := utils.GetCurrentUser(c)
 
-	price, err := products.FindProductLatestPrice(payload.ProductID)
+	price, err := products.FindProductPrice(payload.ProductID, time.Now())
 
 	if err != nil {
+		if errors.Is(err, common.ErrorProductHasVariants) {
+			return &echo.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
 		c.Logger().Error(err.Error())
 		return common.ErrorInternalServerError
 	}
 
-	if err = carts.AddItemToCart(currentUser.CartID, payload.ProductID, uint(payload.Quantity), price.ID); err != nil {
-		if errors.Is(err, common.ErrorInsufficientQuantity) {
+	if err = carts.AddItemToCart(currentUser.CartID, payload.ProductID, payload.Quantity, price.ID); err != nil {
+		if errors.Is(err, common.ErrorInsufficientQuantity) || errors.Is(err, common.ErrorInvalidQuantity) ||
+			errors.Is(err, common.ErrorProductHasVariants) {
 			return &echo.HTTPError{
 				Code:    http.StatusBadRequest,
 				Message: err.Error(),
@@ -93,7 +104,7 @@ func DeleteCartItem(c echo.Context) error {
 // @Param Authorization header string true "With the bearer started"
 // @Param payload body dto.SetCartItemDto true "The quantity and information of the item to be changed"
 // @Success      200  "Success"
-// @Failure      401  "Insufficient stock quantity" {object} echo.HTTPError
+// @Failure      401  "Insufficient stock quantity / Quantity not allowed by the unit of the product" {object} echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/cart [put]
 func SetCartItemQuantity(c echo.Context) error {
@@ -105,8 +116,9 @@ func SetCartItemQuantity(c echo.Context) error {
 
 	currentUser := utils.GetCurrentUser(c)
 
-	if err := carts.SetCartItemQuantity(currentUser.CartID, payload.ProductID, uint(payload.Quantity)); err != nil {
-		if errors.Is(err, common.ErrorInsufficientQuantity) {
+	if err := carts.SetCartItemQuantity(currentUser.CartID, payload.ProductID, payload.Quantity); err != nil {
+		if errors.Is(err, common.ErrorInsufficientQuantity) || errors.Is(err, common.ErrorInvalidQuantity) ||
+			errors.Is(err, common.ErrorProductHasVariants) {
 			return &echo.HTTPError{
 				Code:    http.StatusBadRequest,
 				Message: err.Error(),
@@ -126,10 +138,17 @@ func SetCartItemQuantity(c echo.Context) error {
 // @Tags         cart
 // @Param Authorization header string true "With the bearer started"
 // @Produce      json
+// @Param currency query string false "The currency the prices are shown in, each price is shown in its own currency when empty"
 // @Success      200  "Success"
+// @Failure      400  "Invalid currency" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/cart [get]
 func GetCartItems(c echo.Context) error {
+	currency, err := displayCurrency(c)
+	if err != nil {
+		return err
+	}
+
 	currentUser := utils.GetCurrentUser(c)
 	cart, err := carts.FindUserCart(currentUser.ID)
 
@@ -138,5 +157,12 @@ func GetCartItems(c echo.Context) error {
 		return common.ErrorInternalServerError
 	}
 
+	if len(currency) > 0 {
+		if err := currencies.ConvertCart(&cart, currency); err != nil {
+			c.Logger().Error(err)
+			return common.ErrorInternalServerError
+		}
+	}
+
 	return c.JSON(http.StatusOK, cart)
 }
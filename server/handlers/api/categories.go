@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"order-system/common"
+	"order-system/services/categories"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetCategories godoc
+// @Summary      Get the category tree
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Success      200  {array}  dto.CategoryDto
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/categories [get]
+func GetCategories(c echo.Context) error {
+	tree, err := categories.FindCategoryTree()
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, tree)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"order-system/common"
+	"order-system/services/currencies"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetCurrencies godoc
+// @Summary      Get the currencies the prices can be shown and the orders paid in
+// @Tags         currencies
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Success      200  {object}  dto.CurrenciesDto
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/currencies [get]
+func GetCurrencies(c echo.Context) error {
+	result, err := currencies.FindCurrencies()
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// The currency the prices are shown in (`currency` query parameter),
+// empty when the prices are shown in their own currency
+func displayCurrency(c echo.Context) (string, error) {
+	if len(c.QueryParam("currency")) == 0 {
+		return "", nil
+	}
+
+	currency, err := currencies.NormalizeCurrency(c.QueryParam("currency"))
+	if err != nil {
+		return "", &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	return currency, nil
+}
@@ -3,14 +3,18 @@ package api
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"net/http"
 	"order-system/common"
+	"order-system/config"
 	"order-system/handlers/dto"
 	"order-system/models"
+	"order-system/services/currencies"
 	"order-system/services/orders"
 	"order-system/utils"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -21,7 +25,9 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
+// @Param payload query dto.PaginationQuery false "Pagination request, see the filter, sort and pagination spec in the README"
 // @Success      200  "Success" {object} dto.PaginationResponse
+// @Failure      400  "Unknown or invalid filter / sort" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/orders [get]
 func GetAllOrders(c echo.Context) error {
@@ -31,8 +37,7 @@ func GetAllOrders(c echo.Context) error {
 	paginatedRes, err := orders.FindAllOrdersOfUser(currentUser.ID, *p)
 
 	if err != nil {
-		c.Logger().Error(err)
-		return common.ErrorInternalServerError
+		return utils.ListError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, paginatedRes)
@@ -93,14 +98,71 @@ func GetOrder(c echo.Context) error {
 	return c.JSON(http.StatusOK, order)
 }
 
+// GetOrderHistory godoc
+// @Summary      Get the status history of an order, with the time spent in each status and the SLA breaches
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Order id"
+// @Success      200  "Success" {object} dto.OrderHistoryDto
+// @Failure      404  "Order not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/orders/:id/history [get]
+func GetOrderHistory(c echo.Context) error {
+	oId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	// history could be get by user or vendor
+	currentUser := utils.GetCurrentUser(c)
+	vendorOrderExists, err := orders.OrderExists(currentUser.ID, uint(oId), true)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	if !vendorOrderExists {
+		userOrderExists, err := orders.OrderExists(currentUser.ID, uint(oId), false)
+
+		if err != nil {
+			c.Logger().Error(err.Error())
+			return common.ErrorInternalServerError
+		}
+
+		if !userOrderExists {
+			return &echo.HTTPError{
+				Code:    http.StatusNotFound,
+				Message: common.ErrorResourceNotFound.Error(),
+			}
+		}
+	}
+
+	history, err := orders.FindOrderHistory(uint(oId))
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
 // CreateOrders godoc
 // @Summary      Create orders based on chosen cart items
+// @Description  The cart items are ordered at the current prices of their products. When a price went up since an item was added to the cart, nothing is ordered and the changed prices are returned, the orders can be placed again with the returned prices in acceptedPrices. The price of a cart item is honored during PRICE_GRACE_PERIOD after a change. A couponCode takes its discount off the eligible items of the orders. The taxes depend on the recipientCountry and recipientRegion, and on the tax category of the products. The orders are paid in the given currency (the base currency when empty), the prices are converted at the exchange rates in effect which are kept with the orders
 // @Tags         orders
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
 // @Param payload body dto.OrdersCreateDto true "The information of the orders to be created"
 // @Success      200  "Success"
+// @Failure      400  "Invalid payment method / Insufficient stock quantity (with the short products) / Product without a current price / Coupon not applicable or used up / Invalid currency, or a currency without an exchange rate / No warehouse of the vendor covers the order" {object}  echo.HTTPError
+// @Failure      409  "Prices changed (with the changed products and their current price)" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/orders [post]
 func CreateOrders(c echo.Context) error {
@@ -110,6 +172,14 @@ func CreateOrders(c echo.Context) error {
 		return err
 	}
 
+	currency, err := currencies.NormalizeCurrency(payload.Currency)
+	if err != nil {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
 	currentUser := utils.GetCurrentUser(c)
 
 	newOrders := []models.Order{}
@@ -119,8 +189,11 @@ func CreateOrders(c echo.Context) error {
 			UserID:          currentUser.ID,
 			PaymentMethodID: payload.PaymentMethodId,
 			ShippingAddress: payload.RecipientAddress,
+			ShippingCountry: strings.ToUpper(payload.RecipientCountry),
+			ShippingRegion:  strings.ToUpper(strings.TrimSpace(payload.RecipientRegion)),
 			RecipientName:   payload.RecipientName,
 			RecipientPhone:  payload.RecipientPhone,
+			Currency:        currency,
 		})
 
 		for _, item := range order.Items {
@@ -131,9 +204,48 @@ func CreateOrders(c echo.Context) error {
 		}
 	}
 
-	err := orders.CreateOrders(currentUser.ID, newOrders)
+	acceptedPrices := make(map[uint]uint)
+	for _, accepted := range payload.AcceptedPrices {
+		acceptedPrices[accepted.ProductID] = accepted.ProductPriceID
+	}
+
+	err = orders.CreateOrders(currentUser.ID, newOrders, orders.Checkout{
+		AcceptedPrices: acceptedPrices,
+		CouponCode:     payload.CouponCode,
+	})
 
 	if err != nil {
+		var stockErr *common.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			return &echo.HTTPError{
+				Code: http.StatusBadRequest,
+				Message: map[string]interface{}{
+					"error": common.ErrorInsufficientQuantity.Error(),
+					"items": stockErr.Items,
+				},
+			}
+		}
+
+		var priceErr *common.PriceChangedError
+		if errors.As(err, &priceErr) {
+			return &echo.HTTPError{
+				Code: http.StatusConflict,
+				Message: map[string]interface{}{
+					"error": common.ErrorPricesChanged.Error(),
+					"items": priceErr.Items,
+				},
+			}
+		}
+
+		if errors.Is(err, common.ErrorPaymentMethodInvalid) || errors.Is(err, common.ErrorPriceUnavailable) ||
+			errors.Is(err, common.ErrorCouponNotApplicable) || errors.Is(err, common.ErrorCouponUsageExceeded) ||
+			errors.Is(err, common.ErrorExchangeRateMissing) || errors.Is(err, common.ErrorNoFulfillingWarehouse) {
+			return &echo.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
 		c.Logger().Error(err.Error())
 		return common.ErrorInternalServerError
 	}
@@ -151,6 +263,7 @@ func CreateOrders(c echo.Context) error {
 // @Param id  path int true "The id of the order to be cancelled"
 // @Success      200  "Success"
 // @Failure      400  "invalid payload" echo.HTTPError
+// @Failure      409  "The order cannot be cancelled in its current status" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/orders/:id/cancel [post]
 func CancelOrder(c echo.Context) error {
@@ -184,9 +297,21 @@ func CancelOrder(c echo.Context) error {
 		}
 	}
 
-	err = orders.CancelOrder(uint(oId))
+	err = orders.CancelOrder(uint(oId), orders.TransitionRequest{
+		ActorID:   currentUser.ID,
+		ActorRole: models.ActorBuyer,
+		Reason:    payload.Reason,
+	})
 
 	if err != nil {
+		var transitionErr *common.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			return &echo.HTTPError{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			}
+		}
+
 		c.Logger().Error(err.Error())
 		return common.ErrorInternalServerError
 	}
@@ -228,8 +353,12 @@ func ExportCSV(c echo.Context) error {
 
 	b := &bytes.Buffer{}
 	writer := csv.NewWriter(b)
+	// the amounts are in the currency of each order, the total is
+	// also given in the base currency at the rate of the checkout
+	baseCurrency := config.GetConfig().BaseCurrency
 	writer.Write(
-		[]string{"Id", "Vendor", "Created At", "Updated At", "Total (USD)", "Status"},
+		[]string{"Id", "Vendor", "Created At", "Updated At", "Currency", "Subtotal", "Discount", "Tax", "Total",
+			fmt.Sprintf("Total (%s)", baseCurrency), "Status"},
 	)
 	for _, order := range filteredOrders.Items {
 		writer.Write([]string{
@@ -237,7 +366,12 @@ func ExportCSV(c echo.Context) error {
 			order.VendorName,
 			order.CreatedAt.Format("Jan 02 2006 15:04 -0700"),
 			order.UpdatedAt.Format("Jan 02 2006 15:04 -0700"),
+			order.Currency,
+			order.Subtotal.String(),
+			order.Discount.String(),
+			order.Tax.String(),
 			order.TotalPrice.String(),
+			order.TotalPrice.Mul(order.ExchangeRate).Round(2).String(),
 			string(order.Status),
 		})
 	}
@@ -249,3 +383,82 @@ func ExportCSV(c echo.Context) error {
 
 	return err
 }
+
+// TransitionOrder godoc
+// @Summary      Move an order to a target status (by its buyer or its vendor)
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id  path int true "Order id"
+// @Param payload body dto.OrderTransitionRequest true "The target status, the reason and the tracking information"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request" {object}  echo.HTTPError
+// @Failure      404  "Order not found" {object}  echo.HTTPError
+// @Failure      409  "The transition is not allowed" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/orders/:id/transitions [post]
+func TransitionOrder(c echo.Context) error {
+	payload := new(dto.OrderTransitionRequest)
+
+	oId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	// the role of the current user is relative to the order
+	currentUser := utils.GetCurrentUser(c)
+	actorRole := models.ActorVendor
+	exists, err := orders.OrderExists(currentUser.ID, uint(oId), true)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	if !exists {
+		actorRole = models.ActorBuyer
+		exists, err = orders.OrderExists(currentUser.ID, uint(oId), false)
+
+		if err != nil {
+			c.Logger().Error(err.Error())
+			return common.ErrorInternalServerError
+		}
+	}
+
+	if !exists {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: common.ErrorResourceNotFound.Error(),
+		}
+	}
+
+	err = orders.TransitionOrder(uint(oId), payload.Status, orders.TransitionRequest{
+		ActorID:         currentUser.ID,
+		ActorRole:       actorRole,
+		Reason:          payload.Reason,
+		TrackingCarrier: payload.TrackingCarrier,
+		TrackingNumber:  payload.TrackingNumber,
+	})
+
+	if err != nil {
+		var transitionErr *common.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			return &echo.HTTPError{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			}
+		}
+
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.NoContent(http.StatusOK)
+}
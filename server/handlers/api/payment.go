@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"order-system/common"
+	"order-system/services/orders"
 	"order-system/services/payments"
+	"order-system/utils"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
@@ -27,3 +31,109 @@ func GetSupportedPaymentMethods(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+// GetOrderPayment godoc
+// @Summary      Get the payment of an order, a pending payment is checked with its provider
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Order id"
+// @Success      200  "Success" {object} models.PaymentIntent
+// @Failure      404  "Order not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/orders/:id/payment [get]
+func GetOrderPayment(c echo.Context) error {
+	oId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	// the payment could be get by user or vendor
+	currentUser := utils.GetCurrentUser(c)
+	vendorOrderExists, err := orders.OrderExists(currentUser.ID, uint(oId), true)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	if !vendorOrderExists {
+		userOrderExists, err := orders.OrderExists(currentUser.ID, uint(oId), false)
+
+		if err != nil {
+			c.Logger().Error(err.Error())
+			return common.ErrorInternalServerError
+		}
+
+		if !userOrderExists {
+			return &echo.HTTPError{
+				Code:    http.StatusNotFound,
+				Message: common.ErrorResourceNotFound.Error(),
+			}
+		}
+	}
+
+	intent, err := orders.RefreshOrderPayment(uint(oId))
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, intent)
+}
+
+// RetryOrderPayment godoc
+// @Summary      Retry the failed payment of an user's order
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Order id"
+// @Success      200  "Success" {object} models.PaymentIntent
+// @Failure      400  "The payment cannot be retried" {object}  echo.HTTPError
+// @Failure      404  "Order not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/orders/:id/payment/retry [post]
+func RetryOrderPayment(c echo.Context) error {
+	oId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+	exists, err := orders.OrderExists(currentUser.ID, uint(oId), false)
+
+	if err != nil {
+		c.Logger().Error(err.Error())
+		return common.ErrorInternalServerError
+	}
+
+	if !exists {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: common.ErrorResourceNotFound.Error(),
+		}
+	}
+
+	intent, err := orders.ProcessOrderPayment(uint(oId))
+
+	if err != nil {
+		if errors.Is(err, common.ErrorPaymentNotRetryable) {
+			return &echo.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, intent)
+}
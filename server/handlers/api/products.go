@@ -1,9 +1,11 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"order-system/common"
 	"order-system/handlers/dto"
+	"order-system/services/currencies"
 	"order-system/services/products"
 	"order-system/utils"
 
@@ -11,25 +13,45 @@ import (
 )
 
 // GetAvailableProducts godoc
-// @Summary      Get available products (in-stock products)
+// @Summary      Search the available products (in-stock products by default)
 // @Tags         products
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
-// @Param payload query dto.PaginationQuery false "Pagination request"
+// @Param payload query dto.PaginationQuery false "Pagination request, see the filter, sort and pagination spec in the README. The `q` (full-text search), `inStock` and `category` (with its descendants) filters are also supported. The price is filtered and sorted on by its value in the base currency"
+// @Param currency query string false "The currency the prices are shown in, each price is shown in its own currency when empty"
 // @Success      200  "Success" {object} dto.PaginationResponse
+// @Failure      400  "Unknown or invalid filter / sort / Category not found / Invalid currency" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/products [get]
 func GetAvailableProducts(c echo.Context) error {
 	p := dto.ParsePaginationRequest(c)
 
+	currency, err := displayCurrency(c)
+	if err != nil {
+		return err
+	}
+
 	currentUser := utils.GetCurrentUser(c)
 
 	paginatedRes, err := products.FindAvailableProducts(currentUser.ID, *p)
 
 	if err != nil {
-		c.Logger().Error(err)
-		return common.ErrorInternalServerError
+		if errors.Is(err, common.ErrorCategoryNotFound) {
+			return &echo.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
+		return utils.ListError(c, err)
+	}
+
+	if len(currency) > 0 {
+		if err := currencies.ConvertProducts(paginatedRes.Items, currency); err != nil {
+			c.Logger().Error(err)
+			return common.ErrorInternalServerError
+		}
 	}
 
 	return c.JSON(http.StatusOK, paginatedRes)
@@ -0,0 +1,73 @@
+package vendors
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/alerts"
+	"order-system/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetStockAlerts godoc
+// @Summary      Get the low-stock alerts of the current vendor
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload query dto.PaginationQuery false "Pagination request, filters: acknowledged (true/false)"
+// @Success      200  "Success" {object} dto.PaginationResponse
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/stock-alerts [get]
+func GetStockAlerts(c echo.Context) error {
+	currentUser := utils.GetCurrentUser(c)
+	p := dto.ParsePaginationRequest(c)
+
+	res, err := alerts.FindStockAlertsOfVendor(currentUser.ID, *p)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// AcknowledgeStockAlert godoc
+// @Summary      Acknowledge a low-stock alert
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Stock alert id"
+// @Success      200  "Success"
+// @Failure      404  "Stock alert not found (or already acknowledged)" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/stock-alerts/:id/acknowledge [post]
+func AcknowledgeStockAlert(c echo.Context) error {
+	aId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	if err := alerts.AcknowledgeStockAlert(currentUser.ID, uint(aId)); err != nil {
+		if errors.Is(err, common.ErrorResourceNotFound) {
+			return &echo.HTTPError{
+				Code:    http.StatusNotFound,
+				Message: common.ErrorResourceNotFound.Error(),
+			}
+		}
+
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,122 @@
+package vendors
+
+import (
+	"bytes"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/products"
+	"order-system/services/warehouses"
+	"order-system/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportCatalog godoc
+// @Summary      Create or update products from a catalog file, matched by SKU
+// @Description  Columns (CSV) or keys (JSON lines): sku, name, description, unit, price (a blank one keeps the current price, required for new products), currency (the base currency when empty), stock. The stock is only used for new products
+// @Tags         vendor-products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param file formData file true "Catalog file"
+// @Param format query string false "csv (default) or jsonl"
+// @Success      200  {object}  dto.CatalogImportReport
+// @Failure      400  "Invalid file" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/import [post]
+func ImportCatalog(c echo.Context) error {
+	format := catalogFormat(c)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidCSV.Error(),
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+	defer file.Close()
+
+	var rows []dto.CatalogRow
+	if format == products.CatalogFormatJSONLines {
+		rows, err = products.ParseCatalogJSONLines(file)
+	} else {
+		rows, err = products.ParseCatalogCSV(file)
+	}
+
+	if err != nil {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidCSV.Error(),
+		}
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	warehouse, err := warehouses.FindOrCreateDefaultWarehouse(currentUser.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	report, err := products.ImportCatalog(currentUser.ID, &warehouse.ID, rows)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// ExportCatalog godoc
+// @Summary      Export the products of the current vendor in the catalog import format
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      text/csv
+// @Param Authorization header string true "With the bearer started"
+// @Param format query string false "csv (default) or jsonl"
+// @Success      200  "Success"
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/export [get]
+func ExportCatalog(c echo.Context) error {
+	format := catalogFormat(c)
+	currentUser := utils.GetCurrentUser(c)
+
+	rows, err := products.FindCatalogOfVendor(currentUser.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	b := &bytes.Buffer{}
+	if format == products.CatalogFormatJSONLines {
+		err = products.WriteCatalogJSONLines(b, rows)
+		c.Response().Header().Set("Content-Type", "application/x-ndjson")
+		c.Response().Header().Set("Content-Disposition", "attachment;filename=catalog.jsonl")
+	} else {
+		err = products.WriteCatalogCSV(b, rows)
+		c.Response().Header().Set("Content-Type", "text/csv")
+		c.Response().Header().Set("Content-Disposition", "attachment;filename=catalog.csv")
+	}
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	_, err = c.Response().Write(b.Bytes())
+	return err
+}
+
+func catalogFormat(c echo.Context) string {
+	if c.QueryParam("format") == products.CatalogFormatJSONLines {
+		return products.CatalogFormatJSONLines
+	}
+	return products.CatalogFormatCSV
+}
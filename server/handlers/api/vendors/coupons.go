@@ -0,0 +1,113 @@
+package vendors
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/promotions"
+	"order-system/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetCoupons godoc
+// @Summary      Get the coupons of the current vendor, with their number of uses
+// @Tags         vendor-coupons
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Success      200  {array}  models.Coupon
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/coupons [get]
+func GetCoupons(c echo.Context) error {
+	coupons, err := promotions.FindCoupons(utils.GetCurrentUser(c).ID)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, coupons)
+}
+
+// CreateCoupon godoc
+// @Summary      Create a coupon on the products of the current vendor
+// @Description  A percentage (up to 100) or a fixed amount taken off the products of the vendor, or off the given products only. The code is case insensitive
+// @Tags         vendor-coupons
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.CouponDto true "Coupon to be created, vendorId is ignored"
+// @Success      200  {object}  models.Coupon
+// @Failure      400  "Invalid request / Invalid coupon (code, value, limits, window or products of another vendor)" {object}  echo.HTTPError
+// @Failure      409  "Code already used" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/coupons [post]
+func CreateCoupon(c echo.Context) error {
+	payload := new(dto.CouponDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	coupon, err := promotions.CreateCoupon(currentUser.ID, &currentUser.ID, *payload)
+	if err != nil {
+		return couponError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, coupon)
+}
+
+// DeactivateCoupon godoc
+// @Summary      Deactivate a coupon of the current vendor
+// @Tags         vendor-coupons
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Coupon id"
+// @Success      200  "Success"
+// @Failure      404  "Coupon not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/coupons/:id [delete]
+func DeactivateCoupon(c echo.Context) error {
+	couponId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := promotions.DeactivateCoupon(utils.GetCurrentUser(c).ID, uint(couponId)); err != nil {
+		return couponError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func couponError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorInvalidCoupon) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorCouponCodeExists) {
+		return &echo.HTTPError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorResourceNotFound) {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}
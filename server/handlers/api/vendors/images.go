@@ -0,0 +1,241 @@
+package vendors
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/config"
+	"order-system/handlers/dto"
+	"order-system/services/media"
+	"order-system/services/products"
+	"order-system/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetProductImages godoc
+// @Summary      Get the images of a product, ordered by position
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Success      200  {array}  dto.ProductImageDto
+// @Failure      403  "Insufficient permission (when try to get the images of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/images [get]
+func GetProductImages(c echo.Context) error {
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	images, err := products.FindProductImages(productId)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, images)
+}
+
+// UploadProductImages godoc
+// @Summary      Upload images of a product, they are added after the current ones
+// @Description  JPEG, PNG or GIF, the content type is detected from the content. A thumbnail is generated for each image. The first image of a product becomes its primary image
+// @Tags         vendor-products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param images formData file true "Images, the field can be repeated"
+// @Success      200  {array}  dto.ProductImageDto
+// @Failure      400  "Invalid image" {object} echo.HTTPError
+// @Failure      403  "Insufficient permission (when try to upload images of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      413  "Image too large" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/images [post]
+func UploadProductImages(c echo.Context) error {
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["images"]) == 0 {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidImage.Error(),
+		}
+	}
+
+	// all the images are checked before any of them is stored
+	images := []*media.Image{}
+	for _, fileHeader := range form.File["images"] {
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.Logger().Error(err)
+			return common.ErrorInternalServerError
+		}
+
+		image, err := media.ProcessImage(file, config.GetConfig().ImageMaxSize)
+		file.Close()
+
+		if err != nil {
+			return imageError(c, err)
+		}
+
+		images = append(images, image)
+	}
+
+	created, err := products.AddProductImages(productId, images)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, created)
+}
+
+// ReorderProductImages godoc
+// @Summary      Set the order of the images of a product
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param payload body dto.ReorderProductImagesDto true "All the images of the product, in their new order"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request / The images are not all the images of the product" {object} echo.HTTPError
+// @Failure      403  "Insufficient permission (when try to reorder the images of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/images/order [put]
+func ReorderProductImages(c echo.Context) error {
+	payload := new(dto.ReorderProductImagesDto)
+
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	if err := products.ReorderProductImages(productId, payload.ImageIDs); err != nil {
+		return imageError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// SetPrimaryProductImage godoc
+// @Summary      Set the primary image of a product
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param imageId path int true "Image id"
+// @Success      200  "Success"
+// @Failure      403  "Insufficient permission (when try to update the images of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      404  "Image not found" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/images/:imageId/primary [put]
+func SetPrimaryProductImage(c echo.Context) error {
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	imageId, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := products.SetPrimaryProductImage(productId, uint(imageId)); err != nil {
+		return imageError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// DeleteProductImage godoc
+// @Summary      Delete an image of a product, the next one becomes the primary image if needed
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param imageId path int true "Image id"
+// @Success      200  "Success"
+// @Failure      403  "Insufficient permission (when try to delete an image of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      404  "Image not found" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/images/:imageId [delete]
+func DeleteProductImage(c echo.Context) error {
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	imageId, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := products.DeleteProductImage(productId, uint(imageId)); err != nil {
+		return imageError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// The product of the path, it has to belong to the current vendor
+func findVendorProductId(c echo.Context) (uint, error) {
+	pId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.ErrNotFound
+	}
+
+	product, err := products.FindProductById(uint(pId))
+	if err != nil {
+		c.Logger().Error(err)
+		return 0, common.ErrorInternalServerError
+	}
+
+	if product.VendorID != utils.GetCurrentUser(c).ID {
+		return 0, &echo.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: common.ErrorInsufficientPermission.Error(),
+		}
+	}
+
+	return uint(pId), nil
+}
+
+func imageError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorInvalidImage) || errors.Is(err, common.ErrorInvalidImageOrder) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorImageTooLarge) {
+		return &echo.HTTPError{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorResourceNotFound) {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}
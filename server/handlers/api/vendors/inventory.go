@@ -1,11 +1,16 @@
 package vendors
 
 import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
 	"order-system/common"
 	"order-system/handlers/dto"
 	"order-system/models"
 	"order-system/services/products"
+	"order-system/services/warehouses"
 	"order-system/utils"
 	"strconv"
 
@@ -22,6 +27,7 @@ import (
 // @Param id path int true "Product id"
 // @Success      200  "Success"
 // @Failure      400  "Invalid request / Insufficient stock quantity" {object} echo.HTTPError
+// @Failure      404  "Warehouse not found" {object} echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/products/:id/stocks [post]
 func UpdateProductStock(c echo.Context) error {
@@ -53,29 +59,278 @@ func UpdateProductStock(c echo.Context) error {
 		}
 	}
 
-	var quantity int
-	if payload.Type == models.TransactionTypeIn {
-		err = products.ImportProductStock(uint(pId), int(payload.Quantity), payload.Description)
-	} else {
-		quantity, err = products.FindProductStockQuantity(uint(pId))
+	warehouse, err := findStockWarehouse(currentUser.ID, payload.WarehouseID)
 
-		if err != nil {
-			return common.ErrorInternalServerError
+	if err != nil {
+		if errors.Is(err, common.ErrorResourceNotFound) {
+			return &echo.HTTPError{
+				Code:    http.StatusNotFound,
+				Message: common.ErrorResourceNotFound.Error(),
+			}
 		}
 
-		if quantity < payload.Quantity {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	if payload.Type == models.TransactionTypeIn {
+		err = products.ImportWarehouseStock(uint(pId), &warehouse.ID, payload.Quantity, payload.Description)
+	} else {
+		err = products.ExportWarehouseStock(uint(pId), &warehouse.ID, payload.Quantity, payload.Description)
+	}
+
+	if err != nil {
+		if errors.Is(err, common.ErrorInsufficientQuantity) || errors.Is(err, common.ErrorInvalidQuantity) ||
+			errors.Is(err, common.ErrorProductHasVariants) {
 			return &echo.HTTPError{
 				Code:    http.StatusBadRequest,
-				Message: common.ErrorInsufficientQuantity.Error(),
+				Message: err.Error(),
 			}
 		}
 
-		err = products.ExportProductStock(uint(pId), int(payload.Quantity), payload.Description)
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// GetProductStockMovements godoc
+// @Summary      Get the stock movements (product transactions) of a product with the running balance
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param payload query dto.PaginationQuery false "Pagination request, filters: type, from, to, description"
+// @Success      200  "Success" {object} dto.PaginationResponse
+// @Failure      400  "Invalid filter" {object} echo.HTTPError
+// @Failure      403  "Insufficient permission" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/stocks [get]
+func GetProductStockMovements(c echo.Context) error {
+	pId, err := findProductIdOfVendor(c)
+	if err != nil {
+		return err
+	}
+
+	p := dto.ParsePaginationRequest(c)
+
+	res, err := products.FindStockMovements(pId, *p)
+
+	if err != nil {
+		return stockMovementsError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// ExportProductStockMovementsCSV godoc
+// @Summary      Export the stock movements of a product to CSV
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      text/csv
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param type query string false "Type of the movements (in, out, transfer)"
+// @Param from query string false "Start date (2006-01-02 or RFC 3339)"
+// @Param to query string false "End date, inclusive (2006-01-02 or RFC 3339)"
+// @Param description query string false "Text searched in the description"
+// @Success      200  "Success"
+// @Failure      400  "Invalid filter" {object} echo.HTTPError
+// @Failure      403  "Insufficient permission" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/stocks/export-csv [get]
+func ExportProductStockMovementsCSV(c echo.Context) error {
+	pId, err := findProductIdOfVendor(c)
+	if err != nil {
+		return err
+	}
+
+	filters := make(map[string]string)
+	for _, key := range []string{"type", "from", "to", "description"} {
+		if value := c.QueryParam(key); len(value) > 0 {
+			filters[key] = value
+		}
+	}
+
+	res, err := products.FindStockMovements(pId, dto.PaginationQuery{
+		Filters: filters,
+	})
+
+	if err != nil {
+		return stockMovementsError(c, err)
+	}
+
+	b := writeStockMovementsCSV(res.Items)
+	c.Response().Header().Set("Content-Type", "text/csv")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=stock-movements-%d.csv", pId))
+	_, err = c.Response().Write(b.Bytes())
+
+	return err
+}
+
+func writeStockMovementsCSV(movements []dto.StockMovementDto) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	writer := csv.NewWriter(b)
+	writer.Write(
+		[]string{"Id", "Created At", "Type", "Description", "Warehouse", "Quantity", "Balance"},
+	)
+	for _, movement := range movements {
+		writer.Write([]string{
+			fmt.Sprintf("%d", movement.ID),
+			movement.CreatedAt.Format("Jan 02 2006 15:04 -0700"),
+			string(movement.Type),
+			movement.Description,
+			movement.WarehouseName,
+			movement.Quantity.String(),
+			movement.Balance.String(),
+		})
+	}
+
+	writer.Flush()
+	return b
+}
+
+// ImportStocks godoc
+// @Summary      Import stock changes of many products from a CSV file
+// @Description  Columns: product_id or sku, quantity, type (in/out), description, warehouse_id (optional). Nothing is applied when any row is invalid
+// @Tags         vendor-products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param file formData file true "CSV file"
+// @Param dryRun query bool false "Only validate the rows"
+// @Success      200  {object}  dto.StockImportReport
+// @Failure      400  "Invalid CSV file" {object} echo.HTTPError
+// @Failure      422  "Some rows are invalid" {object} dto.StockImportReport
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/stocks/import [post]
+func ImportStocks(c echo.Context) error {
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dryRun"))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidCSV.Error(),
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+	defer file.Close()
+
+	rows, err := products.ParseStockImportCSV(file)
+	if err != nil {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidCSV.Error(),
+		}
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	report, err := products.ImportStockRows(currentUser.ID, rows, dryRun)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	if report.Errors > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// SetLowStockThreshold godoc
+// @Summary      Set the low-stock threshold of a product, 0 disables the alerts
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param payload body dto.SetLowStockThresholdDto true "Low-stock threshold"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request" {object} echo.HTTPError
+// @Failure      403  "Insufficient permission" {object} echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/low-stock-threshold [put]
+func SetLowStockThreshold(c echo.Context) error {
+	payload := new(dto.SetLowStockThresholdDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	if payload.Threshold.IsNegative() {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidThreshold.Error(),
+		}
 	}
 
+	pId, err := findProductIdOfVendor(c)
 	if err != nil {
+		return err
+	}
+
+	if err := products.SetLowStockThreshold(pId, payload.Threshold); err != nil {
+		c.Logger().Error(err)
 		return common.ErrorInternalServerError
 	}
 
 	return c.NoContent(http.StatusOK)
 }
+
+// Parse the product id of the path, the product
+// must belong to the current vendor
+func findProductIdOfVendor(c echo.Context) (uint, error) {
+	pId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		return 0, common.ErrorInternalServerError
+	}
+
+	product, err := products.FindProductById(uint(pId))
+
+	if err != nil {
+		c.Logger().Error(err)
+		return 0, common.ErrorInternalServerError
+	}
+
+	if product.VendorID != utils.GetCurrentUser(c).ID {
+		return 0, &echo.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: common.ErrorInsufficientPermission.Error(),
+		}
+	}
+
+	return uint(pId), nil
+}
+
+func stockMovementsError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorInvalidFilter) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorInvalidFilter.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}
+
+// The warehouse a stock change goes to, the default
+// warehouse of the vendor when none is given
+func findStockWarehouse(vendorId uint, warehouseId *uint) (models.Warehouse, error) {
+	if warehouseId == nil {
+		return warehouses.FindOrCreateDefaultWarehouse(vendorId)
+	}
+
+	return warehouses.FindWarehouseOfVendor(vendorId, *warehouseId)
+}
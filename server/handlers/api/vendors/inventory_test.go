@@ -0,0 +1,41 @@
+package vendors
+
+import (
+	"order-system/handlers/dto"
+	"order-system/models"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteStockMovementsCSV(t *testing.T) {
+	movements := []dto.StockMovementDto{
+		{
+			ID:            1,
+			CreatedAt:     time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC),
+			Type:          models.TransactionTypeIn,
+			Description:   "Restock",
+			WarehouseName: "Main",
+			Quantity:      decimal.RequireFromString("12.5"),
+			Balance:       decimal.RequireFromString("12.5"),
+		},
+		{
+			ID:          2,
+			CreatedAt:   time.Date(2026, 1, 3, 10, 0, 0, 0, time.UTC),
+			Type:        models.TransactionTypeOut,
+			Description: "Order",
+			Quantity:    decimal.NewFromInt(-2),
+			Balance:     decimal.RequireFromString("10.5"),
+		},
+	}
+
+	expected := "Id,Created At,Type,Description,Warehouse,Quantity,Balance\n" +
+		"1,Jan 02 2026 09:30 +0000,in,Restock,Main,12.5,12.5\n" +
+		"2,Jan 03 2026 10:00 +0000,out,Order,,-2,10.5\n"
+
+	if actual := writeStockMovementsCSV(movements).String(); actual != expected {
+		t.Log("expected: v", expected)
+		t.Error("actual: v", actual)
+	}
+}
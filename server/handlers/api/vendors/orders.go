@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"net/http"
 	"order-system/common"
+	"order-system/config"
 	"order-system/handlers/dto"
+	"order-system/models"
 	"order-system/services/orders"
 	"order-system/utils"
 	"strconv"
@@ -21,8 +23,9 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
-// @Param payload query dto.PaginationQuery false "Pagination request"
+// @Param payload query dto.PaginationQuery false "Pagination request, see the filter, sort and pagination spec in the README"
 // @Success      200  "Success"
+// @Failure      400  "Unknown or invalid filter / sort" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/orders [get]
 func GetAllVendorOrders(c echo.Context) error {
@@ -32,7 +35,7 @@ func GetAllVendorOrders(c echo.Context) error {
 	res, err := orders.FindAllOrdersOfVendor(currentUser.ID, *p)
 
 	if err != nil {
-		return common.ErrorInternalServerError
+		return utils.ListError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, res)
@@ -46,7 +49,7 @@ func GetAllVendorOrders(c echo.Context) error {
 // @Param Authorization header string true "With the bearer started"
 // @Param id path int true "Order id"
 // @Success      200  "Success"
-// @Failure      400  "The order has reached its final state" {object}  echo.HTTPError
+// @Failure      409  "The order has reached its final state / is waiting for its payment" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/orders/:id [put]
 func OrderNextStatus(c echo.Context) error {
@@ -73,11 +76,12 @@ func OrderNextStatus(c echo.Context) error {
 		}
 	}
 
-	if err := orders.SetNextStatusForOrder(uint(oId)); err != nil {
-		if errors.Is(err, common.ErrorOrderFinalStateReached) {
+	if err := orders.SetNextStatusForOrder(uint(oId), currentUser.ID); err != nil {
+		var transitionErr *common.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
 			return &echo.HTTPError{
-				Code:    http.StatusBadRequest,
-				Message: common.ErrorOrderFinalStateReached.Error(),
+				Code:    http.StatusConflict,
+				Message: err.Error(),
 			}
 		}
 
@@ -99,6 +103,7 @@ func OrderNextStatus(c echo.Context) error {
 // @Success      200  "Success"
 // @Failure      400  "Invalid request" {object}  echo.HTTPError
 // @Failure      404  "Order not found (or not belongs to current logged in vendor)" {object}  echo.HTTPError
+// @Failure      409  "The order cannot be cancelled in its current status" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/orders/:id/cancel [post]
 func CancelOrder(c echo.Context) error {
@@ -136,9 +141,21 @@ func CancelOrder(c echo.Context) error {
 		return err
 	}
 
-	err = orders.CancelOrder(uint(oId))
+	err = orders.CancelOrder(uint(oId), orders.TransitionRequest{
+		ActorID:   currentUser.ID,
+		ActorRole: models.ActorVendor,
+		Reason:    payload.Reason,
+	})
 
 	if err != nil {
+		var transitionErr *common.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			return &echo.HTTPError{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			}
+		}
+
 		c.Logger().Error(err)
 		return common.ErrorInternalServerError
 	}
@@ -180,8 +197,12 @@ func ExportCSV(c echo.Context) error {
 
 	b := &bytes.Buffer{}
 	writer := csv.NewWriter(b)
+	// the amounts are in the currency of each order, the total is
+	// also given in the base currency at the rate of the checkout
+	baseCurrency := config.GetConfig().BaseCurrency
 	writer.Write(
-		[]string{"Id", "Recipient Name", "Recipient Phone", "Address", "Created At", "Updated At", "Total (USD)", "Status"},
+		[]string{"Id", "Recipient Name", "Recipient Phone", "Address", "Created At", "Updated At", "Currency", "Subtotal", "Discount", "Tax", "Total",
+			fmt.Sprintf("Total (%s)", baseCurrency), "Status"},
 	)
 	for _, order := range filteredOrders.Items {
 		writer.Write([]string{
@@ -191,7 +212,12 @@ func ExportCSV(c echo.Context) error {
 			order.ShippingAddress,
 			order.CreatedAt.Format("Jan 02 2006 15:04 -0700"),
 			order.UpdatedAt.Format("Jan 02 2006 15:04 -0700"),
+			order.Currency,
+			order.Subtotal.String(),
+			order.Discount.String(),
+			order.Tax.String(),
 			order.TotalPrice.String(),
+			order.TotalPrice.Mul(order.ExchangeRate).Round(2).String(),
 			string(order.Status),
 		})
 	}
@@ -1,6 +1,7 @@
 package vendors
 
 import (
+	"errors"
 	"net/http"
 	"order-system/common"
 	"order-system/handlers/dto"
@@ -20,7 +21,8 @@ import (
 // @Param Authorization header string true "With the bearer started"
 // @Param payload body dto.CreateProductDto true "Product to be created"
 // @Success      200  "Success"
-// @Failure      400  "Invalid request" {object}  echo.HTTPError
+// @Failure      400  "Invalid request / Invalid unit / Invalid barcode / Category not found / Invalid options / Invalid tax category" {object}  echo.HTTPError
+// @Failure      409  "SKU already used by another product of the vendor" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/products [post]
 func CreateProduct(c echo.Context) error {
@@ -36,13 +38,17 @@ func CreateProduct(c echo.Context) error {
 		Name:        payload.Name,
 		Description: payload.Description,
 		VendorID:    currentUser.ID,
+		Unit:        payload.Unit,
+		SKU:         utils.NilIfEmpty(payload.SKU),
+		Barcode:     utils.NilIfEmpty(payload.Barcode),
+		CategoryID:  payload.CategoryID,
+		TaxCategory: payload.TaxCategory,
 	}
 
-	err := products.CreateProduct(newProduct)
+	err := products.CreateProduct(&newProduct, payload.Options)
 
 	if err != nil {
-		c.Logger().Error(err)
-		return common.ErrorInternalServerError
+		return productError(c, err)
 	}
 
 	newProduct.VendorID = currentUser.ID
@@ -58,8 +64,9 @@ func CreateProduct(c echo.Context) error {
 // @Param payload body dto.UpdateProductDto true "Update product request"
 // @Param id path int true "Product id"
 // @Success      200  "Success"
-// @Failure      400  "Invalid request" {object}  echo.HTTPError
+// @Failure      400  "Invalid request / Invalid unit / Invalid barcode / Category not found / Invalid tax category" {object}  echo.HTTPError
 // @Failure      403  "Insufficient permission (when try to update a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      409  "SKU already used by another product of the vendor" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/products/:id [put]
 func UpdateProduct(c echo.Context) error {
@@ -93,7 +100,41 @@ func UpdateProduct(c echo.Context) error {
 		}
 	}
 
-	return products.UpdateProduct(uint(pId), *payload)
+	if err := products.UpdateProduct(uint(pId), *payload); err != nil {
+		return productError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func productError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorInvalidUnit) || errors.Is(err, common.ErrorInvalidBarcode) ||
+		errors.Is(err, common.ErrorCategoryNotFound) || errors.Is(err, common.ErrorInvalidVariant) ||
+		errors.Is(err, common.ErrorProductHasVariants) || errors.Is(err, common.ErrorInvalidPriceWindow) ||
+		errors.Is(err, common.ErrorInvalidTaxCategory) || errors.Is(err, common.ErrorInvalidCurrency) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorResourceNotFound) {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, common.ErrorSKUExists) || errors.Is(err, common.ErrorVariantExists) ||
+		errors.Is(err, common.ErrorPriceNotCancellable) {
+		return &echo.HTTPError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
 }
 
 // GetAllVendorProducts godoc
@@ -102,8 +143,9 @@ func UpdateProduct(c echo.Context) error {
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
-// @Param payload query dto.PaginationQuery false "Pagination request"
+// @Param payload query dto.PaginationQuery false "Pagination request, see the filter, sort and pagination spec in the README"
 // @Success      200  "Success" {object} dto.PaginationResponse
+// @Failure      400  "Unknown or invalid filter / sort" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/products [get]
 func GetAllVendorProducts(c echo.Context) error {
@@ -113,23 +155,23 @@ func GetAllVendorProducts(c echo.Context) error {
 	paginatedRes, err := products.FindProductsOfVendor(currentUser.ID, *p)
 
 	if err != nil {
-		c.Logger().Error(err)
-		return common.ErrorInternalServerError
+		return utils.ListError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, paginatedRes)
 }
 
 // SetProductPrice godoc
-// @Summary     Set the unit price of a product
+// @Summary     Set the unit price of a product, right away or scheduled
+// @Description When several prices are in effect at the same instant, the one which took effect last wins (e.g. a sale over the regular price). A price is in the base currency unless a currency is given
 // @Tags         vendor-products
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
 // @Param payload body dto.SetProductPriceDto true "Set product price request"
 // @Param id path int true "Product id"
-// @Success      200  "Success"
-// @Failure      400  "Invalid request" {object}  echo.HTTPError
+// @Success      200  {object}  models.ProductPrice
+// @Failure      400  "Invalid request / Product with variants, its variants are priced instead / Start in the past or end before the start / Invalid currency" {object}  echo.HTTPError
 // @Failure      403  "Insufficient permission (when try to set price of a product that belongs other vendor)" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/products/:id/prices [post]
@@ -164,22 +206,23 @@ func SetProductPrice(c echo.Context) error {
 		}
 	}
 
-	if err := products.SetProductPrice(uint(pId), payload.Price); err != nil {
-		c.Logger().Error(err.Error())
-		return common.ErrorInternalServerError
+	price, err := products.ScheduleProductPrice(uint(pId), payload.Price, payload.Currency, payload.EffectiveFrom, payload.EffectiveUntil)
+	if err != nil {
+		return productError(c, err)
 	}
 
-	return c.NoContent(http.StatusOK)
+	return c.JSON(http.StatusOK, price)
 }
 
 // GetProductPrices godoc
-// @Summary      Get unit price history of a product
+// @Summary      Get unit price history of a product, the latest start first
 // @Tags         vendor-products
 // @Accept       json
 // @Produce      json
 // @Param Authorization header string true "With the bearer started"
 // @Param id path int true "Product id"
-// @Success      200  "Success"
+// @Param upcoming query bool false "Only the prices which have not started yet"
+// @Success      200  {array}  models.ProductPrice
 // @Failure      403  "Insufficient permission (when try to get price history of a product that belongs other vendor)" {object}  echo.HTTPError
 // @Failure      500  {object}  echo.HTTPError
 // @Router       /api/vendors/products/:id/prices [get]
@@ -209,7 +252,15 @@ func GetProductPrices(c echo.Context) error {
 		}
 	}
 
-	prices, err := products.GetProductPrices(uint(pId))
+	upcoming := false
+	if err := echo.QueryParamsBinder(c).Bool("upcoming", &upcoming).BindError(); err != nil {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	prices, err := products.GetProductPrices(uint(pId), upcoming)
 	if err != nil {
 		c.Logger().Error(err.Error())
 		return common.ErrorInternalServerError
@@ -217,3 +268,35 @@ func GetProductPrices(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, prices)
 }
+
+// CancelProductPrice godoc
+// @Summary      Cancel a price of a product which has not started yet
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param priceId path int true "Price id"
+// @Success      200  "Success"
+// @Failure      403  "Insufficient permission (when try to cancel a price of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      404  "Price not found" {object}  echo.HTTPError
+// @Failure      409  "The price has already started" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/prices/:priceId [delete]
+func CancelProductPrice(c echo.Context) error {
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	priceId, err := strconv.ParseUint(c.Param("priceId"), 10, 64)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := products.CancelProductPrice(productId, uint(priceId)); err != nil {
+		return productError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,72 @@
+package vendors
+
+import (
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/services/products"
+	"order-system/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetProductVariants godoc
+// @Summary      Get the variants of a product with their stock and latest price
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Success      200  {array}  dto.ProductVariantDto
+// @Failure      403  "Insufficient permission (when try to get the variants of a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/variants [get]
+func GetProductVariants(c echo.Context) error {
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	variants, err := products.FindVariants(productId)
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, variants)
+}
+
+// CreateProductVariant godoc
+// @Summary      Create a variant of a product with options
+// @Description  The variant takes one value for each option of the product, its name, unit and category are the ones of the product. It has its own prices and stock
+// @Tags         vendor-products
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param id path int true "Product id"
+// @Param payload body dto.CreateVariantDto true "Variant to be created"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request / Invalid options / Invalid barcode" {object}  echo.HTTPError
+// @Failure      403  "Insufficient permission (when try to add a variant to a product that belongs other vendor)" {object}  echo.HTTPError
+// @Failure      409  "Variant with the same options already exists / SKU already used by another product of the vendor" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/products/:id/variants [post]
+func CreateProductVariant(c echo.Context) error {
+	payload := new(dto.CreateVariantDto)
+
+	productId, err := findVendorProductId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	variant, err := products.CreateVariant(productId, *payload)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, variant)
+}
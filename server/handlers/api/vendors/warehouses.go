@@ -0,0 +1,195 @@
+package vendors
+
+import (
+	"errors"
+	"net/http"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/services/products"
+	"order-system/services/warehouses"
+	"order-system/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetWarehouses godoc
+// @Summary      Get all warehouses of the current vendor
+// @Tags         vendor-warehouses
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Success      200  {array}  models.Warehouse
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/warehouses [get]
+func GetWarehouses(c echo.Context) error {
+	currentUser := utils.GetCurrentUser(c)
+
+	result, err := warehouses.FindWarehousesOfVendor(currentUser.ID)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// CreateWarehouse godoc
+// @Summary      Create a new warehouse
+// @Tags         vendor-warehouses
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.WarehouseDto true "Warehouse to be created"
+// @Success      200  {object}  models.Warehouse
+// @Failure      400  "Invalid request" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/warehouses [post]
+func CreateWarehouse(c echo.Context) error {
+	payload := new(dto.WarehouseDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	newWarehouse := models.Warehouse{
+		VendorID:  currentUser.ID,
+		Name:      payload.Name,
+		Address:   payload.Address,
+		Priority:  payload.Priority,
+		IsDefault: payload.IsDefault,
+	}
+
+	if err := warehouses.CreateWarehouse(&newWarehouse); err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, newWarehouse)
+}
+
+// UpdateWarehouse godoc
+// @Summary      Update a warehouse
+// @Tags         vendor-warehouses
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.WarehouseDto true "Update warehouse request"
+// @Param id path int true "Warehouse id"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request" {object}  echo.HTTPError
+// @Failure      404  "Warehouse not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/warehouses/:id [put]
+func UpdateWarehouse(c echo.Context) error {
+	payload := new(dto.WarehouseDto)
+	wIdParam := c.Param("id")
+
+	wId, err := strconv.ParseUint(wIdParam, 10, 64)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	if _, err := warehouses.FindWarehouseOfVendor(currentUser.ID, uint(wId)); err != nil {
+		return warehouseError(c, err)
+	}
+
+	if err := warehouses.UpdateWarehouse(currentUser.ID, uint(wId), *payload); err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// TransferStock godoc
+// @Summary      Move product stock between two warehouses
+// @Tags         vendor-warehouses
+// @Accept       json
+// @Produce      json
+// @Param Authorization header string true "With the bearer started"
+// @Param payload body dto.StockTransferDto true "Stock transfer request"
+// @Success      200  "Success"
+// @Failure      400  "Invalid request / Insufficient stock quantity" {object}  echo.HTTPError
+// @Failure      403  "Insufficient permission (when the product belongs to other vendor)" {object}  echo.HTTPError
+// @Failure      404  "Warehouse not found" {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /api/vendors/warehouses/transfers [post]
+func TransferStock(c echo.Context) error {
+	payload := new(dto.StockTransferDto)
+
+	if err := utils.BindAndValidate(c, payload); err != nil {
+		return err
+	}
+
+	if payload.FromWarehouseID == payload.ToWarehouseID || !payload.Quantity.IsPositive() {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: common.ErrorStockTransferInvalid.Error(),
+		}
+	}
+
+	currentUser := utils.GetCurrentUser(c)
+
+	product, err := products.FindProductById(payload.ProductID)
+
+	if err != nil {
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	if product.VendorID != currentUser.ID {
+		return &echo.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: common.ErrorInsufficientPermission.Error(),
+		}
+	}
+
+	for _, warehouseId := range []uint{payload.FromWarehouseID, payload.ToWarehouseID} {
+		if _, err := warehouses.FindWarehouseOfVendor(currentUser.ID, warehouseId); err != nil {
+			return warehouseError(c, err)
+		}
+	}
+
+	err = warehouses.TransferStock(payload.ProductID, payload.FromWarehouseID, payload.ToWarehouseID,
+		payload.Quantity, payload.Description)
+
+	if err != nil {
+		if errors.Is(err, common.ErrorInsufficientQuantity) || errors.Is(err, common.ErrorInvalidQuantity) ||
+			errors.Is(err, common.ErrorProductHasVariants) {
+			return &echo.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
+		c.Logger().Error(err)
+		return common.ErrorInternalServerError
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func warehouseError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorResourceNotFound) {
+		return &echo.HTTPError{
+			Code:    http.StatusNotFound,
+			Message: common.ErrorResourceNotFound.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}
@@ -1,18 +1,22 @@
 package dto
 
-import "github.com/shopspring/decimal"
+import (
+	"order-system/models"
+
+	"github.com/shopspring/decimal"
+)
 
 type AddCartItemDto struct {
-	ProductID uint   `json:"productId"`
-	Quantity  uint64 `json:"quantity"`
+	ProductID uint            `json:"productId"`
+	Quantity  decimal.Decimal `json:"quantity"`
 }
 type DeleteCartItemDto struct {
 	ProductID uint `json:"productId"`
 }
 
 type SetCartItemDto struct {
-	ProductID uint   `json:"productId"`
-	Quantity  uint64 `json:"quantity"`
+	ProductID uint            `json:"productId"`
+	Quantity  decimal.Decimal `json:"quantity"`
 }
 
 type CartDto struct {
@@ -24,7 +28,9 @@ type CartItemDto struct {
 	ProductName  string          `json:"productName"`
 	ProductID    uint            `json:"productId"`
 	ProductPrice decimal.Decimal `json:"productPrice"`
-	Quantity     uint            `json:"quantity"`
+	Currency     string          `json:"currency"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Unit         models.Unit     `json:"unit"`
 	VendorID     uint            `json:"vendorId"`
 	VendorName   string          `json:"vendorName"`
 }
@@ -0,0 +1,21 @@
+package dto
+
+type CategoryRequestDto struct {
+	Name     string `json:"name" valid:"required~name_required"`
+	ParentID *uint  `json:"parentId"`
+	Position int    `json:"position"`
+}
+
+type CategoryDto struct {
+	ID       uint          `json:"id"`
+	Name     string        `json:"name"`
+	ParentID *uint         `json:"parentId"`
+	Position int           `json:"position"`
+	Children []CategoryDto `json:"children"`
+}
+
+// An ancestor of a product in its breadcrumbs
+type CategoryRefDto struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
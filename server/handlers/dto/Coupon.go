@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"order-system/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type CouponDto struct {
+	Code           string            `json:"code" valid:"required~code_required"`
+	Type           models.CouponType `json:"type" valid:"required~type_required,in(percentage|fixed)~invalid_coupon_type"`
+	Value          decimal.Decimal   `json:"value"`
+	MinSpend       decimal.Decimal   `json:"minSpend"`
+	MaxUses        *int              `json:"maxUses"`
+	MaxUsesPerUser *int              `json:"maxUsesPerUser"`
+	StartsAt       *time.Time        `json:"startsAt"`
+	EndsAt         *time.Time        `json:"endsAt"`
+	// the vendor of the eligible products, set by an admin only
+	VendorID   *uint  `json:"vendorId"`
+	ProductIDs []uint `json:"productIds"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type ExchangeRateDto struct {
+	// ISO 4217 currency code
+	Currency string `json:"currency" valid:"required~currency_required"`
+	// the value of one unit of the currency in the base currency
+	Rate decimal.Decimal `json:"rate"`
+	// right away when empty
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+}
+
+type CurrenciesDto struct {
+	Base string `json:"base"`
+	// the base currency and the currencies with a rate in effect
+	Currencies []string `json:"currencies"`
+}
@@ -1,9 +1,66 @@
 package dto
 
-import "order-system/models"
+import (
+	"order-system/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type UpdateProductStockDto struct {
-	Quantity    int                    `json:"quantity" valid:"required~quantity_empty,numeric~quantity_not_numeric"`
+	Quantity    decimal.Decimal        `json:"quantity"`
 	Type        models.TransactionType `json:"type" valid:"required~update_type_empty,in(in|out)~invalid_stock_change_type"`
 	Description string                 `json:"description"`
+	// the default warehouse of the vendor is used when empty
+	WarehouseID *uint `json:"warehouseId"`
+}
+
+// A product transaction along with the stock
+// of the product right after it
+type StockMovementDto struct {
+	ID            uint                   `json:"id" gorm:"column:id"`
+	CreatedAt     time.Time              `json:"createdAt" gorm:"column:created_at"`
+	Type          models.TransactionType `json:"type" gorm:"column:type"`
+	Description   string                 `json:"description" gorm:"column:description"`
+	Quantity      decimal.Decimal        `json:"quantity" gorm:"column:quantity"`
+	WarehouseID   *uint                  `json:"warehouseId" gorm:"column:warehouse_id"`
+	WarehouseName string                 `json:"warehouseName" gorm:"column:warehouse_name"`
+	Balance       decimal.Decimal        `json:"balance" gorm:"column:balance"`
+}
+
+// A row of a bulk stock import, the product is given
+// by its id or by its SKU
+type StockImportRow struct {
+	Line        int                    `json:"line"`
+	ProductID   uint                   `json:"productId"`
+	SKU         string                 `json:"sku"`
+	WarehouseID *uint                  `json:"warehouseId"`
+	Quantity    decimal.Decimal        `json:"quantity"`
+	Type        models.TransactionType `json:"type"`
+	Description string                 `json:"description"`
+	// available quantity of the product after the row
+	Balance decimal.Decimal `json:"balance"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type StockImportReport struct {
+	DryRun  bool             `json:"dryRun"`
+	Applied bool             `json:"applied"`
+	Errors  int              `json:"errors"`
+	Rows    []StockImportRow `json:"rows"`
+}
+
+type StockAlertDto struct {
+	ID             uint            `json:"id" gorm:"column:id"`
+	CreatedAt      time.Time       `json:"createdAt" gorm:"column:created_at"`
+	ProductID      uint            `json:"productId" gorm:"column:product_id"`
+	ProductName    string          `json:"productName" gorm:"column:product_name"`
+	VendorID       uint            `json:"vendorId" gorm:"column:vendor_id"`
+	Threshold      decimal.Decimal `json:"threshold" gorm:"column:threshold"`
+	Quantity       decimal.Decimal `json:"quantity" gorm:"column:quantity"`
+	AcknowledgedAt *time.Time      `json:"acknowledgedAt" gorm:"column:acknowledged_at"`
+}
+
+type SetLowStockThresholdDto struct {
+	Threshold decimal.Decimal `json:"threshold"`
 }
@@ -13,6 +13,21 @@ type OrdersCreateDto struct {
 	RecipientAddress string `json:"recipientAddress"`
 	RecipientName    string `json:"recipientName"`
 	RecipientPhone   string `json:"recipientPhone"`
+	// ISO 3166 country code and region (e.g. state) code
+	// of the recipient address, the taxes depend on them
+	RecipientCountry string `json:"recipientCountry" valid:"ISO3166Alpha2~invalid_country"`
+	RecipientRegion  string `json:"recipientRegion" valid:"runelength(0|8)~invalid_region"`
+	// the current prices accepted by the buyer after their cart was repriced
+	AcceptedPrices []AcceptedPriceDto `json:"acceptedPrices"`
+	CouponCode     string             `json:"couponCode"`
+	// ISO 4217 code of the currency the orders are paid in,
+	// the base currency when empty
+	Currency string `json:"currency" valid:"ISO4217~invalid_currency"`
+}
+
+type AcceptedPriceDto struct {
+	ProductID      uint `json:"productId"`
+	ProductPriceID uint `json:"productPriceId"`
 }
 
 type OrderCreateDto struct {
@@ -21,31 +36,57 @@ type OrderCreateDto struct {
 
 type OrderDto struct {
 	models.BaseWithAudit
-	Id                uint               `json:"id" gorm:"column:id"`
-	Status            models.OrderStatus `json:"status" gorm:"column:status"`
-	StatusChangeTime  time.Time          `json:"statusChangeTime" gorm:"column:status_change_time"`
-	TotalPrice        decimal.Decimal    `json:"totalPrice" gorm:"column:total_price"`
-	PaymentMethodID   string             `json:"paymentMethodId" gorm:"column:payment_method_id"`
-	PaymentMethodName string             `json:"paymentMethodName" gorm:"column:payment_method_name"`
-	ShippingAddress   string             `json:"shippingAddress" gorm:"column:shipping_address"`
-	RecipientName     string             `json:"recipientName" gorm:"column:recipient_name"`
-	RecipientPhone    string             `json:"recipientPhone" gorm:"column:recipient_phone"`
-	VendorID          uint               `json:"vendorId" gorm:"column:vendor_id"`
-	VendorName        string             `json:"vendorName" gorm:"column:vendor_name"`
-	UserID            uint               `json:"userId" gorm:"column:user_id"`
-	UserName          string             `json:"userName" gorm:"column:user_name"`
-	Items             []OrderItemDto     `json:"items" gorm:"-"`
+	Id               uint               `json:"id" gorm:"column:id"`
+	Status           models.OrderStatus `json:"status" gorm:"column:status"`
+	StatusChangeTime time.Time          `json:"statusChangeTime" gorm:"column:status_change_time"`
+	// the total of the items, then the total of the discounts
+	// (negative), the total of the taxes and the total to pay, in the
+	// currency of the order. The exchange rate is the value of one unit
+	// of this currency in the base currency when the order was placed
+	Subtotal          decimal.Decimal      `json:"subtotal" gorm:"column:subtotal"`
+	Discount          decimal.Decimal      `json:"discount" gorm:"column:discount"`
+	Tax               decimal.Decimal      `json:"tax" gorm:"column:tax"`
+	TotalPrice        decimal.Decimal      `json:"totalPrice" gorm:"column:total_price"`
+	Currency          string               `json:"currency" gorm:"column:currency"`
+	ExchangeRate      decimal.Decimal      `json:"exchangeRate" gorm:"column:exchange_rate"`
+	PaymentMethodID   string               `json:"paymentMethodId" gorm:"column:payment_method_id"`
+	PaymentMethodName string               `json:"paymentMethodName" gorm:"column:payment_method_name"`
+	ShippingAddress   string               `json:"shippingAddress" gorm:"column:shipping_address"`
+	RecipientName     string               `json:"recipientName" gorm:"column:recipient_name"`
+	RecipientPhone    string               `json:"recipientPhone" gorm:"column:recipient_phone"`
+	VendorID          uint                 `json:"vendorId" gorm:"column:vendor_id"`
+	VendorName        string               `json:"vendorName" gorm:"column:vendor_name"`
+	UserID            uint                 `json:"userId" gorm:"column:user_id"`
+	UserName          string               `json:"userName" gorm:"column:user_name"`
+	Items             []OrderItemDto       `json:"items" gorm:"-"`
+	Adjustments       []OrderAdjustmentDto `json:"adjustments,omitempty" gorm:"-"`
+}
+
+type OrderAdjustmentDto struct {
+	Type        models.AdjustmentType `json:"type"`
+	Description string                `json:"description"`
+	Amount      decimal.Decimal       `json:"amount"`
+	// the percentage of a tax
+	Rate *decimal.Decimal `json:"rate,omitempty"`
 }
 
 type OrderCancelRequest struct {
-	Status models.OrderStatus `json:"status"`
+	Reason string `json:"reason" valid:"runelength(0|500)~reason_too_long"`
+}
+
+type OrderTransitionRequest struct {
+	Status          models.OrderStatus `json:"status" valid:"required~status_required"`
+	Reason          string             `json:"reason" valid:"runelength(0|500)~reason_too_long"`
+	TrackingCarrier string             `json:"trackingCarrier" valid:"runelength(0|100)~tracking_carrier_too_long"`
+	TrackingNumber  string             `json:"trackingNumber" valid:"runelength(0|100)~tracking_number_too_long"`
 }
 
 type OrderItemDto struct {
 	ProductID   uint32          `json:"productId"`
 	ProductName string          `json:"productName"`
-	Quantity    int             `json:"quantity"`
-	UnitPrice   decimal.Decimal `json:"unitPrice"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	// in the currency of the order
+	UnitPrice decimal.Decimal `json:"unitPrice"`
 }
 
 type ExportCSVRequest struct {
@@ -55,3 +96,35 @@ type ExportCSVRequest struct {
 type OrderNextStatusRequest struct {
 	OrderId uint `json:"orderId"`
 }
+
+type OrderHistoryDto struct {
+	OrderID     uint                 `json:"orderId"`
+	Status      models.OrderStatus   `json:"status"`
+	Transitions []OrderTransitionDto `json:"transitions"`
+	SLABreaches []OrderSLABreachDto  `json:"slaBreaches"`
+}
+
+type OrderTransitionDto struct {
+	ID              uint               `json:"id" gorm:"column:id"`
+	CreatedAt       time.Time          `json:"createdAt" gorm:"column:created_at"`
+	PreviousStatus  models.OrderStatus `json:"previousStatus" gorm:"column:previous_status"`
+	Status          models.OrderStatus `json:"status" gorm:"column:status"`
+	ActorID         uint               `json:"actorId" gorm:"column:actor_id"`
+	ActorRole       models.ActorRole   `json:"actorRole" gorm:"column:actor_role"`
+	ActorName       string             `json:"actorName" gorm:"column:actor_name"`
+	Reason          string             `json:"reason" gorm:"column:reason"`
+	TrackingCarrier string             `json:"trackingCarrier" gorm:"column:tracking_carrier"`
+	TrackingNumber  string             `json:"trackingNumber" gorm:"column:tracking_number"`
+	// time spent in this status, until the next transition
+	// (or until now for the current status)
+	DurationSeconds int64 `json:"durationSeconds" gorm:"-"`
+	Current         bool  `json:"current" gorm:"-"`
+}
+
+type OrderSLABreachDto struct {
+	Status          models.OrderStatus `json:"status"`
+	LimitSeconds    int64              `json:"limitSeconds"`
+	DurationSeconds int64              `json:"durationSeconds"`
+	// the order is still in the status
+	Ongoing bool `json:"ongoing"`
+}
@@ -11,13 +11,22 @@ type PaginationQuery struct {
 	PageIndex    int               `json:"pageIndex"`
 	ItemsPerPage int               `json:"itemsPerPage"`
 	Filters      map[string]string `json:"filters"`
+	// comma separated fields, "-" first for a descending order
+	Sort string `json:"sort"`
+	// keyset pagination instead of the page index, an empty
+	// cursor is the first page. nil in offset mode
+	Cursor *string `json:"cursor"`
+	// do not count the items, the total is left out
+	SkipTotal bool `json:"skipTotal"`
 }
 
 type PaginationResponse[T interface{}] struct {
-	Items        []T `json:"items"`
-	Total        int `json:"total"`
-	PageIndex    int `json:"pageIndex"`
-	ItemsPerPage int `json:"itemsPerPage"`
+	Items        []T  `json:"items"`
+	Total        *int `json:"total,omitempty"`
+	PageIndex    int  `json:"pageIndex"`
+	ItemsPerPage int  `json:"itemsPerPage"`
+	// the cursor of the next page, empty on the last one
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 const (
@@ -30,6 +39,8 @@ func ParsePaginationRequest(c echo.Context) *PaginationQuery {
 	err := echo.QueryParamsBinder(c).
 		Int("itemsPerPage", &p.ItemsPerPage).
 		Int("pageIndex", &p.PageIndex).
+		String("sort", &p.Sort).
+		Bool("skipTotal", &p.SkipTotal).
 		CustomFunc("filters", func(_ []string) []error {
 			err := json.Unmarshal([]byte(c.QueryParam("filters")), &p.Filters)
 			return []error{err}
@@ -41,6 +52,11 @@ func ParsePaginationRequest(c echo.Context) *PaginationQuery {
 		p.PageIndex = defaultPageIndex
 	}
 
+	if c.QueryParams().Has("cursor") {
+		cursor := c.QueryParam("cursor")
+		p.Cursor = &cursor
+	}
+
 	if p.ItemsPerPage <= 0 {
 		p.ItemsPerPage = defaultItemsPerPage
 	}
@@ -1,23 +1,58 @@
 package dto
 
 import (
+	"time"
+
+	"order-system/models"
+
 	"github.com/shopspring/decimal"
 )
 
 type CreateProductDto struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Unit        models.Unit `json:"unit"`
+	SKU         string      `json:"sku"`
+	Barcode     string      `json:"barcode"`
+	CategoryID  *uint       `json:"categoryId"`
+	// the default tax category when empty
+	TaxCategory string `json:"taxCategory"`
+	// the option axes (e.g. size, color) of a product sold through variants
+	Options []string `json:"options"`
 }
 
 type Product struct {
-	ID             uint            `json:"id" gorm:"column:id"`
-	Name           string          `json:"name" gorm:"column:name"`
-	Description    string          `json:"description" gorm:"column:description"`
-	VendorID       uint            `json:"vendorId" gorm:"column:vendor_id"`
-	Unit           string          `json:"unit" gorm:"column:uint"`
-	StockQuantity  uint            `json:"stockQuantity" gorm:"column:stock_quantity"`
-	ProductPriceId uint            `json:"productPriceId" gorm:"column:product_price_id"`
-	ProductPrice   decimal.Decimal `json:"productPrice" gorm:"column:product_price"`
+	ID                uint            `json:"id" gorm:"column:id"`
+	CreatedAt         time.Time       `json:"createdAt" gorm:"column:created_at"`
+	Name              string          `json:"name" gorm:"column:name"`
+	Description       string          `json:"description" gorm:"column:description"`
+	VendorID          uint            `json:"vendorId" gorm:"column:vendor_id"`
+	Unit              models.Unit     `json:"unit" gorm:"column:unit"`
+	SKU               *string         `json:"sku" gorm:"column:sku"`
+	Barcode           *string         `json:"barcode" gorm:"column:barcode"`
+	CategoryID        *uint           `json:"categoryId" gorm:"column:category_id"`
+	TaxCategory       string          `json:"taxCategory" gorm:"column:tax_category"`
+	StockQuantity     decimal.Decimal `json:"stockQuantity" gorm:"column:stock_quantity"`
+	ReservedQuantity  decimal.Decimal `json:"reservedQuantity" gorm:"column:reserved_quantity"`
+	LowStockThreshold decimal.Decimal `json:"lowStockThreshold" gorm:"column:low_stock_threshold"`
+	ProductPriceId    uint            `json:"productPriceId" gorm:"column:product_price_id"`
+	ProductPrice      decimal.Decimal `json:"productPrice" gorm:"column:product_price"`
+	Currency          string          `json:"currency" gorm:"column:product_currency"`
+	// stock per warehouse (only for the vendor of the product)
+	Warehouses []WarehouseStockDto `json:"warehouses,omitempty" gorm:"-"`
+	// relevance and matched terms wrapped in <mark> (only for a search)
+	Rank                 float64 `json:"rank,omitempty" gorm:"column:search_rank"`
+	NameHighlight        string  `json:"nameHighlight,omitempty" gorm:"column:name_highlight"`
+	DescriptionHighlight string  `json:"descriptionHighlight,omitempty" gorm:"column:description_highlight"`
+	// the category of the product and its ancestors, root first
+	Categories []CategoryRefDto `json:"categories" gorm:"-"`
+	// ordered by position, the primary image is flagged
+	Images []ProductImageDto `json:"images" gorm:"-"`
+	// a product with variants has the stock of all its variants
+	// and the lowest of their prices
+	HasVariants bool                `json:"hasVariants" gorm:"column:has_variants"`
+	Options     []string            `json:"options,omitempty" gorm:"-"`
+	Variants    []ProductVariantDto `json:"variants,omitempty" gorm:"-"`
 }
 
 type ProductWithPrice struct {
@@ -26,10 +61,47 @@ type ProductWithPrice struct {
 }
 
 type UpdateProductDto struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Unit        models.Unit `json:"unit"`
+	SKU         string      `json:"sku"`
+	Barcode     string      `json:"barcode"`
+	CategoryID  *uint       `json:"categoryId"`
+	// kept when empty
+	TaxCategory string `json:"taxCategory"`
 }
 
 type SetProductPriceDto struct {
 	Price decimal.Decimal `json:"price"`
+	// ISO 4217 currency code, the base currency when empty
+	Currency string `json:"currency"`
+	// right away when empty, it cannot be in the past
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+	// for good when empty
+	EffectiveUntil *time.Time `json:"effectiveUntil"`
+}
+
+// A product of a catalog import or export, the same
+// format is used in both directions
+type CatalogRow struct {
+	Line        int                 `json:"line,omitempty" gorm:"-"`
+	SKU         string              `json:"sku" gorm:"column:sku"`
+	Name        string              `json:"name" gorm:"column:name"`
+	Description string              `json:"description" gorm:"column:description"`
+	Unit        models.Unit         `json:"unit" gorm:"column:unit"`
+	Barcode     string              `json:"barcode,omitempty" gorm:"column:barcode"`
+	Price       decimal.NullDecimal `json:"price" gorm:"column:price"`
+	Currency    string              `json:"currency,omitempty" gorm:"column:currency"`
+	Stock       decimal.Decimal     `json:"stock" gorm:"column:stock"`
+	ProductID   uint                `json:"productId,omitempty" gorm:"-"`
+	// "created" or "updated"
+	Action string `json:"action,omitempty" gorm:"-"`
+	Error  string `json:"error,omitempty" gorm:"-"`
+}
+
+type CatalogImportReport struct {
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Errors  int          `json:"errors"`
+	Rows    []CatalogRow `json:"rows"`
 }
@@ -0,0 +1,16 @@
+package dto
+
+type ProductImageDto struct {
+	ID           uint   `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Position     int    `json:"position"`
+	Primary      bool   `json:"primary"`
+}
+
+type ReorderProductImagesDto struct {
+	// all the images of the product, in their new order
+	ImageIDs []uint `json:"imageIds"`
+}
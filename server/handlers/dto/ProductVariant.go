@@ -0,0 +1,30 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type CreateVariantDto struct {
+	// option name -> value, every option of the product is required
+	Options     map[string]string `json:"options"`
+	Description string            `json:"description"`
+	SKU         string            `json:"sku"`
+	Barcode     string            `json:"barcode"`
+}
+
+type VariantOptionDto struct {
+	Name  string `json:"name" gorm:"column:name"`
+	Value string `json:"value" gorm:"column:value"`
+}
+
+type ProductVariantDto struct {
+	ID               uint               `json:"id" gorm:"column:id"`
+	ParentID         uint               `json:"parentId" gorm:"column:parent_id"`
+	Name             string             `json:"name" gorm:"column:name"`
+	SKU              *string            `json:"sku" gorm:"column:sku"`
+	Barcode          *string            `json:"barcode" gorm:"column:barcode"`
+	StockQuantity    decimal.Decimal    `json:"stockQuantity" gorm:"column:stock_quantity"`
+	ReservedQuantity decimal.Decimal    `json:"reservedQuantity" gorm:"column:reserved_quantity"`
+	ProductPriceId   uint               `json:"productPriceId" gorm:"column:product_price_id"`
+	ProductPrice     decimal.Decimal    `json:"productPrice" gorm:"column:product_price"`
+	Currency         string             `json:"currency" gorm:"column:product_currency"`
+	Options          []VariantOptionDto `json:"options" gorm:"-"`
+}
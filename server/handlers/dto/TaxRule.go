@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type TaxRuleDto struct {
+	Name string `json:"name" valid:"required~name_required"`
+	// ISO 3166 country code, the region (e.g. a state) is optional
+	Country string `json:"country" valid:"required~country_required"`
+	Region  string `json:"region"`
+	// any category when empty
+	TaxCategory string `json:"taxCategory"`
+	// a percentage
+	Rate decimal.Decimal `json:"rate"`
+}
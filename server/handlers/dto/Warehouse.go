@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type WarehouseDto struct {
+	Name      string `json:"name" valid:"required~name_required"`
+	Address   string `json:"address"`
+	Priority  int    `json:"priority"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+type WarehouseStockDto struct {
+	ProductID     uint            `json:"productId" gorm:"column:product_id"`
+	WarehouseID   uint            `json:"warehouseId" gorm:"column:warehouse_id"`
+	WarehouseName string          `json:"warehouseName" gorm:"column:warehouse_name"`
+	Quantity      decimal.Decimal `json:"quantity" gorm:"column:quantity"`
+}
+
+type StockTransferDto struct {
+	ProductID       uint            `json:"productId" valid:"required~product_id_required"`
+	FromWarehouseID uint            `json:"fromWarehouseId" valid:"required~from_warehouse_required"`
+	ToWarehouseID   uint            `json:"toWarehouseId" valid:"required~to_warehouse_required"`
+	Quantity        decimal.Decimal `json:"quantity"`
+	Description     string          `json:"description"`
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"order-system/common"
 	"order-system/handlers/api"
+	"order-system/handlers/api/admin"
 	"order-system/handlers/api/vendors"
 	"order-system/models"
 	"order-system/utils"
@@ -15,6 +17,7 @@ func PrivateEndpoints(e *echo.Group) {
 	e.GET("/me", api.CurrentUser)
 	e.POST("/orders", api.CreateOrders)
 	e.GET("/orders/:id", api.GetOrder)
+	e.GET("/orders/:id/history", api.GetOrderHistory)
 	e.PUT("/orders/:id", api.CancelOrder)
 	e.GET("/orders", api.GetAllOrders)
 	e.GET("/cart", api.GetCartItems)
@@ -22,11 +25,43 @@ func PrivateEndpoints(e *echo.Group) {
 	e.PUT("/cart", api.SetCartItemQuantity)
 	e.POST("/cart/remove-item", api.DeleteCartItem)
 	e.GET("/products", api.GetAvailableProducts)
+	e.GET("/categories", api.GetCategories)
+	e.GET("/currencies", api.GetCurrencies)
 	e.POST("/orders/:id/cancel", api.CancelOrder)
+	e.POST("/orders/:id/transitions", api.TransitionOrder)
 	e.GET("/orders/export-csv", api.ExportCSV)
 	e.GET("/payment-methods", api.GetSupportedPaymentMethods)
+	e.GET("/orders/:id/payment", api.GetOrderPayment)
+	e.POST("/orders/:id/payment/retry", api.RetryOrderPayment)
 
 	initVendorsEnpoint(e)
+	initAdminEndpoint(e)
+}
+
+func initAdminEndpoint(e *echo.Group) {
+	adminGroup := e.Group("/admin", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := utils.GetCurrentUser(c)
+			if user.Role != models.Admin {
+				return &echo.HTTPError{
+					Code:    http.StatusForbidden,
+					Message: common.ErrorInsufficientPermission.Error(),
+				}
+			}
+			return next(c)
+		}
+	})
+
+	adminGroup.POST("/categories", admin.CreateCategory)
+	adminGroup.PUT("/categories/:id", admin.UpdateCategory)
+	adminGroup.GET("/coupons", admin.GetCoupons)
+	adminGroup.POST("/coupons", admin.CreateCoupon)
+	adminGroup.DELETE("/coupons/:id", admin.DeactivateCoupon)
+	adminGroup.GET("/tax-rules", admin.GetTaxRules)
+	adminGroup.POST("/tax-rules", admin.CreateTaxRule)
+	adminGroup.DELETE("/tax-rules/:id", admin.DeleteTaxRule)
+	adminGroup.GET("/exchange-rates", admin.GetExchangeRates)
+	adminGroup.POST("/exchange-rates", admin.CreateExchangeRate)
 }
 
 func initVendorsEnpoint(e *echo.Group) {
@@ -46,11 +81,34 @@ func initVendorsEnpoint(e *echo.Group) {
 	vendorGroup.POST("/products", vendors.CreateProduct)
 	vendorGroup.PUT("/products/:id", vendors.UpdateProduct)
 	vendorGroup.GET("/products", vendors.GetAllVendorProducts)
+	vendorGroup.POST("/products/import", vendors.ImportCatalog)
+	vendorGroup.GET("/products/export", vendors.ExportCatalog)
 	vendorGroup.GET("/products/:id/prices", vendors.GetProductPrices)
 	vendorGroup.POST("/products/:id/prices", vendors.SetProductPrice)
+	vendorGroup.DELETE("/products/:id/prices/:priceId", vendors.CancelProductPrice)
 	vendorGroup.POST("/products/:id/stocks", vendors.UpdateProductStock)
+	vendorGroup.GET("/products/:id/stocks", vendors.GetProductStockMovements)
+	vendorGroup.GET("/products/:id/stocks/export-csv", vendors.ExportProductStockMovementsCSV)
+	vendorGroup.PUT("/products/:id/low-stock-threshold", vendors.SetLowStockThreshold)
+	vendorGroup.GET("/products/:id/variants", vendors.GetProductVariants)
+	vendorGroup.POST("/products/:id/variants", vendors.CreateProductVariant)
+	vendorGroup.GET("/products/:id/images", vendors.GetProductImages)
+	vendorGroup.POST("/products/:id/images", vendors.UploadProductImages)
+	vendorGroup.PUT("/products/:id/images/order", vendors.ReorderProductImages)
+	vendorGroup.PUT("/products/:id/images/:imageId/primary", vendors.SetPrimaryProductImage)
+	vendorGroup.DELETE("/products/:id/images/:imageId", vendors.DeleteProductImage)
+	vendorGroup.POST("/stocks/import", vendors.ImportStocks)
+	vendorGroup.GET("/stock-alerts", vendors.GetStockAlerts)
+	vendorGroup.POST("/stock-alerts/:id/acknowledge", vendors.AcknowledgeStockAlert)
+	vendorGroup.GET("/warehouses", vendors.GetWarehouses)
+	vendorGroup.POST("/warehouses", vendors.CreateWarehouse)
+	vendorGroup.PUT("/warehouses/:id", vendors.UpdateWarehouse)
+	vendorGroup.POST("/warehouses/transfers", vendors.TransferStock)
 	vendorGroup.GET("/orders", vendors.GetAllVendorOrders)
 	vendorGroup.PUT("/orders/:id", vendors.OrderNextStatus)
 	vendorGroup.POST("/orders/:id/cancel", vendors.CancelOrder)
 	vendorGroup.GET("/orders/export-csv", vendors.ExportCSV)
+	vendorGroup.GET("/coupons", vendors.GetCoupons)
+	vendorGroup.POST("/coupons", vendors.CreateCoupon)
+	vendorGroup.DELETE("/coupons/:id", vendors.DeactivateCoupon)
 }
@@ -8,11 +8,18 @@ import (
 )
 
 type TransportMsg struct {
-	Data interface{}
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// A message pushed to every session of a user
+type userMsg struct {
+	userId uint
+	data   interface{}
 }
 
 type Hub struct {
-	broadcast chan uint
+	broadcast chan userMsg
 
 	clientMap map[uint](map[*websocket.Conn]bool)
 
@@ -20,7 +27,7 @@ type Hub struct {
 }
 
 var hub *Hub = &Hub{
-	make(chan uint), make(map[uint]map[*websocket.Conn]bool), sync.Mutex{},
+	make(chan userMsg), make(map[uint]map[*websocket.Conn]bool), sync.Mutex{},
 }
 
 func GetHub() *Hub {
@@ -28,7 +35,13 @@ func GetHub() *Hub {
 }
 
 func (hub *Hub) RefreshCart(userId uint) {
-	hub.broadcast <- userId
+	hub.broadcast <- userMsg{userId, "reload"}
+}
+
+// Push a message to the sessions of a user, it is wrapped
+// in a TransportMsg so it can be told apart from a cart reload
+func (hub *Hub) NotifyUser(userId uint, msgType string, data interface{}) {
+	hub.broadcast <- userMsg{userId, TransportMsg{Type: msgType, Data: data}}
 }
 
 func (hub *Hub) RegisterNewClient(userId uint, ws *websocket.Conn) {
@@ -44,9 +57,9 @@ func (hub *Hub) RegisterNewClient(userId uint, ws *websocket.Conn) {
 }
 
 func (hub *Hub) Run() {
-	for userId := range hub.broadcast {
+	for msg := range hub.broadcast {
 		hub.lock.Lock()
-		clients, ok := hub.clientMap[userId]
+		clients, ok := hub.clientMap[msg.userId]
 		hub.lock.Unlock()
 		fmt.Println(clients)
 		if !ok {
@@ -55,7 +68,7 @@ func (hub *Hub) Run() {
 
 		toRevoke := []*websocket.Conn{}
 		for client := range clients {
-			err := client.WriteJSON("reload")
+			err := client.WriteJSON(msg.data)
 			if err != nil {
 				toRevoke = append(toRevoke, client)
 			}
@@ -9,7 +9,15 @@ import (
 	"order-system/handlers"
 	"order-system/handlers/dto"
 	"order-system/handlers/websocket"
+	"order-system/services/alerts"
+	"order-system/services/currencies"
+	"order-system/services/media"
+	"order-system/services/orders"
+	"order-system/services/payments"
+	"order-system/services/products"
+	"order-system/services/taxes"
 	"os"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/labstack/echo/v4"
@@ -24,6 +32,8 @@ import (
 var dbSeed = flag.Bool("seed", false, "whether to perform db seeding")
 var dbSeedSample = flag.Bool("seedsample", false, "whether to perform db seeding for sample data")
 var dbMigrate = flag.Bool("migrate", false, "whether to perform db migration")
+var dbReconcileStock = flag.Bool("reconcilestock", false, "whether to recompute the stock balances (in total and by warehouse) from the product transactions")
+var dbLoadRates = flag.Bool("loadrates", false, "whether to load the exchange rates of the EXCHANGE_RATES_FILE file")
 
 type GoValidatorAdapter struct {
 }
@@ -35,9 +45,48 @@ func (v *GoValidatorAdapter) Validate(i interface{}) error {
 	return nil
 }
 
+func reconcileStock() {
+	fmt.Println("reconciling stock balances...")
+	drifts, err := products.ReconcileStock()
+
+	for _, drift := range drifts {
+		location := ""
+		if drift.WarehouseID != nil {
+			location = fmt.Sprintf(" in warehouse %d", *drift.WarehouseID)
+		}
+
+		fmt.Printf("product %d%s: balance %s, ledger %s (drift %s)\n",
+			drift.ProductID, location, drift.BalanceQuantity, drift.LedgerQuantity, drift.BalanceQuantity.Sub(drift.LedgerQuantity))
+	}
+
+	if err != nil {
+		fmt.Println("error reconciling stock balances")
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d drifted stock balance(s) fixed\n", len(drifts))
+}
+
+func loadRates(path string) {
+	fmt.Printf("loading exchange rates from %s...\n", path)
+	count, err := currencies.LoadRatesFile(path)
+
+	if err != nil {
+		fmt.Println("error loading exchange rates")
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d exchange rate(s) loaded\n", count)
+}
+
 func bootstrap() *echo.Echo {
 	// chores
-	config.LoadConfig()
+	appConfig := config.LoadConfig()
+	payments.InitProviders(appConfig)
+	media.InitStore(appConfig)
+	taxes.InitEngine(appConfig)
 
 	database.InitDB()
 	db := database.GetDBInstance()
@@ -54,9 +103,17 @@ func bootstrap() *echo.Echo {
 		database.SeedSampleData(db)
 	}
 
+	if *dbReconcileStock {
+		reconcileStock()
+	}
+
+	if *dbLoadRates {
+		loadRates(appConfig.ExchangeRatesFile)
+	}
+
 	// if run migrate or seeding,
 	// just run as a cli tool
-	if *dbMigrate || *dbSeed || *dbSeedSample {
+	if *dbMigrate || *dbSeed || *dbSeedSample || *dbReconcileStock || *dbLoadRates {
 		os.Exit(0)
 	}
 
@@ -92,8 +149,12 @@ func bootstrap() *echo.Echo {
 	}))
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	e.Static(appConfig.StorageURL, appConfig.StorageDir)
 
 	go websocket.GetHub().Run()
+	go orders.RunPaymentConfirmationWorker(time.Second * 5)
+	go orders.RunReservationExpiryWorker(time.Second * 30)
+	go alerts.RunStockAlertWorker(time.Second*5, websocket.GetHub().NotifyUser)
 
 	return e
 }
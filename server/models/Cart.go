@@ -1,5 +1,7 @@
 package models
 
+import "github.com/shopspring/decimal"
+
 type Cart struct {
 	ID     uint       `json:"id"`
 	Items  []CartItem `json:"items"`
@@ -9,11 +11,11 @@ type Cart struct {
 type CartItem struct {
 	ID uint `json:"id" gorm:"primaryKey"`
 	BaseWithAudit
-	Product        Product      `json:"product"`
-	ProductID      uint         `json:"productId"`
-	Quantity       uint64       `json:"quantity"`
-	ProductPrice   ProductPrice `json:"productPrice"`
-	ProductPriceId uint         `json:"productPriceId"`
-	CartID         uint         `json:"cartId"`
-	Active         bool         `json:"active"`
+	Product        Product         `json:"product"`
+	ProductID      uint            `json:"productId"`
+	Quantity       decimal.Decimal `json:"quantity" gorm:"type:numeric"`
+	ProductPrice   ProductPrice    `json:"productPrice"`
+	ProductPriceId uint            `json:"productPriceId"`
+	CartID         uint            `json:"cartId"`
+	Active         bool            `json:"active"`
 }
@@ -0,0 +1,11 @@
+package models
+
+// A node of the product taxonomy, the categories
+// without a parent are the roots of the tree
+type Category struct {
+	Base
+	Name     string `json:"name"`
+	ParentID *uint  `json:"parentId" gorm:"index"`
+	// the order of the category among its siblings
+	Position int `json:"position"`
+}
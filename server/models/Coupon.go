@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type CouponType string
+
+const (
+	// Value is a percentage of the eligible items
+	CouponPercentage CouponType = "percentage"
+	// Value is an amount taken off the eligible items, in the base currency
+	CouponFixed CouponType = "fixed"
+)
+
+// A discount code defined by a vendor (on its own products) or by an admin
+// (on any product, or on the products of a vendor). The eligible items of a
+// checkout are the items of the vendor, narrowed to the products of the
+// coupon when it has any
+type Coupon struct {
+	BaseWithPrimaryKey
+	BaseWithAudit
+	// upper case, unique whatever the case it is typed with
+	Code        string          `json:"code" gorm:"uniqueIndex"`
+	CreatedByID uint            `json:"createdById"`
+	VendorID    *uint           `json:"vendorId" gorm:"index"`
+	Type        CouponType      `json:"type"`
+	Value       decimal.Decimal `json:"value" gorm:"type:numeric"`
+	// the eligible items have to add up to this much (in the base currency)
+	MinSpend       decimal.Decimal `json:"minSpend" gorm:"type:numeric"`
+	MaxUses        *int            `json:"maxUses"`
+	MaxUsesPerUser *int            `json:"maxUsesPerUser"`
+	StartsAt       *time.Time      `json:"startsAt"`
+	EndsAt         *time.Time      `json:"endsAt"`
+	Active         bool            `json:"active"`
+	ProductIDs     []uint          `json:"productIds" gorm:"-"`
+	Uses           int64           `json:"uses" gorm:"-"`
+}
+
+type CouponProduct struct {
+	CouponID  uint `json:"couponId" gorm:"primarykey;autoIncrement:false"`
+	ProductID uint `json:"productId" gorm:"primarykey;autoIncrement:false"`
+}
+
+// A checkout which used a coupon, whatever the number of its orders
+type CouponRedemption struct {
+	BaseWithPrimaryKey
+	BaseWithAudit
+	CouponID uint `json:"couponId" gorm:"index"`
+	UserID   uint `json:"userId" gorm:"index"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// The value of one unit of a currency in the base currency from
+// EffectiveFrom, until the next rate of the currency takes effect
+type ExchangeRate struct {
+	ID            uint            `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Currency      string          `json:"currency" gorm:"size:3;uniqueIndex:idx_exchange_rates_currency_from"`
+	Rate          decimal.Decimal `json:"rate" gorm:"type:numeric"`
+	EffectiveFrom time.Time       `json:"effectiveFrom" gorm:"uniqueIndex:idx_exchange_rates_currency_from"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "github.com/shopspring/decimal"
+
 type OrderStatus string
 
 const (
@@ -9,37 +11,66 @@ const (
 	OrderShipping   = "SHIPPING"
 	OrderShipped    = "SHIPPED"
 	OrderCancelled  = "CANCELLED"
+	OrderReturned   = "RETURNED"
+	OrderRefunded   = "REFUNDED"
+)
+
+// The role of the one who changes the status of an order,
+// relative to the order itself
+type ActorRole string
+
+const (
+	ActorBuyer  ActorRole = "buyer"
+	ActorVendor ActorRole = "vendor"
+	ActorSystem ActorRole = "system"
 )
 
-/* Assume all orders are paid
-right after they were created */
 type Order struct {
 	Base
-	Items            []OrderItem        `json:"items"`
-	UserID           uint               `json:"userId"`
-	VendorID         uint               `json:"vendorId"`
-	PaymentMethod    PaymentMethod      `json:"paymentMethod"`
-	PaymentMethodID  string             `json:"paymentMethodId"`
-	ShippingAddress  string             `json:"shippingAddress"`
+	Items           []OrderItem   `json:"items"`
+	UserID          uint          `json:"userId"`
+	VendorID        uint          `json:"vendorId"`
+	PaymentMethod   PaymentMethod `json:"paymentMethod"`
+	PaymentMethodID string        `json:"paymentMethodId"`
+	ShippingAddress string        `json:"shippingAddress"`
+	// ISO 3166 country code and region (e.g. state) code
+	// of the shipping address, the taxes depend on them
+	ShippingCountry  string             `json:"shippingCountry"`
+	ShippingRegion   string             `json:"shippingRegion"`
 	RecipientName    string             `json:"recipientName"`
 	RecipientPhone   string             `json:"recipientPhone"`
 	OrderTransaction []OrderTransaction `json:"orderTransaction"`
+	WarehouseID      *uint              `json:"warehouseId"`
+	// the currency the order is paid in and the value of one unit of it
+	// in the base currency at checkout, the orders placed before the
+	// currencies were introduced are in US dollars
+	Currency     string          `json:"currency" gorm:"size:3;default:USD"`
+	ExchangeRate decimal.Decimal `json:"exchangeRate" gorm:"type:numeric;default:1"`
 }
 
 type OrderTransaction struct {
 	BaseWithPrimaryKey
 	BaseWithAudit
-	PreviousStatus OrderStatus `json:"previousStatus"`
-	Status         OrderStatus `json:"status"`
-	OrderID        uint        `json:"orderId"`
+	PreviousStatus  OrderStatus `json:"previousStatus"`
+	Status          OrderStatus `json:"status"`
+	OrderID         uint        `json:"orderId"`
+	ActorID         uint        `json:"actorId"`
+	ActorRole       ActorRole   `json:"actorRole"`
+	Reason          string      `json:"reason"`
+	TrackingCarrier string      `json:"trackingCarrier"`
+	TrackingNumber  string      `json:"trackingNumber"`
 }
 
 type OrderItem struct {
 	Base
-	Product        Product      `json:"product"`
-	ProductID      uint         `json:"productId"`
-	Quantity       int          `json:"quantity"`
-	ProductPrice   ProductPrice `json:"productPrice"`
-	ProductPriceId uint         `json:"productPriceId"`
-	OrderId        uint         `json:"orderId"`
+	Product        Product         `json:"product"`
+	ProductID      uint            `json:"productId"`
+	Quantity       decimal.Decimal `json:"quantity" gorm:"type:numeric"`
+	ProductPrice   ProductPrice    `json:"productPrice"`
+	ProductPriceId uint            `json:"productPriceId"`
+	OrderId        uint            `json:"orderId"`
+	// the rate from the currency of the price to the currency of
+	// the order at checkout, the unit price of the item is the
+	// price converted with it and rounded to the cent
+	ExchangeRate decimal.Decimal `json:"exchangeRate" gorm:"type:numeric;default:1"`
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type AdjustmentType string
+
+const (
+	AdjustmentDiscount AdjustmentType = "discount"
+	AdjustmentTax      AdjustmentType = "tax"
+)
+
+// An amount added to (or taken off, when negative) the items of an order,
+// the total of an order is the total of its items and its adjustments
+type OrderAdjustment struct {
+	ID          uint            `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	OrderID     uint            `json:"orderId" gorm:"index"`
+	Type        AdjustmentType  `json:"type"`
+	Description string          `json:"description"`
+	Amount      decimal.Decimal `json:"amount" gorm:"type:numeric"`
+	CouponID    *uint           `json:"couponId"`
+	// the percentage of a tax, as it was when the order was placed
+	Rate *decimal.Decimal `json:"rate" gorm:"type:numeric"`
+}
@@ -1,5 +1,10 @@
 package models
 
+const (
+	PaymentMethodCOD    = "payment_cod"
+	PaymentMethodCredit = "payment_credit"
+)
+
 type PaymentMethod struct {
 	ID   string `json:"id" gorm:"primarykey,type:varchar(20)"`
 	Name string `json:"name"`
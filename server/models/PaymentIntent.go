@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PaymentIntentStatus string
+
+const (
+	PaymentIntentPending   PaymentIntentStatus = "pending"
+	PaymentIntentSucceeded PaymentIntentStatus = "succeeded"
+	PaymentIntentFailed    PaymentIntentStatus = "failed"
+	PaymentIntentCancelled PaymentIntentStatus = "cancelled"
+	PaymentIntentRefunded  PaymentIntentStatus = "refunded"
+)
+
+// A payment intent tracks the payment of an order
+// through its provider, an order has at most one intent
+type PaymentIntent struct {
+	Base
+	OrderID           uint                `json:"orderId" gorm:"uniqueIndex"`
+	PaymentMethodID   string              `json:"paymentMethodId"`
+	Provider          string              `json:"provider"`
+	Amount            decimal.Decimal     `json:"amount" gorm:"type:numeric;"`
+	Currency          string              `json:"currency" gorm:"size:3;default:USD"`
+	Status            PaymentIntentStatus `json:"status"`
+	ProviderReference string              `json:"providerReference"`
+	FailureReason     string              `json:"failureReason"`
+	Attempts          int                 `json:"attempts"`
+	LastAttemptAt     *time.Time          `json:"lastAttemptAt"`
+	ConfirmedAt       *time.Time          `json:"confirmedAt"`
+}
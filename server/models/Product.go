@@ -1,10 +1,31 @@
 package models
 
+import "github.com/shopspring/decimal"
+
+// The text searched in the products,
+// it is indexed (GIN) with this exact expression
+const ProductSearchDocument = `to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, ''))`
+
 type Product struct {
 	Base
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Vendor      User   `json:"vendor"`
-	VendorID    uint   `json:"vendorId"`
-	Unit        string `json:"unit"`
+	VendorID    uint   `json:"vendorId" gorm:"uniqueIndex:idx_products_vendor_sku"`
+	Unit        Unit   `json:"unit"`
+	CategoryID  *uint  `json:"categoryId" gorm:"index"`
+	// stock keeping unit, unique among the products of a vendor
+	SKU *string `json:"sku" gorm:"uniqueIndex:idx_products_vendor_sku"`
+	// GTIN (EAN, UPC) barcode, its check digit is verified
+	Barcode *string `json:"barcode" gorm:"index"`
+	// the taxes of the product depend on it and on where it is shipped
+	TaxCategory string `json:"taxCategory" gorm:"default:standard"`
+	// a stock alert is raised when the stock drops below it, 0 disables it
+	LowStockThreshold decimal.Decimal `json:"lowStockThreshold" gorm:"type:numeric;default:0"`
+	// a product with options is sold through its variants, it has
+	// no price nor stock itself. A variant is a product with a parent
+	HasVariants bool  `json:"hasVariants" gorm:"default:false"`
+	ParentID    *uint `json:"parentId" gorm:"uniqueIndex:idx_products_variant"`
+	// the values of the options of a variant, unique among its siblings
+	VariantKey *string `json:"-" gorm:"uniqueIndex:idx_products_variant"`
 }
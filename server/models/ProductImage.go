@@ -0,0 +1,19 @@
+package models
+
+// An image of a product, its content and its thumbnail
+// are in the blob store under Key and ThumbnailKey
+type ProductImage struct {
+	BaseWithPrimaryKey
+	BaseWithAudit
+	ProductID    uint   `json:"productId" gorm:"index"`
+	Key          string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	ContentType  string `json:"contentType"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	// the order of the image among the images of the product
+	Position int `json:"position"`
+	// shown first, a product has at most one primary image
+	IsPrimary bool `json:"primary"`
+}
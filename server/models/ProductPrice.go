@@ -7,11 +7,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// A price of a product in effect from EffectiveFrom until EffectiveUntil
+// (excluded, open when empty). When several prices are in effect at the
+// same instant, the one which took effect last wins, e.g. a weekend sale
+// over the regular price. The prices recorded before the currencies
+// were introduced are in US dollars
 type ProductPrice struct {
-	ID        uint            `json:"id" gorm:"primarykey"`
-	CreatedAt time.Time       `json:"createdAt"`
-	DeletedAt gorm.DeletedAt  `json:"deletedAt"`
-	ProductID uint            `json:"productId"`
-	Product   Product         `json:"product"`
-	Price     decimal.Decimal `json:"price" gorm:"type:numeric;"`
+	ID             uint            `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeletedAt      gorm.DeletedAt  `json:"deletedAt"`
+	ProductID      uint            `json:"productId"`
+	Product        Product         `json:"product"`
+	Price          decimal.Decimal `json:"price" gorm:"type:numeric;"`
+	Currency       string          `json:"currency" gorm:"size:3;default:USD"`
+	EffectiveFrom  time.Time       `json:"effectiveFrom" gorm:"index"`
+	EffectiveUntil *time.Time      `json:"effectiveUntil"`
+}
+
+// A price without a start takes effect right away
+func (p *ProductPrice) BeforeCreate(tx *gorm.DB) error {
+	if p.EffectiveFrom.IsZero() {
+		p.EffectiveFrom = time.Now()
+	}
+	return nil
 }
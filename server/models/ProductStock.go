@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// The materialized stock balance of a product,
+// it is the sum of all the product transactions of the product
+type ProductStock struct {
+	ProductID uint            `json:"productId" gorm:"primarykey;autoIncrement:false"`
+	Quantity  decimal.Decimal `json:"quantity" gorm:"type:numeric"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
@@ -1,12 +1,21 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"gorm.io/gorm"
+)
 
 type TransactionType string
 
 const (
 	TransactionTypeIn  TransactionType = "in"
 	TransactionTypeOut TransactionType = "out"
+	// a move between two warehouses of the same product,
+	// it does not change the stock of the product
+	TransactionTypeTransfer TransactionType = "transfer"
 )
 
 type ProductTransaction struct {
@@ -15,5 +24,54 @@ type ProductTransaction struct {
 	Type        TransactionType `json:"type"`
 	Description string          `json:"description"`
 	ProductID   uint            `json:"productId"`
-	Quantity    int             `json:"quantity"`
+	Quantity    decimal.Decimal `json:"quantity" gorm:"type:numeric"`
+	// the warehouse the stock comes in or goes out,
+	// empty for the transactions made before warehouses existed
+	WarehouseID *uint `json:"warehouseId" gorm:"index"`
+}
+
+// Keep the stock balances of the product in sync with the ledger,
+// in the same database transaction as the new entry
+func (t *ProductTransaction) AfterCreate(tx *gorm.DB) error {
+	balance := decimal.Zero
+	err := tx.Raw(`
+		insert into product_stocks (product_id, quantity, updated_at) values (?, ?, now())
+		on conflict (product_id) do update
+		set quantity = product_stocks.quantity + excluded.quantity, updated_at = excluded.updated_at
+		returning quantity`,
+		t.ProductID, t.Quantity).Row().Scan(&balance)
+
+	if err != nil {
+		return err
+	}
+
+	if err := t.raiseStockAlert(tx, balance); err != nil {
+		return err
+	}
+
+	if t.WarehouseID == nil {
+		return nil
+	}
+
+	return tx.Exec(`
+		insert into warehouse_stocks (product_id, warehouse_id, quantity, updated_at) values (?, ?, ?, now())
+		on conflict (product_id, warehouse_id) do update
+		set quantity = warehouse_stocks.quantity + excluded.quantity, updated_at = excluded.updated_at`,
+		t.ProductID, *t.WarehouseID, t.Quantity).Error
+}
+
+// Raise a stock alert when the transaction makes the stock cross
+// the low-stock threshold of the product. A transfer leaves
+// the stock of the product unchanged so it is skipped
+func (t *ProductTransaction) raiseStockAlert(tx *gorm.DB, balance decimal.Decimal) error {
+	if !t.Quantity.IsNegative() || t.Type == TransactionTypeTransfer {
+		return nil
+	}
+
+	return tx.Exec(`
+		insert into stock_alerts (created_at, updated_at, product_id, vendor_id, threshold, quantity)
+			(select now(), now(), p.id, p.vendor_id, p.low_stock_threshold, ?
+			from products p
+			where p.id = ? and p.low_stock_threshold > 0 and ? < p.low_stock_threshold and ? >= p.low_stock_threshold)`,
+		balance, t.ProductID, balance, balance.Sub(t.Quantity)).Error
 }
@@ -0,0 +1,18 @@
+package models
+
+// An option axis of a product with variants, e.g. the size or the color
+type ProductOption struct {
+	BaseWithPrimaryKey
+	ProductID uint   `json:"productId" gorm:"index"`
+	Name      string `json:"name"`
+	// the order of the option among the options of the product
+	Position int `json:"position"`
+}
+
+// The value of an option for a variant, e.g. `M` for the size
+type ProductVariantValue struct {
+	BaseWithPrimaryKey
+	VariantID uint   `json:"variantId" gorm:"uniqueIndex:idx_product_variant_values"`
+	OptionID  uint   `json:"optionId" gorm:"uniqueIndex:idx_product_variant_values"`
+	Value     string `json:"value"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Raised when a product transaction drops the stock
+// of a product below its low-stock threshold
+type StockAlert struct {
+	ID uint `json:"id" gorm:"primarykey"`
+	BaseWithAudit
+	ProductID uint            `json:"productId" gorm:"index"`
+	VendorID  uint            `json:"vendorId" gorm:"index"`
+	Threshold decimal.Decimal `json:"threshold" gorm:"type:numeric"`
+	// stock of the product right after the transaction
+	Quantity       decimal.Decimal `json:"quantity" gorm:"type:numeric"`
+	NotifiedAt     *time.Time      `json:"notifiedAt" gorm:"index"`
+	AcknowledgedAt *time.Time      `json:"acknowledgedAt"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationActive   ReservationStatus = "active"
+	ReservationConsumed ReservationStatus = "consumed"
+	ReservationReleased ReservationStatus = "released"
+)
+
+// A hold on the stock of a product made by an order at checkout,
+// it becomes an "out" product transaction once the order is paid
+type StockReservation struct {
+	ID uint `json:"id" gorm:"primarykey"`
+	BaseWithAudit
+	ProductID uint              `json:"productId" gorm:"index"`
+	OrderID   uint              `json:"orderId" gorm:"index"`
+	Quantity  decimal.Decimal   `json:"quantity" gorm:"type:numeric"`
+	Status    ReservationStatus `json:"status" gorm:"index"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	// the warehouse fulfilling the order
+	WarehouseID *uint `json:"warehouseId"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// the tax category of a product when none is given
+const DefaultTaxCategory = "standard"
+
+// A tax levied on the products shipped to a country, or to a region of a
+// country. A rule without a tax category applies to the products of any
+// category which have no rule of their own at the same level
+type TaxRule struct {
+	BaseWithPrimaryKey
+	BaseWithAudit
+	Name        string          `json:"name"`
+	Country     string          `json:"country" gorm:"uniqueIndex:idx_tax_rules_jurisdiction"`
+	Region      string          `json:"region" gorm:"uniqueIndex:idx_tax_rules_jurisdiction"`
+	TaxCategory string          `json:"taxCategory" gorm:"uniqueIndex:idx_tax_rules_jurisdiction"`
+	Rate        decimal.Decimal `json:"rate" gorm:"type:numeric"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Unit of measure a product is sold by
+type Unit string
+
+const (
+	UnitPiece      Unit = "piece"
+	UnitBox        Unit = "box"
+	UnitPack       Unit = "pack"
+	UnitKilogram   Unit = "kg"
+	UnitGram       Unit = "g"
+	UnitLitre      Unit = "l"
+	UnitMillilitre Unit = "ml"
+	UnitMetre      Unit = "m"
+)
+
+// How a quantity of a unit can be split,
+// Decimals is the number of decimal places allowed
+type UnitRule struct {
+	Fractional bool
+	Decimals   int32
+}
+
+var Units = map[Unit]UnitRule{
+	UnitPiece:      {},
+	UnitBox:        {},
+	UnitPack:       {},
+	UnitKilogram:   {Fractional: true, Decimals: 3},
+	UnitGram:       {},
+	UnitLitre:      {Fractional: true, Decimals: 3},
+	UnitMillilitre: {},
+	UnitMetre:      {Fractional: true, Decimals: 2},
+}
+
+func (u Unit) IsValid() bool {
+	_, ok := Units[u]
+	return ok
+}
+
+// The rule of the unit, the products created before the units
+// of measure were controlled are counted by piece
+func (u Unit) Rule() UnitRule {
+	if rule, ok := Units[u]; ok {
+		return rule
+	}
+	return Units[UnitPiece]
+}
+
+// Check that a quantity can be ordered or stored in this unit:
+// whole numbers only for a unit which cannot be split
+func (u Unit) IsValidQuantity(quantity decimal.Decimal) bool {
+	rule := u.Rule()
+	if !rule.Fractional {
+		return quantity.IsInteger()
+	}
+	return quantity.Equal(quantity.Truncate(rule.Decimals))
+}
@@ -5,6 +5,7 @@ type UserRole int32
 const (
 	RegularUser UserRole = iota
 	Vendor
+	Admin
 )
 
 type User struct {
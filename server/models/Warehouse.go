@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// A stock location owned by a vendor
+type Warehouse struct {
+	Base
+	VendorID uint   `json:"vendorId" gorm:"index"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	// the lower, the sooner the warehouse is picked to fulfil an order
+	Priority  int  `json:"priority"`
+	IsDefault bool `json:"isDefault"`
+}
+
+// The materialized stock balance of a product in a warehouse
+type WarehouseStock struct {
+	ProductID   uint            `json:"productId" gorm:"primarykey;autoIncrement:false"`
+	WarehouseID uint            `json:"warehouseId" gorm:"primarykey;autoIncrement:false"`
+	Quantity    decimal.Decimal `json:"quantity" gorm:"type:numeric"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestUnitIsValidQuantity(t *testing.T) {
+	cases := []struct {
+		unit     Unit
+		quantity string
+		expected bool
+	}{
+		{UnitPiece, "3", true},
+		{UnitPiece, "1.5", false},
+		{UnitKilogram, "1.5", true},
+		{UnitKilogram, "0.125", true},
+		{UnitKilogram, "0.1255", false},
+		{UnitMetre, "2.25", true},
+		{UnitMetre, "2.255", false},
+		// products created before the units were controlled count by piece
+		{Unit("dozen"), "0.5", false},
+	}
+
+	for _, c := range cases {
+		if actual := c.unit.IsValidQuantity(decimal.RequireFromString(c.quantity)); actual != c.expected {
+			t.Log("expected: v", c.unit, c.quantity, c.expected)
+			t.Error("actual: v", actual)
+		}
+	}
+}
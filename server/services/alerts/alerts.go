@@ -0,0 +1,133 @@
+package alerts
+
+import (
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const StockAlertMsgType = "stock_alert"
+
+// Pushes a message to the sessions of a user
+type Notifier func(userId uint, msgType string, data interface{})
+
+const stockAlertsQuery = `
+	select sa.id, sa.created_at, sa.product_id, p.name as product_name, sa.vendor_id, sa.threshold, sa.quantity, sa.acknowledged_at
+		from stock_alerts sa
+		inner join products p on p.id = sa.product_id`
+
+// Find the stock alerts of a vendor, newest first.
+// The `acknowledged` filter ("true" or "false") is supported
+func FindStockAlertsOfVendor(vendorId uint, paginationQuery dto.PaginationQuery) (*dto.PaginationResponse[dto.StockAlertDto], error) {
+	db := database.GetDBInstance()
+	alerts := []dto.StockAlertDto{}
+	pageIndex := paginationQuery.PageIndex
+	itemsPerPage := paginationQuery.ItemsPerPage
+
+	whereQuery := " where sa.vendor_id = ?"
+	params := []interface{}{vendorId}
+
+	switch paginationQuery.Filters["acknowledged"] {
+	case "true":
+		whereQuery += " and sa.acknowledged_at is not null"
+	case "false":
+		whereQuery += " and sa.acknowledged_at is null"
+	}
+
+	countParams := append([]interface{}{}, params...)
+	limitQuery := ""
+
+	if itemsPerPage > 0 {
+		params = append(params, pageIndex*itemsPerPage, itemsPerPage)
+		limitQuery = " offset ? limit ?"
+	}
+
+	err := db.Raw(stockAlertsQuery+whereQuery+" order by sa.created_at desc, sa.id desc"+limitQuery, params...).
+		Scan(&alerts).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	err = db.Raw(`select count(sa.id) from stock_alerts sa`+whereQuery, countParams...).Scan(&total).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginationResponse[dto.StockAlertDto]{
+		Items:        alerts,
+		Total:        &total,
+		PageIndex:    pageIndex,
+		ItemsPerPage: itemsPerPage,
+	}, nil
+}
+
+func AcknowledgeStockAlert(vendorId uint, alertId uint) error {
+	db := database.GetDBInstance()
+	res := db.Model(&models.StockAlert{}).
+		Where("id = ? and vendor_id = ? and acknowledged_at is null", alertId, vendorId).
+		Updates(map[string]interface{}{"acknowledged_at": time.Now(), "updated_at": time.Now()})
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return common.ErrorResourceNotFound
+	}
+
+	return nil
+}
+
+// Periodically push the new stock alerts to the sessions of their vendors.
+// The alerts are raised inside the database transactions changing the stock,
+// so they are only pushed once these transactions are committed
+func RunStockAlertWorker(interval time.Duration, notify Notifier) {
+	for range time.Tick(interval) {
+		if err := notifyStockAlerts(notify); err != nil {
+			utils.LogErrorLn("failed to notify stock alerts", err)
+		}
+	}
+}
+
+// The alerts are marked as notified first, then pushed once that is
+// committed so a slow push does not hold the locks of the alerts
+func notifyStockAlerts(notify Notifier) error {
+	db := database.GetDBInstance()
+	alerts := []dto.StockAlertDto{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(stockAlertsQuery + ` where sa.notified_at is null
+			order by sa.id
+			for update of sa skip locked`).Scan(&alerts).Error
+
+		if err != nil || len(alerts) == 0 {
+			return err
+		}
+
+		alertIds := []uint{}
+		for _, alert := range alerts {
+			alertIds = append(alertIds, alert.ID)
+		}
+
+		return tx.Model(&models.StockAlert{}).Where("id in (?)", alertIds).
+			Update("notified_at", time.Now()).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		notify(alert.VendorID, StockAlertMsgType, alert)
+	}
+
+	return nil
+}
@@ -2,13 +2,14 @@ package carts
 
 import (
 	"errors"
-	"fmt"
 	"order-system/common"
 	"order-system/database"
 	"order-system/handlers/dto"
 	"order-system/models"
-	"order-system/utils"
+	"order-system/services/products"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -27,13 +28,32 @@ func FindUserCart(userId uint) (dto.CartDto, error) {
 		Items: make([]dto.CartItemDto, 0),
 	}
 
+	productIds := []uint{}
 	for _, i := range o {
+		productIds = append(productIds, i.ProductID)
+	}
+
+	// the price in effect now, the one of when the item was
+	// added is only shown if the product has no price anymore
+	prices, err := products.FindActivePrices(db, productIds, time.Now())
+	if err != nil {
+		return dto.CartDto{}, err
+	}
+
+	for _, i := range o {
+		price, currency := i.ProductPrice.Price, i.ProductPrice.Currency
+		if activePrice, ok := prices[i.ProductID]; ok {
+			price, currency = activePrice.Price, activePrice.Currency
+		}
+
 		item := dto.CartItemDto{
 			ID:           i.ID,
 			ProductID:    i.ProductID,
 			ProductName:  i.Product.Name,
-			ProductPrice: i.ProductPrice.Price,
-			Quantity:     uint(i.Quantity),
+			ProductPrice: price,
+			Currency:     currency,
+			Quantity:     i.Quantity,
+			Unit:         i.Product.Unit,
 			VendorID:     i.Product.VendorID,
 			VendorName:   i.Product.Vendor.Name,
 		}
@@ -55,7 +75,7 @@ func RemoveItemFromCart(cartId uint, productId uint) error {
 // Add a number of product item into the cart
 // The quantity will be checked and ensure that
 // it does not exceed the product stock quantity
-func AddItemToCart(cartId uint, productId uint, requiredQuantity uint, productPriceId uint) error {
+func AddItemToCart(cartId uint, productId uint, requiredQuantity decimal.Decimal, productPriceId uint) error {
 	db := database.GetDBInstance()
 
 	type Result struct {
@@ -63,9 +83,14 @@ func AddItemToCart(cartId uint, productId uint, requiredQuantity uint, productPr
 	}
 
 	return db.Transaction(func(tx *gorm.DB) error {
-		tableName := utils.GetModelTableName(models.CartItem{})
+		// the product row lock serializes the changes
+		// of the stock and of the cart items of this product
 		var err error
-		if err = tx.Raw(fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE;", tableName)).Error; err != nil {
+		if err = products.LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		if err = products.ValidateQuantity(tx, productId, requiredQuantity); err != nil {
 			return err
 		}
 		storedCartItem := models.CartItem{}
@@ -82,12 +107,14 @@ func AddItemToCart(cartId uint, productId uint, requiredQuantity uint, productPr
 		o := Result{}
 		if err := tx.Raw(`
 			select sum(stock_quantity) > sum(required_quantity) as result from (
-				(select sum(coalesce(pt.quantity, 0)) as stock_quantity, 0 as required_quantity from products p
-					left join product_transactions pt on p.id = pt.product_id
-																	where p.id = ?
+				(select coalesce(sum(ps.quantity), 0) as stock_quantity, 0 as required_quantity from product_stocks ps
+					where ps.product_id = ?
 				union
-				select 0 as stock_quantity, coalesce(max(quantity), 0) + ? as required_quantity from cart_items where cart_id = ? and product_id = ?)) d
-		`, productId, requiredQuantity, cartId, productId).Scan(&o).Error; err != nil {
+				select 0 as stock_quantity, coalesce(max(quantity), 0) + ? as required_quantity from cart_items where cart_id = ? and product_id = ?
+				union all
+				select -coalesce(sum(quantity), 0) as stock_quantity, 0 as required_quantity from stock_reservations
+					where product_id = ? and status = ? and expires_at > now())) d
+		`, productId, requiredQuantity, cartId, productId, productId, models.ReservationActive).Scan(&o).Error; err != nil {
 			return err
 		}
 
@@ -97,7 +124,7 @@ func AddItemToCart(cartId uint, productId uint, requiredQuantity uint, productPr
 
 		if cartItemExisted {
 			return tx.Where("cart_id = ? and product_id = ?", cartId, productId).Updates(&models.CartItem{
-				Quantity: storedCartItem.Quantity + uint64(requiredQuantity),
+				Quantity: storedCartItem.Quantity.Add(requiredQuantity),
 			}).Error
 		}
 		newCartItem := models.CartItem{
@@ -105,7 +132,7 @@ func AddItemToCart(cartId uint, productId uint, requiredQuantity uint, productPr
 			Active:         true,
 			CartID:         cartId,
 			ProductPriceId: productPriceId,
-			Quantity:       uint64(requiredQuantity),
+			Quantity:       requiredQuantity,
 		}
 
 		return tx.Create(&newCartItem).Error
@@ -115,7 +142,7 @@ func AddItemToCart(cartId uint, productId uint, requiredQuantity uint, productPr
 // Set the quantity of an entry in the cart
 // The quantity will be checked and ensure that
 // it does not exceed the product stock quantity
-func SetCartItemQuantity(cartId uint, productId uint, requiredQuantity uint) error {
+func SetCartItemQuantity(cartId uint, productId uint, requiredQuantity decimal.Decimal) error {
 	db := database.GetDBInstance()
 
 	type Result struct {
@@ -123,9 +150,11 @@ func SetCartItemQuantity(cartId uint, productId uint, requiredQuantity uint) err
 	}
 
 	return db.Transaction(func(tx *gorm.DB) error {
-		tableName := utils.GetModelTableName(models.CartItem{})
-		var err error
-		if err = tx.Raw(fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE;", tableName)).Error; err != nil {
+		if err := products.LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		if err := products.ValidateQuantity(tx, productId, requiredQuantity); err != nil {
 			return err
 		}
 
@@ -133,12 +162,14 @@ func SetCartItemQuantity(cartId uint, productId uint, requiredQuantity uint) err
 		o := Result{}
 		if err := tx.Raw(`
 			select sum(stock_quantity) > sum(required_quantity) as result from (
-				(select sum(coalesce(pt.quantity, 0)) as stock_quantity, 0 as required_quantity from products p
-					left join product_transactions pt on p.id = pt.product_id
-																	where p.id = ?
+				(select coalesce(sum(ps.quantity), 0) as stock_quantity, 0 as required_quantity from product_stocks ps
+					where ps.product_id = ?
 				union
-				select 0 as stock_quantity, ? as required_quantity from cart_items where cart_id = ? and product_id = ?)) d
-		`, productId, requiredQuantity, cartId, productId).Scan(&o).Error; err != nil {
+				select 0 as stock_quantity, ? as required_quantity from cart_items where cart_id = ? and product_id = ?
+				union all
+				select -coalesce(sum(quantity), 0) as stock_quantity, 0 as required_quantity from stock_reservations
+					where product_id = ? and status = ? and expires_at > now())) d
+		`, productId, requiredQuantity, cartId, productId, productId, models.ReservationActive).Scan(&o).Error; err != nil {
 			return err
 		}
 
@@ -147,7 +178,7 @@ func SetCartItemQuantity(cartId uint, productId uint, requiredQuantity uint) err
 		}
 
 		updatedCartItem := models.CartItem{
-			Quantity: uint64(requiredQuantity),
+			Quantity: requiredQuantity,
 		}
 		return tx.Where("cart_id = ? and product_id = ?", cartId, productId).Updates(&updatedCartItem).Error
 	})
@@ -0,0 +1,198 @@
+package categories
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+
+	"gorm.io/gorm"
+)
+
+// Find the whole category tree, the siblings are
+// ordered by their position then by their name
+func FindCategoryTree() ([]dto.CategoryDto, error) {
+	categories, err := findAllCategories(database.GetDBInstance())
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCategoryTree(categories), nil
+}
+
+func CreateCategory(payload dto.CategoryRequestDto) (*models.Category, error) {
+	db := database.GetDBInstance()
+	category := &models.Category{
+		Name:     payload.Name,
+		ParentID: payload.ParentID,
+		Position: payload.Position,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if payload.ParentID != nil {
+			if err := CheckCategoryExists(tx, *payload.ParentID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(category).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// Rename a category or move it under another parent, along
+// with its subtree. A category cannot be moved under itself
+// or one of its descendants
+func UpdateCategory(id uint, payload dto.CategoryRequestDto) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// two concurrent moves could each be valid alone and form a cycle together
+		if err := tx.Exec("lock table categories in share row exclusive mode").Error; err != nil {
+			return err
+		}
+
+		category := models.Category{}
+		if err := tx.First(&category, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrorResourceNotFound
+			}
+			return err
+		}
+
+		if payload.ParentID != nil {
+			if err := CheckCategoryExists(tx, *payload.ParentID); err != nil {
+				return err
+			}
+
+			descendants, err := FindDescendantIds(tx, id)
+			if err != nil {
+				return err
+			}
+
+			for _, descendant := range descendants {
+				if descendant == *payload.ParentID {
+					return common.ErrorCategoryCycle
+				}
+			}
+		}
+
+		return tx.Model(&category).Updates(map[string]interface{}{
+			"name":      payload.Name,
+			"parent_id": payload.ParentID,
+			"position":  payload.Position,
+		}).Error
+	})
+}
+
+func CheckCategoryExists(db *gorm.DB, id uint) error {
+	count := int64(0)
+	if err := db.Model(&models.Category{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return common.ErrorCategoryNotFound
+	}
+
+	return nil
+}
+
+// Find a category and all the categories below it
+func FindDescendantIds(db *gorm.DB, id uint) ([]uint, error) {
+	ids := []uint{}
+	err := db.Raw(`with recursive subtree as (
+			select id from categories where id = ? and deleted_at is null
+			union
+			select c.id from categories c
+				inner join subtree s on c.parent_id = s.id
+				where c.deleted_at is null
+		) select id from subtree`, id).Scan(&ids).Error
+
+	return ids, err
+}
+
+// Find the breadcrumbs of the given categories,
+// from the root down to the category itself
+func FindBreadcrumbs(categoryIds []uint) (map[uint][]dto.CategoryRefDto, error) {
+	result := make(map[uint][]dto.CategoryRefDto)
+	if len(categoryIds) == 0 {
+		return result, nil
+	}
+
+	categories, err := findAllCategories(database.GetDBInstance())
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[uint]models.Category)
+	for _, category := range categories {
+		byId[category.ID] = category
+	}
+
+	for _, id := range categoryIds {
+		if _, ok := result[id]; !ok {
+			result[id] = breadcrumbsOf(byId, id)
+		}
+	}
+
+	return result, nil
+}
+
+func findAllCategories(db *gorm.DB) ([]models.Category, error) {
+	categories := []models.Category{}
+	err := db.Order("position, name, id").Find(&categories).Error
+	return categories, err
+}
+
+func buildCategoryTree(categories []models.Category) []dto.CategoryDto {
+	children := make(map[uint][]models.Category)
+	roots := []models.Category{}
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(nodes []models.Category) []dto.CategoryDto
+	build = func(nodes []models.Category) []dto.CategoryDto {
+		result := []dto.CategoryDto{}
+		for _, node := range nodes {
+			result = append(result, dto.CategoryDto{
+				ID:       node.ID,
+				Name:     node.Name,
+				ParentID: node.ParentID,
+				Position: node.Position,
+				Children: build(children[node.ID]),
+			})
+		}
+		return result
+	}
+
+	return build(roots)
+}
+
+func breadcrumbsOf(byId map[uint]models.Category, id uint) []dto.CategoryRefDto {
+	breadcrumbs := []dto.CategoryRefDto{}
+	visited := make(map[uint]bool)
+
+	for current, ok := byId[id]; ok && !visited[current.ID]; {
+		visited[current.ID] = true
+		breadcrumbs = append([]dto.CategoryRefDto{{ID: current.ID, Name: current.Name}}, breadcrumbs...)
+
+		if current.ParentID == nil {
+			break
+		}
+		current, ok = byId[*current.ParentID]
+	}
+
+	return breadcrumbs
+}
@@ -0,0 +1,56 @@
+package categories
+
+import (
+	"order-system/models"
+	"testing"
+)
+
+func category(id uint, name string, parentId *uint) models.Category {
+	c := models.Category{Name: name, ParentID: parentId}
+	c.ID = id
+	return c
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	food, drinks := uint(1), uint(2)
+	tree := buildCategoryTree([]models.Category{
+		category(1, "Food", nil),
+		category(2, "Drinks", &food),
+		category(3, "Tea", &drinks),
+		category(4, "Tools", nil),
+	})
+
+	if len(tree) != 2 || tree[0].Name != "Food" || tree[1].Name != "Tools" {
+		t.Fatal("actual: v", tree)
+	}
+
+	if len(tree[0].Children) != 1 || len(tree[0].Children[0].Children) != 1 ||
+		tree[0].Children[0].Children[0].Name != "Tea" || len(tree[1].Children) != 0 {
+		t.Error("actual: v", tree)
+	}
+}
+
+func TestBreadcrumbsOf(t *testing.T) {
+	food, drinks, a, b := uint(1), uint(2), uint(4), uint(5)
+	byId := map[uint]models.Category{
+		1: category(1, "Food", nil),
+		2: category(2, "Drinks", &food),
+		3: category(3, "Tea", &drinks),
+		// a corrupted tree must not loop forever
+		4: category(4, "A", &b),
+		5: category(5, "B", &a),
+	}
+
+	breadcrumbs := breadcrumbsOf(byId, 3)
+	if len(breadcrumbs) != 3 || breadcrumbs[0].Name != "Food" || breadcrumbs[2].Name != "Tea" {
+		t.Error("actual: v", breadcrumbs)
+	}
+
+	if breadcrumbs := breadcrumbsOf(byId, 4); len(breadcrumbs) != 2 {
+		t.Error("actual: v", breadcrumbs)
+	}
+
+	if breadcrumbs := breadcrumbsOf(byId, 42); len(breadcrumbs) != 0 {
+		t.Error("actual: v", breadcrumbs)
+	}
+}
@@ -0,0 +1,91 @@
+package currencies
+
+import (
+	"order-system/common"
+	"order-system/config"
+	"order-system/models"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// The exchange rate of each currency in effect now, the value
+// of one unit of the currency in the base currency
+const CurrentRatesQuery = `select distinct on (currency) currency, rate from exchange_rates
+	where effective_from <= now()
+	order by currency, effective_from desc`
+
+// The currency of a price or of an order, upper case.
+// An empty currency is the base currency
+func NormalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) == 0 {
+		return config.GetConfig().BaseCurrency, nil
+	}
+
+	if !govalidator.IsISO4217(currency) {
+		return "", common.ErrorInvalidCurrency
+	}
+
+	return currency, nil
+}
+
+// Find the exchange rates of the given currencies in effect at an instant,
+// the base currency is worth 1 and the currencies without a rate at this
+// instant are left out
+func FindRates(db *gorm.DB, currencies []string, at time.Time) (map[string]decimal.Decimal, error) {
+	result := map[string]decimal.Decimal{config.GetConfig().BaseCurrency: decimal.NewFromInt(1)}
+	if len(currencies) == 0 {
+		return result, nil
+	}
+
+	rates := []models.ExchangeRate{}
+	err := db.Raw(`select distinct on (currency) * from exchange_rates
+		where currency in (?) and effective_from <= ?
+		order by currency, effective_from desc`, currencies, at).Scan(&rates).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rate := range rates {
+		if _, ok := result[rate.Currency]; !ok {
+			result[rate.Currency] = rate.Rate
+		}
+	}
+
+	return result, nil
+}
+
+// The rate from a currency to another one through their
+// rates in the base currency
+func Rate(from string, to string, rates map[string]decimal.Decimal) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	fromRate, ok := rates[from]
+	if !ok {
+		return decimal.Zero, common.ErrorExchangeRateMissing
+	}
+
+	toRate, ok := rates[to]
+	if !ok {
+		return decimal.Zero, common.ErrorExchangeRateMissing
+	}
+
+	return fromRate.Div(toRate), nil
+}
+
+// Convert an amount from a currency to another one, rounded to the cent
+func Convert(amount decimal.Decimal, from string, to string, rates map[string]decimal.Decimal) (decimal.Decimal, error) {
+	rate, err := Rate(from, to, rates)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return amount.Mul(rate).Round(2), nil
+}
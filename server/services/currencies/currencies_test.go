@@ -0,0 +1,99 @@
+package currencies
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var rates = map[string]decimal.Decimal{
+	"USD": decimal.NewFromInt(1),
+	"EUR": decimal.RequireFromString("1.1"),
+	"JPY": decimal.RequireFromString("0.0068"),
+}
+
+func init() {
+	config.GetConfig().BaseCurrency = "USD"
+}
+
+func TestConvert(t *testing.T) {
+	if amount, err := Convert(decimal.NewFromInt(10), "EUR", "USD", rates); err != nil || !amount.Equal(decimal.NewFromInt(11)) {
+		t.Log("expected: v", 11)
+		t.Error("actual: v", amount, err)
+	}
+
+	// through the base currency, rounded to the cent
+	if amount, err := Convert(decimal.NewFromInt(1000), "JPY", "EUR", rates); err != nil || !amount.Equal(decimal.RequireFromString("6.18")) {
+		t.Log("expected: v", "6.18")
+		t.Error("actual: v", amount, err)
+	}
+
+	if amount, err := Convert(decimal.NewFromInt(5), "GBP", "GBP", rates); err != nil || !amount.Equal(decimal.NewFromInt(5)) {
+		t.Error("actual: v", amount, err)
+	}
+
+	if _, err := Convert(decimal.NewFromInt(5), "GBP", "USD", rates); err != common.ErrorExchangeRateMissing {
+		t.Log("expected: v", common.ErrorExchangeRateMissing)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	if currency, err := NormalizeCurrency(" eur "); err != nil || currency != "EUR" {
+		t.Log("expected: v", "EUR")
+		t.Error("actual: v", currency, err)
+	}
+
+	if currency, _ := NormalizeCurrency(""); currency != "USD" {
+		t.Log("expected: v", "USD")
+		t.Error("actual: v", currency)
+	}
+
+	if _, err := NormalizeCurrency("EURO"); err != common.ErrorInvalidCurrency {
+		t.Log("expected: v", common.ErrorInvalidCurrency)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestParseRatesCSV(t *testing.T) {
+	parsed, err := ParseRatesCSV(strings.NewReader("Currency,Rate,Effective_From\neur,1.1,2026-01-01\nJPY,0.0068,2026-01-02T09:00:00Z\nGBP,1.27,\n"))
+	if err != nil || len(parsed) != 3 {
+		t.Fatal("actual: v", parsed, err)
+	}
+
+	if parsed[0].Currency != "EUR" || !parsed[0].Rate.Equal(decimal.RequireFromString("1.1")) ||
+		!parsed[0].EffectiveFrom.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("actual: v", parsed[0])
+	}
+
+	// right away without a start
+	if time.Since(parsed[2].EffectiveFrom) > time.Minute {
+		t.Error("actual: v", parsed[2])
+	}
+
+	invalid := []string{
+		"currency,rate\nEUR,abc\n",
+		"currency,rate\nEUR,0\n",
+		"currency,rate\nEURO,1.1\n",
+		"currency,rate\nUSD,1\n",
+		"currency,rate,effective_from\nEUR,1.1,yesterday\n",
+		"currency,rate,effective_from\nEUR,1.1,2026-01-01\nEUR,1.2,2026-01-01\n",
+	}
+
+	for _, content := range invalid {
+		_, err := ParseRatesCSV(strings.NewReader(content))
+		if !errors.Is(err, common.ErrorInvalidExchangeRate) && !errors.Is(err, common.ErrorInvalidCurrency) {
+			t.Error("actual: v", content, err)
+		}
+	}
+
+	if _, err := ParseRatesCSV(strings.NewReader("currency\nEUR\n")); err != common.ErrorInvalidCSV {
+		t.Log("expected: v", common.ErrorInvalidCSV)
+		t.Error("actual: v", err)
+	}
+}
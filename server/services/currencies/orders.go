@@ -0,0 +1,92 @@
+package currencies
+
+import (
+	"order-system/config"
+	"order-system/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// an item of an order with the currency of its price
+type orderItemCurrency struct {
+	ID            uint
+	OrderID       uint
+	OrderCurrency string
+	PriceCurrency string
+}
+
+// Record the exchange rates in effect of newly created orders: the rate
+// from the currency of each order to the base currency, and the rate from
+// the currency of the price of each item to the currency of its order.
+// The totals of the orders are computed with these rates from then on
+func RateOrders(tx *gorm.DB, orderIds []uint) error {
+	if len(orderIds) == 0 {
+		return nil
+	}
+
+	items := []orderItemCurrency{}
+	err := tx.Raw(`
+		select oi.id, oi.order_id, o.currency as order_currency, pp.currency as price_currency
+		from order_items oi
+		inner join orders o on oi.order_id = o.id
+		inner join product_prices pp on oi.product_price_id = pp.id
+		where oi.order_id in (?)`, orderIds).Scan(&items).Error
+
+	if err != nil {
+		return err
+	}
+
+	orders := []models.Order{}
+	if err := tx.Where("id in (?)", orderIds).Find(&orders).Error; err != nil {
+		return err
+	}
+
+	currencies := []string{}
+	for _, order := range orders {
+		currencies = append(currencies, order.Currency)
+	}
+	for _, item := range items {
+		currencies = append(currencies, item.PriceCurrency)
+	}
+
+	rates, err := FindRates(tx, currencies, time.Now())
+	if err != nil {
+		return err
+	}
+
+	base := config.GetConfig().BaseCurrency
+	for _, order := range orders {
+		rate, err := Rate(order.Currency, base, rates)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("exchange_rate", rate).Error; err != nil {
+			return err
+		}
+	}
+
+	// the items are updated by rate
+	itemIds := make(map[string][]uint)
+	itemRates := make(map[string]decimal.Decimal)
+	for _, item := range items {
+		rate, err := Rate(item.PriceCurrency, item.OrderCurrency, rates)
+		if err != nil {
+			return err
+		}
+
+		key := item.PriceCurrency + ">" + item.OrderCurrency
+		itemIds[key] = append(itemIds[key], item.ID)
+		itemRates[key] = rate
+	}
+
+	for key, ids := range itemIds {
+		if err := tx.Model(&models.OrderItem{}).Where("id in (?)", ids).Update("exchange_rate", itemRates[key]).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package currencies
+
+import (
+	"order-system/database"
+	"order-system/handlers/dto"
+	"time"
+)
+
+// Convert the current prices of products and of their variants into a
+// display currency, a price without a rate is left in its own currency
+func ConvertProducts(products []dto.Product, currency string) error {
+	currencies := []string{currency}
+	for _, product := range products {
+		currencies = append(currencies, product.Currency)
+		for _, variant := range product.Variants {
+			currencies = append(currencies, variant.Currency)
+		}
+	}
+
+	rates, err := FindRates(database.GetDBInstance(), currencies, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		if price, err := Convert(products[i].ProductPrice, products[i].Currency, currency, rates); err == nil {
+			products[i].ProductPrice = price
+			products[i].Currency = currency
+		}
+
+		for j := range products[i].Variants {
+			variant := &products[i].Variants[j]
+			if price, err := Convert(variant.ProductPrice, variant.Currency, currency, rates); err == nil {
+				variant.ProductPrice = price
+				variant.Currency = currency
+			}
+		}
+	}
+
+	return nil
+}
+
+// Convert the prices of the items of a cart into a display currency,
+// a price without a rate is left in its own currency
+func ConvertCart(cart *dto.CartDto, currency string) error {
+	currencies := []string{currency}
+	for _, item := range cart.Items {
+		currencies = append(currencies, item.Currency)
+	}
+
+	rates, err := FindRates(database.GetDBInstance(), currencies, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range cart.Items {
+		if price, err := Convert(cart.Items[i].ProductPrice, cart.Items[i].Currency, currency, rates); err == nil {
+			cart.Items[i].ProductPrice = price
+			cart.Items[i].Currency = currency
+		}
+	}
+
+	return nil
+}
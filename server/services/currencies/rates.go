@@ -0,0 +1,218 @@
+package currencies
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"order-system/common"
+	"order-system/config"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// the layouts of the start of a rate in a rates file
+var effectiveFromLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// Record an exchange rate, a rate of a currency recorded for the same start
+// is replaced. The orders keep the rates they were placed with
+func CreateExchangeRate(payload dto.ExchangeRateDto) (*models.ExchangeRate, error) {
+	rate := &models.ExchangeRate{
+		Currency:      strings.ToUpper(strings.TrimSpace(payload.Currency)),
+		Rate:          payload.Rate,
+		EffectiveFrom: time.Now(),
+	}
+
+	if payload.EffectiveFrom != nil {
+		rate.EffectiveFrom = *payload.EffectiveFrom
+	}
+
+	if err := validateExchangeRate(rate); err != nil {
+		return nil, err
+	}
+
+	if err := saveExchangeRates(database.GetDBInstance(), []models.ExchangeRate{*rate}); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func validateExchangeRate(rate *models.ExchangeRate) error {
+	currency, err := NormalizeCurrency(rate.Currency)
+	if err != nil || len(rate.Currency) == 0 {
+		return common.ErrorInvalidCurrency
+	}
+
+	// the base currency is always worth 1
+	if currency == config.GetConfig().BaseCurrency || !rate.Rate.IsPositive() {
+		return common.ErrorInvalidExchangeRate
+	}
+
+	rate.Currency = currency
+	return nil
+}
+
+func saveExchangeRates(db *gorm.DB, rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "effective_from"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate"}),
+	}).Create(&rates).Error
+}
+
+// Find the exchange rates of a currency (of all the
+// currencies when empty), the latest first
+func FindExchangeRates(currency string) ([]models.ExchangeRate, error) {
+	rates := []models.ExchangeRate{}
+	query := database.GetDBInstance().Order("currency, effective_from desc")
+	if len(currency) > 0 {
+		query = query.Where("currency = ?", strings.ToUpper(currency))
+	}
+
+	err := query.Find(&rates).Error
+	return rates, err
+}
+
+// The currencies a buyer can pick, the base currency
+// first then the ones with a rate in effect
+func FindCurrencies() (dto.CurrenciesDto, error) {
+	base := config.GetConfig().BaseCurrency
+	result := dto.CurrenciesDto{Base: base, Currencies: []string{base}}
+
+	currencies := []string{}
+	err := database.GetDBInstance().Raw(`select r.currency from (` + CurrentRatesQuery + `) r`).
+		Scan(&currencies).Error
+
+	if err != nil {
+		return result, err
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		if currency != base {
+			result.Currencies = append(result.Currencies, currency)
+		}
+	}
+
+	return result, nil
+}
+
+// Load the exchange rates of a file in CSV, with the currency, rate and
+// effective_from columns. The whole file is rejected when a row is invalid
+func LoadRatesFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	rates, err := ParseRatesCSV(file)
+	if err != nil {
+		return 0, err
+	}
+
+	err = database.GetDBInstance().Transaction(func(tx *gorm.DB) error {
+		return saveExchangeRates(tx, rates)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+// Parse exchange rates in CSV, the first line is the header and the
+// columns are found by name. A rate without a start takes effect right away
+func ParseRatesCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, common.ErrorInvalidCSV
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	_, hasCurrency := index["currency"]
+	_, hasRate := index["rate"]
+	if !hasCurrency || !hasRate {
+		return nil, common.ErrorInvalidCSV
+	}
+
+	now := time.Now()
+	rates := []models.ExchangeRate{}
+	seen := make(map[string]bool)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, common.ErrorInvalidCSV
+		}
+
+		values := make(map[string]string)
+		for _, column := range []string{"currency", "rate", "effective_from"} {
+			if i, ok := index[column]; ok && i < len(record) {
+				values[column] = strings.TrimSpace(record[i])
+			}
+		}
+
+		rate := models.ExchangeRate{Currency: values["currency"], EffectiveFrom: now}
+		if rate.Rate, err = decimal.NewFromString(values["rate"]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, common.ErrorInvalidExchangeRate)
+		}
+
+		if len(values["effective_from"]) > 0 {
+			if rate.EffectiveFrom, err = parseEffectiveFrom(values["effective_from"]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, common.ErrorInvalidExchangeRate)
+			}
+		}
+
+		if err := validateExchangeRate(&rate); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		// a rate is given once for a start
+		key := rate.Currency + "@" + rate.EffectiveFrom.UTC().String()
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: %w", line, common.ErrorInvalidExchangeRate)
+		}
+		seen[key] = true
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseEffectiveFrom(value string) (time.Time, error) {
+	var err error
+	for _, layout := range effectiveFromLayouts {
+		var at time.Time
+		if at, err = time.Parse(layout, value); err == nil {
+			return at, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,244 @@
+package listing
+
+import (
+	"order-system/common"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type FieldType int
+
+const (
+	String FieldType = iota
+	Integer
+	Decimal
+	Date
+	Boolean
+)
+
+const (
+	OperatorEq  = "eq"
+	OperatorNe  = "ne"
+	OperatorGt  = "gt"
+	OperatorGte = "gte"
+	OperatorLt  = "lt"
+	OperatorLte = "lte"
+	OperatorIn  = "in"
+
+	dateLayout = "2006-01-02"
+)
+
+var sqlOperators = map[string]string{
+	OperatorEq:  "=",
+	OperatorNe:  "<>",
+	OperatorGt:  ">",
+	OperatorGte: ">=",
+	OperatorLt:  "<",
+	OperatorLte: "<=",
+}
+
+// `field` or `field[operator]`
+var filterKey = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)(?:\[([a-z]+)\])?$`)
+
+// A field of a list, known by its name in the API
+type Field struct {
+	// the SQL expression of the field, it never comes from the request
+	Column string
+	Type   FieldType
+	// the field can be sorted on but not filtered, e.g. an aggregate
+	SortOnly bool
+}
+
+// The fields a list can be filtered and sorted on. Only these fields
+// are accepted, a filter or a sort on any other is rejected
+type Spec struct {
+	Fields map[string]Field
+	// filters handled by the finder itself, they are accepted as they are
+	Custom []string
+	// used when the request has no sort
+	DefaultSort string
+	// appended to every sort so the pages are stable
+	TieBreaker string
+	// the columns of the keyset pagination, nil when
+	// the list can only be paginated by offset
+	Keyset *Keyset
+}
+
+// Translate the filters into SQL conditions, each one starting with " and ".
+// A filter is `field` (equality) or `field[operator]` with the operators eq, ne,
+// gt, gte, lt, lte and in (comma separated values). A date without a time
+// covers the whole day, `createdAt[lte]=2024-01-31` includes the 31st
+func (s Spec) Where(filters map[string]string) (string, []interface{}, error) {
+	keys := []string{}
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	// the same filters always give the same query
+	sort.Strings(keys)
+
+	query := ""
+	params := []interface{}{}
+
+	for _, key := range keys {
+		if s.isCustom(key) {
+			continue
+		}
+
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			return "", nil, common.ErrorInvalidFilter
+		}
+
+		field, ok := s.Fields[match[1]]
+		if !ok || field.SortOnly {
+			return "", nil, common.ErrorInvalidFilter
+		}
+
+		operator := match[2]
+		if len(operator) == 0 {
+			operator = OperatorEq
+		}
+
+		condition, conditionParams, err := field.condition(operator, filters[key])
+		if err != nil {
+			return "", nil, err
+		}
+
+		query += " and " + condition
+		params = append(params, conditionParams...)
+	}
+
+	return query, params, nil
+}
+
+// Translate a sort, comma separated fields with a leading "-"
+// for a descending order, e.g. `-createdAt,id`, into an order by clause
+func (s Spec) OrderBy(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		value = s.DefaultSort
+	}
+
+	columns := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		direction := "asc"
+		if strings.HasPrefix(name, "-") {
+			direction = "desc"
+			name = name[1:]
+		}
+
+		field, ok := s.Fields[name]
+		if !ok {
+			return "", common.ErrorInvalidSort
+		}
+
+		columns = append(columns, field.Column+" "+direction)
+	}
+
+	if len(s.TieBreaker) > 0 {
+		columns = append(columns, s.TieBreaker)
+	}
+
+	return " order by " + strings.Join(columns, ", "), nil
+}
+
+func (s Spec) isCustom(key string) bool {
+	for _, custom := range s.Custom {
+		if key == custom {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Field) condition(operator string, value string) (string, []interface{}, error) {
+	if operator == OperatorIn {
+		if f.Type == Date || f.Type == Boolean {
+			return "", nil, common.ErrorInvalidFilter
+		}
+
+		values := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			parsed, err := f.parse(strings.TrimSpace(item))
+			if err != nil {
+				return "", nil, err
+			}
+			values = append(values, parsed)
+		}
+
+		return f.Column + " in (?)", []interface{}{values}, nil
+	}
+
+	sqlOperator, ok := sqlOperators[operator]
+	if !ok {
+		return "", nil, common.ErrorInvalidFilter
+	}
+
+	if f.Type == Boolean && operator != OperatorEq && operator != OperatorNe {
+		return "", nil, common.ErrorInvalidFilter
+	}
+
+	if f.Type == Date {
+		if day, err := time.Parse(dateLayout, value); err == nil {
+			return f.dayCondition(operator, day)
+		}
+	}
+
+	parsed, err := f.parse(value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return f.Column + " " + sqlOperator + " ?", []interface{}{parsed}, nil
+}
+
+// A date without a time is the whole day, from its
+// start (included) to the start of the next one (excluded)
+func (f Field) dayCondition(operator string, day time.Time) (string, []interface{}, error) {
+	nextDay := day.AddDate(0, 0, 1)
+
+	switch operator {
+	case OperatorEq:
+		return "(" + f.Column + " >= ? and " + f.Column + " < ?)", []interface{}{day, nextDay}, nil
+	case OperatorNe:
+		return "(" + f.Column + " < ? or " + f.Column + " >= ?)", []interface{}{day, nextDay}, nil
+	case OperatorGt:
+		return f.Column + " >= ?", []interface{}{nextDay}, nil
+	case OperatorGte:
+		return f.Column + " >= ?", []interface{}{day}, nil
+	case OperatorLt:
+		return f.Column + " < ?", []interface{}{day}, nil
+	default:
+		return f.Column + " < ?", []interface{}{nextDay}, nil
+	}
+}
+
+func (f Field) parse(value string) (interface{}, error) {
+	var parsed interface{}
+	var err error
+
+	switch f.Type {
+	case Integer:
+		parsed, err = strconv.ParseInt(value, 10, 64)
+	case Decimal:
+		parsed, err = decimal.NewFromString(value)
+	case Date:
+		parsed, err = time.Parse(time.RFC3339, value)
+	case Boolean:
+		parsed, err = strconv.ParseBool(value)
+	default:
+		parsed = value
+	}
+
+	if err != nil {
+		return nil, common.ErrorInvalidFilter
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,89 @@
+package listing
+
+import (
+	"order-system/common"
+	"testing"
+	"time"
+)
+
+var spec = Spec{
+	Fields: map[string]Field{
+		"id":         {Column: "o.id", Type: Integer},
+		"status":     {Column: "ot1.status", Type: String},
+		"createdAt":  {Column: "o.created_at", Type: Date},
+		"paid":       {Column: "o.paid", Type: Boolean},
+		"totalPrice": {Column: "total_price", Type: Decimal, SortOnly: true},
+	},
+	Custom:      []string{"q"},
+	DefaultSort: "-createdAt",
+	TieBreaker:  "o.id desc",
+}
+
+func TestWhere(t *testing.T) {
+	query, params, err := spec.Where(map[string]string{
+		"status[in]":     "PAID, SHIPPED",
+		"id[gt]":         "10",
+		"createdAt[lte]": "2024-01-31",
+		"q":              "ignored",
+	})
+
+	if err != nil {
+		t.Fatal("error while translating filters", err)
+	}
+
+	expected := " and o.created_at < ? and o.id > ? and ot1.status in (?)"
+	if query != expected {
+		t.Log("expected: v", expected)
+		t.Error("actual: v", query)
+	}
+
+	// the whole last day is included
+	if len(params) != 3 || !params[0].(time.Time).Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) ||
+		params[1].(int64) != 10 || len(params[2].([]interface{})) != 2 {
+		t.Error("actual: v", params)
+	}
+
+	query, params, _ = spec.Where(map[string]string{"createdAt": "2024-01-31"})
+	if query != " and (o.created_at >= ? and o.created_at < ?)" || len(params) != 2 {
+		t.Error("actual: v", query, params)
+	}
+}
+
+func TestWhereRejectsInvalidFilters(t *testing.T) {
+	invalid := []map[string]string{
+		{"unknown": "1"},
+		{"status[like]": "PAID"},
+		{"id": "one"},
+		{"totalPrice[gt]": "10"},
+		{"paid[gt]": "true"},
+		{"createdAt[in]": "2024-01-01"},
+		{"o.id = 1 or 1": "1"},
+	}
+
+	for _, filters := range invalid {
+		if _, _, err := spec.Where(filters); err != common.ErrorInvalidFilter {
+			t.Log("expected: v", filters, common.ErrorInvalidFilter)
+			t.Error("actual: v", err)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	sorts := map[string]string{
+		"":                  " order by o.created_at desc, o.id desc",
+		"-totalPrice, id":   " order by total_price desc, o.id asc, o.id desc",
+		"status,-createdAt": " order by ot1.status asc, o.created_at desc, o.id desc",
+	}
+
+	for sort, expected := range sorts {
+		if actual, err := spec.OrderBy(sort); err != nil || actual != expected {
+			t.Log("expected: v", expected)
+			t.Error("actual: v", actual, err)
+		}
+	}
+
+	if _, err := spec.OrderBy("createdAt; drop table orders"); err != common.ErrorInvalidSort {
+		t.Log("expected: v", common.ErrorInvalidSort)
+		t.Error("actual: v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"order-system/common"
+	"order-system/handlers/dto"
+	"time"
+)
+
+// The columns of the keyset pagination of a list, the
+// rows are ordered by a time then by an id, newest first
+type Keyset struct {
+	TimeColumn string
+	IDColumn   string
+}
+
+// The position after which the next page starts,
+// it is handed to the clients as an opaque token
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   uint      `json:"i"`
+}
+
+// The order and the bounds of a page of a list, either by page index
+// (offset) or after a cursor (keyset). A page after a cursor fetches one
+// more row than requested to know if there is a next page
+type Page struct {
+	Keyset       bool
+	Order        string
+	Where        string
+	WhereParams  []interface{}
+	Limit        string
+	LimitParams  []interface{}
+	ItemsPerPage int
+}
+
+// The page requested by a pagination query. The keyset pagination
+// has its own order, a list without keyset only supports the offset
+func (s Spec) Page(query dto.PaginationQuery) (*Page, error) {
+	page := &Page{ItemsPerPage: query.ItemsPerPage}
+
+	if query.Cursor == nil {
+		order, err := s.OrderBy(query.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Order = order
+		if query.ItemsPerPage > 0 {
+			page.Limit = " offset ? limit ?"
+			page.LimitParams = []interface{}{query.PageIndex * query.ItemsPerPage, query.ItemsPerPage}
+		}
+
+		return page, nil
+	}
+
+	if s.Keyset == nil || len(query.Sort) > 0 {
+		return nil, common.ErrorInvalidSort
+	}
+
+	page.Keyset = true
+	page.Order = " order by " + s.Keyset.TimeColumn + " desc, " + s.Keyset.IDColumn + " desc"
+	page.Limit = " limit ?"
+	page.LimitParams = []interface{}{query.ItemsPerPage + 1}
+
+	if len(*query.Cursor) == 0 {
+		return page, nil
+	}
+
+	cursor, err := DecodeCursor(*query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Where = " and (" + s.Keyset.TimeColumn + ", " + s.Keyset.IDColumn + ") < (?, ?)"
+	page.WhereParams = []interface{}{cursor.Time, cursor.ID}
+
+	return page, nil
+}
+
+// Drop the extra row of a keyset page and give the cursor
+// of the next page, which starts after the last row kept
+func NextPage[T interface{}](page *Page, items []T, key func(T) Cursor) ([]T, string) {
+	if !page.Keyset || len(items) <= page.ItemsPerPage {
+		return items, ""
+	}
+
+	items = items[:page.ItemsPerPage]
+	return items, EncodeCursor(key(items[len(items)-1]))
+}
+
+func EncodeCursor(cursor Cursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	cursor := Cursor{}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, common.ErrorInvalidCursor
+	}
+
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, common.ErrorInvalidCursor
+	}
+
+	return cursor, nil
+}
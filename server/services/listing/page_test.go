@@ -0,0 +1,84 @@
+package listing
+
+import (
+	"order-system/common"
+	"order-system/handlers/dto"
+	"testing"
+	"time"
+)
+
+var keysetSpec = Spec{
+	Fields:      spec.Fields,
+	DefaultSort: spec.DefaultSort,
+	TieBreaker:  spec.TieBreaker,
+	Keyset:      &Keyset{TimeColumn: "o.created_at", IDColumn: "o.id"},
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	expected := Cursor{Time: time.Date(2024, 1, 31, 10, 30, 0, 123000000, time.UTC), ID: 42}
+
+	actual, err := DecodeCursor(EncodeCursor(expected))
+	if err != nil || !actual.Time.Equal(expected.Time) || actual.ID != expected.ID {
+		t.Log("expected: v", expected)
+		t.Error("actual: v", actual, err)
+	}
+
+	for _, token := range []string{"not a cursor!", "bm90IGpzb24", EncodeCursor(Cursor{Time: expected.Time})} {
+		if _, err := DecodeCursor(token); err != common.ErrorInvalidCursor {
+			t.Log("expected: v", common.ErrorInvalidCursor)
+			t.Error("actual: v", token, err)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	page, err := keysetSpec.Page(dto.PaginationQuery{PageIndex: 2, ItemsPerPage: 10})
+	if err != nil || page.Keyset || page.Order != " order by o.created_at desc, o.id desc" ||
+		page.Limit != " offset ? limit ?" || page.LimitParams[0] != 20 {
+		t.Error("actual: v", page, err)
+	}
+
+	first := ""
+	page, err = keysetSpec.Page(dto.PaginationQuery{ItemsPerPage: 10, Cursor: &first})
+	if err != nil || !page.Keyset || len(page.Where) != 0 || page.Limit != " limit ?" || page.LimitParams[0] != 11 {
+		t.Error("actual: v", page, err)
+	}
+
+	cursor := EncodeCursor(Cursor{Time: time.Now(), ID: 7})
+	page, err = keysetSpec.Page(dto.PaginationQuery{ItemsPerPage: 10, Cursor: &cursor})
+	if err != nil || page.Where != " and (o.created_at, o.id) < (?, ?)" || page.WhereParams[1] != uint(7) {
+		t.Error("actual: v", page, err)
+	}
+
+	// the keyset has its own order
+	if _, err := keysetSpec.Page(dto.PaginationQuery{Cursor: &cursor, Sort: "id"}); err != common.ErrorInvalidSort {
+		t.Log("expected: v", common.ErrorInvalidSort)
+		t.Error("actual: v", err)
+	}
+
+	if _, err := spec.Page(dto.PaginationQuery{Cursor: &first}); err != common.ErrorInvalidSort {
+		t.Log("expected: v", common.ErrorInvalidSort)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	key := func(id uint) Cursor { return Cursor{ID: id} }
+	page := &Page{Keyset: true, ItemsPerPage: 2}
+
+	items, next := NextPage(page, []uint{5, 4, 3}, key)
+	if len(items) != 2 || next != EncodeCursor(Cursor{ID: 4}) {
+		t.Error("actual: v", items, next)
+	}
+
+	// the last page has no next cursor
+	items, next = NextPage(page, []uint{2, 1}, key)
+	if len(items) != 2 || len(next) != 0 {
+		t.Error("actual: v", items, next)
+	}
+
+	items, next = NextPage(&Page{ItemsPerPage: 2}, []uint{5, 4, 3}, key)
+	if len(items) != 3 || len(next) != 0 {
+		t.Error("actual: v", items, next)
+	}
+}
@@ -0,0 +1,154 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif" // registered for image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"order-system/common"
+)
+
+const (
+	// the longest side of a thumbnail, in pixels
+	ThumbnailSize = 320
+	// a small file can still decode into a huge image
+	maxImagePixels = 40_000_000
+	jpegQuality    = 85
+)
+
+// the accepted content types and the extension of their files
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// An uploaded image checked and decoded, with its thumbnail
+type Image struct {
+	Content     []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+
+	Thumbnail            []byte
+	ThumbnailContentType string
+	ThumbnailExtension   string
+}
+
+// Read an uploaded image and make its thumbnail. The content type is
+// sniffed from the content, whatever the client claims, and only JPEG,
+// PNG and GIF are accepted. A file over maxSize bytes is rejected
+func ProcessImage(content io.Reader, maxSize int64) (*Image, error) {
+	b, err := io.ReadAll(io.LimitReader(content, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > maxSize {
+		return nil, common.ErrorImageTooLarge
+	}
+
+	contentType := http.DetectContentType(b)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, common.ErrorInvalidImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return nil, common.ErrorInvalidImage
+	}
+
+	if config.Width*config.Height > maxImagePixels {
+		return nil, common.ErrorImageTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, common.ErrorInvalidImage
+	}
+
+	result := &Image{
+		Content:     b,
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+
+	// a JPEG has no transparency to keep, the other formats are thumbnailed as PNG
+	thumbnail := Thumbnail(decoded, ThumbnailSize)
+	buffer := &bytes.Buffer{}
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(buffer, thumbnail, &jpeg.Options{Quality: jpegQuality})
+		result.ThumbnailContentType, result.ThumbnailExtension = "image/jpeg", "jpg"
+	} else {
+		err = png.Encode(buffer, thumbnail)
+		result.ThumbnailContentType, result.ThumbnailExtension = "image/png", "png"
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Thumbnail = buffer.Bytes()
+	return result, nil
+}
+
+// Scale an image down so that its longest side is at most size, keeping
+// its aspect ratio. Each pixel is the average of the source pixels it
+// covers, a smaller image is only copied
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	width, height := srcWidth, srcHeight
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, srcHeight*size/srcWidth)
+		} else {
+			width, height = max(1, srcWidth*size/srcHeight), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcHeight/height)
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcWidth/width)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r / count) >> 8),
+				G: uint8((g / count) >> 8),
+				B: uint8((b / count) >> 8),
+				A: uint8((a / count) >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"order-system/common"
+	"strings"
+	"testing"
+)
+
+func encodedImage(t *testing.T, width int, height int, format string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(buffer, img, nil)
+	} else {
+		err = png.Encode(buffer, img)
+	}
+
+	if err != nil {
+		t.Fatal("error while encoding image", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestProcessImage(t *testing.T) {
+	result, err := ProcessImage(bytes.NewReader(encodedImage(t, 800, 400, "jpeg")), 1<<20)
+	if err != nil {
+		t.Fatal("error while processing image", err)
+	}
+
+	if result.ContentType != "image/jpeg" || result.Extension != "jpg" || result.Width != 800 || result.Height != 400 ||
+		result.ThumbnailContentType != "image/jpeg" {
+		t.Error("actual: v", result.ContentType, result.Extension, result.Width, result.Height)
+	}
+
+	thumbnail, err := jpeg.DecodeConfig(bytes.NewReader(result.Thumbnail))
+	if err != nil || thumbnail.Width != ThumbnailSize || thumbnail.Height != ThumbnailSize/2 {
+		t.Error("actual: v", thumbnail, err)
+	}
+
+	result, err = ProcessImage(bytes.NewReader(encodedImage(t, 10, 20, "png")), 1<<20)
+	if err != nil || result.Extension != "png" || result.ThumbnailExtension != "png" {
+		t.Error("actual: v", result, err)
+	}
+}
+
+func TestProcessImageRejectsInvalidImages(t *testing.T) {
+	content := encodedImage(t, 100, 100, "png")
+
+	invalid := map[string]error{
+		"not an image":                    common.ErrorInvalidImage,
+		"<svg xmlns='http://www.w3.org'>": common.ErrorInvalidImage,
+		// a truncated PNG has the right signature but does not decode
+		string(content[:len(content)/2]): common.ErrorInvalidImage,
+	}
+
+	for body, expected := range invalid {
+		if _, err := ProcessImage(strings.NewReader(body), 1<<20); err != expected {
+			t.Log("expected: v", expected)
+			t.Error("actual: v", err)
+		}
+	}
+
+	if _, err := ProcessImage(bytes.NewReader(content), int64(len(content)-1)); err != common.ErrorImageTooLarge {
+		t.Log("expected: v", common.ErrorImageTooLarge)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	sizes := map[[2]int][2]int{
+		{640, 480}:  {320, 240},
+		{480, 1000}: {153, 320},
+		{100, 50}:   {100, 50},
+		{5000, 1}:   {320, 1},
+	}
+
+	for source, expected := range sizes {
+		src := image.NewRGBA(image.Rect(0, 0, source[0], source[1]))
+		bounds := Thumbnail(src, 320).Bounds()
+		if bounds.Dx() != expected[0] || bounds.Dy() != expected[1] {
+			t.Log("expected: v", expected)
+			t.Error("actual: v", bounds)
+		}
+	}
+
+	// each pixel is the average of the pixels it covers
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{R: 255, A: 255})
+	src.Set(1, 0, color.RGBA{B: 255, A: 255})
+	if actual := Thumbnail(src, 1).RGBAAt(0, 0); actual != (color.RGBA{R: 127, B: 127, A: 255}) {
+		t.Error("actual: v", actual)
+	}
+}
@@ -0,0 +1,112 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"order-system/common"
+	"order-system/config"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Where the uploaded files are kept. A key is a slash separated
+// path, e.g. `products/1/5f0c.jpg`, chosen by the caller
+type BlobStore interface {
+	// Store the content under the key, replacing any previous one
+	Put(key string, content io.Reader) error
+	// Remove the content of the key, a missing key is not an error
+	Delete(key string) error
+	// The public URL of the content of the key
+	URL(key string) string
+}
+
+var store BlobStore
+
+func InitStore(config *config.Config) {
+	SetStore(NewLocalStore(config.StorageDir, config.StorageURL))
+}
+
+func SetStore(blobStore BlobStore) {
+	store = blobStore
+}
+
+func GetStore() BlobStore {
+	return store
+}
+
+// Keep the files in a directory of the local filesystem,
+// they are served by the app itself under baseURL
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir string, baseURL string) *LocalStore {
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStore) Put(key string, content io.Reader) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	// written aside then renamed, a reader never sees a partial file
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+func (s *LocalStore) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// The file of a key, a key cannot point outside of the directory
+func (s *LocalStore) path(key string) (string, error) {
+	if len(key) == 0 || strings.HasPrefix(key, "/") || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") {
+		return "", common.ErrorInvalidBlobKey
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,52 @@
+package media
+
+import (
+	"errors"
+	"order-system/common"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir, "/media/")
+
+	if err := store.Put("products/1/a.jpg", strings.NewReader("content")); err != nil {
+		t.Fatal("error while storing", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "products", "1", "a.jpg"))
+	if err != nil || string(b) != "content" {
+		t.Error("actual: v", string(b), err)
+	}
+
+	if url := store.URL("products/1/a.jpg"); url != "/media/products/1/a.jpg" {
+		t.Error("actual: v", url)
+	}
+
+	if err := store.Delete("products/1/a.jpg"); err != nil {
+		t.Error("actual: v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "products", "1", "a.jpg")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("actual: v", err)
+	}
+
+	// already deleted
+	if err := store.Delete("products/1/a.jpg"); err != nil {
+		t.Error("actual: v", err)
+	}
+}
+
+func TestLocalStoreRejectsInvalidKeys(t *testing.T) {
+	store := NewLocalStore(t.TempDir(), "/media")
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "products/../../secret", "products//a.jpg", ".."} {
+		if err := store.Put(key, strings.NewReader("content")); err != common.ErrorInvalidBlobKey {
+			t.Log("expected: v", key, common.ErrorInvalidBlobKey)
+			t.Error("actual: v", err)
+		}
+	}
+}
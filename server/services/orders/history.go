@@ -0,0 +1,74 @@
+package orders
+
+import (
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"time"
+)
+
+// The longest time an order is expected to stay in a status,
+// e.g. a paid order should be shipped within 48 hours
+var StatusSLAs = map[models.OrderStatus]time.Duration{
+	models.OrderPlaced:   time.Hour * 24,
+	models.OrderPaid:     time.Hour * 48,
+	models.OrderShipping: time.Hour * 24 * 7,
+}
+
+func FindOrderHistory(orderId uint) (dto.OrderHistoryDto, error) {
+	db := database.GetDBInstance()
+	transitions := []dto.OrderTransitionDto{}
+
+	err := db.Raw(`
+		select ot.*, coalesce(u.name, '') as actor_name
+			from order_transactions ot
+			left join users u on ot.actor_id = u.id
+		where ot.order_id = ?
+		order by ot.created_at asc, ot.id asc`, orderId).Scan(&transitions).Error
+
+	if err != nil {
+		return dto.OrderHistoryDto{}, err
+	}
+
+	history := buildOrderHistory(transitions, time.Now(), StatusSLAs)
+	history.OrderID = orderId
+
+	return history, nil
+}
+
+// Compute the time spent in each status and the SLA breaches
+// from the ordered list of transitions of an order
+func buildOrderHistory(transitions []dto.OrderTransitionDto, now time.Time, slas map[models.OrderStatus]time.Duration) dto.OrderHistoryDto {
+	history := dto.OrderHistoryDto{
+		Transitions: transitions,
+		SLABreaches: []dto.OrderSLABreachDto{},
+	}
+
+	for i := range transitions {
+		until := now
+		current := i == len(transitions)-1
+		if !current {
+			until = transitions[i+1].CreatedAt
+		}
+
+		duration := until.Sub(transitions[i].CreatedAt)
+		transitions[i].DurationSeconds = int64(duration.Seconds())
+		transitions[i].Current = current
+
+		if current {
+			history.Status = transitions[i].Status
+		}
+
+		limit, ok := slas[transitions[i].Status]
+		if ok && duration > limit {
+			history.SLABreaches = append(history.SLABreaches, dto.OrderSLABreachDto{
+				Status:          transitions[i].Status,
+				LimitSeconds:    int64(limit.Seconds()),
+				DurationSeconds: transitions[i].DurationSeconds,
+				Ongoing:         current,
+			})
+		}
+	}
+
+	return history
+}
@@ -0,0 +1,50 @@
+package orders
+
+import (
+	"order-system/handlers/dto"
+	"order-system/models"
+	"testing"
+	"time"
+)
+
+func TestBuildOrderHistory(t *testing.T) {
+	placedAt := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	transitions := []dto.OrderTransitionDto{
+		{Status: models.OrderPlaced, CreatedAt: placedAt},
+		{Status: models.OrderPaid, CreatedAt: placedAt.Add(time.Minute)},
+		{Status: models.OrderShipping, CreatedAt: placedAt.Add(time.Hour * 50)},
+	}
+
+	history := buildOrderHistory(transitions, placedAt.Add(time.Hour*60), StatusSLAs)
+
+	if history.Status != models.OrderShipping {
+		t.Errorf("expected current status %s, got %s", models.OrderShipping, history.Status)
+	}
+
+	if history.Transitions[0].DurationSeconds != 60 || history.Transitions[2].DurationSeconds != int64(time.Hour*10/time.Second) {
+		t.Error("actual: v", []interface{}{history.Transitions[0].DurationSeconds, history.Transitions[2].DurationSeconds})
+	}
+
+	if !history.Transitions[2].Current || history.Transitions[1].Current {
+		t.Error("expected only the last transition to be current")
+	}
+
+	if len(history.SLABreaches) != 1 ||
+		history.SLABreaches[0].Status != models.OrderPaid ||
+		history.SLABreaches[0].Ongoing {
+		t.Errorf("expected a single past breach of %s, got %+v", models.OrderPaid, history.SLABreaches)
+	}
+}
+
+func TestBuildOrderHistoryOngoingBreach(t *testing.T) {
+	placedAt := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	transitions := []dto.OrderTransitionDto{
+		{Status: models.OrderPlaced, CreatedAt: placedAt},
+	}
+
+	history := buildOrderHistory(transitions, placedAt.Add(time.Hour*25), StatusSLAs)
+
+	if len(history.SLABreaches) != 1 || !history.SLABreaches[0].Ongoing {
+		t.Errorf("expected an ongoing breach, got %+v", history.SLABreaches)
+	}
+}
@@ -6,21 +6,70 @@ import (
 	"order-system/database"
 	"order-system/handlers/dto"
 	"order-system/models"
+	"order-system/services/currencies"
+	"order-system/services/listing"
+	"order-system/services/payments"
+	"order-system/services/products"
+	"order-system/services/promotions"
+	"order-system/services/reservations"
+	"order-system/services/taxes"
+	"order-system/services/warehouses"
+	"order-system/utils"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// the subtotal of each order, its items at their prices in the currency of the order
+const orderSubtotalsQuery = `select oi.order_id, sum(round(coalesce(pp.price, 0) * oi.exchange_rate, 2) * oi.quantity) as subtotal
+	from order_items oi
+	left join product_prices pp on oi.product_price_id = pp.id
+	group by oi.order_id`
+
+// the total of the adjustments of each order, its discounts (a negative amount) and its taxes
+const orderAdjustmentTotalsQuery = `select oa.order_id, sum(oa.amount) as total,
+	sum(oa.amount) filter (where oa.type = '` + string(models.AdjustmentDiscount) + `') as discount,
+	sum(oa.amount) filter (where oa.type = '` + string(models.AdjustmentTax) + `') as tax
+	from order_adjustments oa
+	group by oa.order_id`
+
+// the amounts of an order o joined by "st" and "adj"
+const orderAmountsFields = `coalesce(st.subtotal, 0) as subtotal, coalesce(adj.discount, 0) as discount, coalesce(adj.tax, 0) as tax,
+	coalesce(st.subtotal, 0) + coalesce(adj.total, 0) as total_price`
+
+const orderAmountsJoins = `left join (` + orderSubtotalsQuery + `) st on o.id = st.order_id
+	left join (` + orderAdjustmentTotalsQuery + `) adj on o.id = adj.order_id`
+
 type VendorProduct struct {
 	VendorId uint `gorm:"column:vendor_id"`
 }
 
-func CreateOrders(userId uint, orders []models.Order) error {
+// The choices of the buyer at checkout
+type Checkout struct {
+	// the current prices the buyer accepted (by product)
+	// after the checkout failed with the changed prices
+	AcceptedPrices map[uint]uint
+	// a coupon to apply to the orders, none when empty
+	CouponCode string
+}
+
+// Create the orders of the user from their cart items,
+// the items are ordered at their current prices
+func CreateOrders(userId uint, orders []models.Order, checkout Checkout) error {
 	db := database.GetDBInstance()
 
-	return db.Transaction(func(tx *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
 		orderItems := make(map[int]([]models.OrderItem))
 
+		if err := verifyStock(tx, userId, orders); err != nil {
+			return err
+		}
+
+		// the cart items are moved to the current prices before being copied into the orders
+		if err := repriceCart(tx, userId, orderedProductIds(orders), checkout.AcceptedPrices); err != nil {
+			return err
+		}
+
 		// Find another way to
 		// ensure integrity between order, vendor, order item and product
 		for i, order := range orders {
@@ -86,7 +135,7 @@ func CreateOrders(userId uint, orders []models.Order) error {
 				productIds = append(productIds, item.ProductID)
 			}
 
-			res := tx.Debug().Exec(orderItemsCreateQuery, orderId, productIds, userId)
+			res := tx.Exec(orderItemsCreateQuery, orderId, productIds, userId)
 
 			if res.Error != nil {
 				return res.Error
@@ -99,9 +148,30 @@ func CreateOrders(userId uint, orders []models.Order) error {
 			return err
 		}
 
-		// for the sake of simplicity
-		// all orders are in status of "PAID"
-		// after creating
+		createdOrderIds := []uint{}
+		for _, order := range orders {
+			createdOrderIds = append(createdOrderIds, order.ID)
+		}
+
+		// the items are converted into the currency of their order,
+		// then the discounts and the taxes (on the discounted items)
+		// are known before the payments are created
+		if err := currencies.RateOrders(tx, createdOrderIds); err != nil {
+			return err
+		}
+
+		if len(checkout.CouponCode) > 0 {
+			if err := promotions.ApplyCoupon(tx, userId, checkout.CouponCode, createdOrderIds); err != nil {
+				return err
+			}
+		}
+
+		if err := taxes.TaxOrders(tx, createdOrderIds); err != nil {
+			return err
+		}
+
+		// orders start as "PLACED", they are moved to "PAID"
+		// once their payment provider confirms the payment
 		orderTransactions := []models.OrderTransaction{}
 		for _, order := range orders {
 			newOrderTransaction := models.OrderTransaction{
@@ -111,39 +181,238 @@ func CreateOrders(userId uint, orders []models.Order) error {
 			}
 			newOrderTransaction.CreatedAt = time.Now()
 			orderTransactions = append(orderTransactions, newOrderTransaction)
-			newOrderTransaction = models.OrderTransaction{
-				OrderID:        order.ID,
-				PreviousStatus: models.OrderPlaced,
-				Status:         models.OrderPaid,
-			}
-			newOrderTransaction.CreatedAt = time.Now().Add(time.Second * 10)
-			orderTransactions = append(orderTransactions, newOrderTransaction)
 		}
 
 		if err := tx.Create(orderTransactions).Error; err != nil {
 			return err
 		}
 
+		for _, order := range orders {
+			if err := payments.CreateIntent(tx, order.ID, order.PaymentMethodID); err != nil {
+				return err
+			}
+		}
+
+		// each order is fulfilled from a single warehouse of its vendor
 		orderIds := []uint{}
 		for _, order := range orders {
+			warehouseId, err := warehouses.PickFulfillingWarehouse(tx, order.ID)
+			if err != nil {
+				return err
+			}
+
+			if warehouseId != nil {
+				if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).
+					Update("warehouse_id", *warehouseId).Error; err != nil {
+					return err
+				}
+			}
+
 			orderIds = append(orderIds, order.ID)
 		}
 
-		// export the corresponding products
-		// the product transaction entries will
-		// be created based on their corresponding order items
-		productTransactionsCreateQuery := `
-			insert into product_transactions (created_at, type, product_id, quantity, description)
-				(select now(), ?, oi.product_id, -oi.quantity, concat(concat('order ', oi.order_id), ' placed')
-				from order_items oi
-				inner join orders o on oi.order_id = o.id
-				where o.id in (?))`
+		// hold the stock of the ordered products, the holds
+		// are turned into product transactions once the orders are paid
+		if err := reservations.HoldOrders(tx, orderIds, time.Now().Add(reservations.HoldTTL())); err != nil {
+			return err
+		}
+
+		// cash on delivery orders are not waiting for any payment
+		for _, order := range orders {
+			if order.PaymentMethodID != models.PaymentMethodCOD {
+				continue
+			}
+
+			if err := reservations.Consume(tx, order.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// a declined or failed payment does not fail the checkout,
+	// the order stays "PLACED" and its payment can be retried
+	for _, order := range orders {
+		if _, err := ProcessOrderPayment(order.ID); err != nil {
+			utils.LogErrorLn("failed to process payment of order", order.ID, err)
+		}
+	}
+
+	return nil
+}
 
-		if err := tx.Exec(productTransactionsCreateQuery, models.TransactionTypeOut, orderIds).Error; err != nil {
+// Lock the ordered products and make sure that each of them
+// has enough available stock for the quantity in the user cart
+func verifyStock(tx *gorm.DB, userId uint, orders []models.Order) error {
+	productIds := orderedProductIds(orders)
+
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	if err := products.LockProducts(tx, productIds); err != nil {
+		return err
+	}
+
+	lines := []common.InsufficientStockItem{}
+	err := tx.Raw(`
+		select p.id as product_id, p.name as product_name, ci.quantity as requested,
+			coalesce((select ps.quantity from product_stocks ps where ps.product_id = p.id), 0) -
+			coalesce((select sum(sr.quantity) from stock_reservations sr
+				where sr.product_id = p.id and sr.status = ? and sr.expires_at > now()), 0) as available
+		from products p
+		inner join cart_items ci on ci.product_id = p.id
+		inner join carts c on ci.cart_id = c.id
+		where p.id in (?) and c.user_id = ?
+		order by p.id`, models.ReservationActive, productIds, userId).Scan(&lines).Error
+
+	if err != nil {
+		return err
+	}
+
+	shortItems := []common.InsufficientStockItem{}
+	for _, line := range lines {
+		if line.Requested.GreaterThan(line.Available) {
+			shortItems = append(shortItems, line)
+		}
+	}
+
+	if len(shortItems) > 0 {
+		return &common.InsufficientStockError{Items: shortItems}
+	}
+
+	return nil
+}
+
+func orderedProductIds(orders []models.Order) []uint {
+	productIds := []uint{}
+	for _, order := range orders {
+		for _, item := range order.Items {
+			productIds = append(productIds, item.ProductID)
+		}
+	}
+
+	return productIds
+}
+
+// Charge the payment of an order (or retry a failed one),
+// the order is moved to "PAID" when the charge is confirmed right away
+func ProcessOrderPayment(orderId uint) (models.PaymentIntent, error) {
+	db := database.GetDBInstance()
+	intent := models.PaymentIntent{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		intent, err = payments.ChargeIntent(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		return markOrderPaid(tx, intent)
+	})
+
+	return intent, err
+}
+
+// Check a pending payment of an order with its provider
+func RefreshOrderPayment(orderId uint) (models.PaymentIntent, error) {
+	db := database.GetDBInstance()
+	intent := models.PaymentIntent{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		intent, err = payments.RefreshIntent(tx, orderId)
+		if err != nil {
 			return err
 		}
 
+		return markOrderPaid(tx, intent)
+	})
+
+	return intent, err
+}
+
+// Periodically confirm the payments that are still pending
+// on the provider side (delayed confirmations)
+func RunPaymentConfirmationWorker(interval time.Duration) {
+	for range time.Tick(interval) {
+		orderIds, err := payments.FindPendingIntentOrderIds()
+		if err != nil {
+			utils.LogErrorLn("failed to find pending payments", err)
+			continue
+		}
+
+		for _, orderId := range orderIds {
+			if _, err := RefreshOrderPayment(orderId); err != nil {
+				utils.LogErrorLn("failed to refresh payment of order", orderId, err)
+			}
+		}
+	}
+}
+
+// Periodically cancel the unpaid orders whose stock holds are expired
+func RunReservationExpiryWorker(interval time.Duration) {
+	for range time.Tick(interval) {
+		orderIds, err := reservations.FindExpiredOrderIds(time.Now())
+		if err != nil {
+			utils.LogErrorLn("failed to find expired reservations", err)
+			continue
+		}
+
+		for _, orderId := range orderIds {
+			if err := expireOrderReservations(orderId); err != nil {
+				utils.LogErrorLn("failed to expire reservations of order", orderId, err)
+			}
+		}
+	}
+}
+
+func expireOrderReservations(orderId uint) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		status, err := findOrderStatus(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		// the order is already paid or cancelled,
+		// only the leftover holds have to be released
+		if status != models.OrderPlaced {
+			return reservations.Release(tx, orderId)
+		}
+
+		return orderStateMachine.Apply(tx, orderId, TransitionCancel, TransitionRequest{
+			ActorRole: models.ActorSystem,
+			Reason:    "stock reservation expired",
+		})
+	})
+}
+
+func markOrderPaid(tx *gorm.DB, intent models.PaymentIntent) error {
+	if intent.Status != models.PaymentIntentSucceeded {
+		return nil
+	}
+
+	status, err := findOrderStatus(tx, intent.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// the order could have been cancelled
+	// while waiting for the confirmation
+	if status != models.OrderPlaced {
 		return nil
+	}
+
+	return orderStateMachine.Apply(tx, intent.OrderID, TransitionPay, TransitionRequest{
+		ActorRole: models.ActorSystem,
+		Reason:    fmt.Sprintf("payment %s confirmed by %s", intent.ProviderReference, intent.Provider),
 	})
 }
 
@@ -152,22 +421,18 @@ func FindOrder(id uint) (dto.OrderDto, error) {
 	order := dto.OrderDto{}
 
 	orderQuery := `
-		select o.*, pm.name as payment_method_name, u.name as vendor_name, u1.name as user_name, sum(coalesce(pp1.price,0) * oi.quantity) as total_price, ot1.created_at as status_change_time, ot1.status as status
+		select o.*, pm.name as payment_method_name, u.name as vendor_name, u1.name as user_name,
+			` + orderAmountsFields + `, ot1.created_at as status_change_time, ot1.status as status
 			from orders o
 			left join users u on o.vendor_id = u.id
 			left join users u1 on o.user_id = u1.id
-			left join order_items oi on o.id = oi.order_id
-			left join product_prices pp1 on (oi.product_price_id = pp1.id)
-			left join product_prices pp2 on  (oi.product_price_id = pp2.id and
-											(pp1.created_at < pp2.created_at or (pp1.created_at = pp2.created_at and pp1.id < pp2.id)))
-
+			` + orderAmountsJoins + `
 			left join order_transactions ot1 on (o.id = ot1.order_id)
 			left join order_transactions ot2 on (o.id = ot2.order_id and
 												(ot1.created_at < ot2.created_at or
 												(ot1.created_at = ot2.created_at and ot1.id < ot2.id)))
 			left join payment_methods pm on o.payment_method_id = pm.id
-			where ot2.id is null and  pp2.id is null and o.id = ?
-			group by o.id, pp1.price, ot1.status, o.created_at, ot1.created_at, u.name, u1.name, pm.name
+			where ot2.id is null and o.id = ?
 	`
 
 	if err := db.Raw(orderQuery, id).Scan(&order).Error; err != nil {
@@ -185,7 +450,22 @@ func FindOrder(id uint) (dto.OrderDto, error) {
 			ProductID:   uint32(item.ProductID),
 			ProductName: item.Product.Name,
 			Quantity:    item.Quantity,
-			UnitPrice:   item.ProductPrice.Price,
+			UnitPrice:   item.ProductPrice.Price.Mul(item.ExchangeRate).Round(2),
+		})
+	}
+
+	adjustments := []models.OrderAdjustment{}
+	if err := db.Where("order_id = ?", id).Order("id").Find(&adjustments).Error; err != nil {
+		return order, err
+	}
+
+	order.Adjustments = []dto.OrderAdjustmentDto{}
+	for _, adjustment := range adjustments {
+		order.Adjustments = append(order.Adjustments, dto.OrderAdjustmentDto{
+			Type:        adjustment.Type,
+			Description: adjustment.Description,
+			Amount:      adjustment.Amount,
+			Rate:        adjustment.Rate,
 		})
 	}
 
@@ -207,39 +487,90 @@ func OrderExists(userId uint, orderId uint, isVendor bool) (bool, error) {
 	return exists, err
 }
 
-func getOrderNextStatus(status models.OrderStatus) (models.OrderStatus, error) {
-	switch status {
-	case models.OrderPaid:
-		return models.OrderShipping, nil
-	case models.OrderPlaced:
-		return models.OrderPaid, nil
-	case models.OrderShipping:
-		return models.OrderShipped, nil
-	default:
-		return models.OrderZeroStatus, common.ErrorOrderFinalStateReached
-	}
+// Process the order into its next status
+func SetNextStatusForOrder(orderId uint, vendorId uint) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		status, err := findOrderStatus(tx, orderId)
+
+		if err != nil {
+			return err
+		}
+
+		next, err := orderStateMachine.Next(status)
+
+		if err != nil {
+			return err
+		}
+
+		return orderStateMachine.Apply(tx, orderId, next, TransitionRequest{
+			ActorID:   vendorId,
+			ActorRole: models.ActorVendor,
+		})
+	})
 }
 
-// Process the order into its next state
-func SetNextStatusForOrder(orderId uint) error {
+// Move an order to the requested status, the transition
+// leading to that status must be allowed for the actor
+func TransitionOrder(orderId uint, target models.OrderStatus, req TransitionRequest) error {
 	db := database.GetDBInstance()
-	status, err := FindOrderStatus(orderId)
 
-	if err != nil {
-		return err
-	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		status, err := findOrderStatus(tx, orderId)
 
-	nextStatus, err := getOrderNextStatus(status)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
-	}
+		name, err := orderStateMachine.Resolve(status, target)
+
+		if err != nil {
+			return err
+		}
+
+		return orderStateMachine.Apply(tx, orderId, name, req)
+	})
+}
 
-	return db.Create(&models.OrderTransaction{
-		PreviousStatus: status,
-		Status:         nextStatus,
-		OrderID:        orderId,
-	}).Error
+// The fields of the orders of a user and of a vendor, the total
+// price is an aggregate so it can only be sorted on
+var orderFields = map[string]listing.Field{
+	"id":               {Column: "o.id", Type: listing.Integer},
+	"status":           {Column: "ot1.status", Type: listing.String},
+	"createdAt":        {Column: "o.created_at", Type: listing.Date},
+	"statusChangeTime": {Column: "ot1.created_at", Type: listing.Date},
+	"paymentMethodId":  {Column: "o.payment_method_id", Type: listing.String},
+	"totalPrice":       {Column: "total_price", Type: listing.Decimal, SortOnly: true},
+}
+
+// the orders are paginated by keyset on their latest status change
+var orderKeyset = &listing.Keyset{TimeColumn: "ot1.created_at", IDColumn: "o.id"}
+
+var userOrdersSpec = listing.Spec{
+	Fields:      withOrderField("vendorId", listing.Field{Column: "o.vendor_id", Type: listing.Integer}),
+	DefaultSort: "-statusChangeTime",
+	TieBreaker:  "o.id desc",
+	Keyset:      orderKeyset,
+}
+
+var vendorOrdersSpec = listing.Spec{
+	Fields:      withOrderField("userId", listing.Field{Column: "o.user_id", Type: listing.Integer}),
+	DefaultSort: "-statusChangeTime",
+	TieBreaker:  "o.id desc",
+	Keyset:      orderKeyset,
+}
+
+func orderCursor(order dto.OrderDto) listing.Cursor {
+	return listing.Cursor{Time: order.StatusChangeTime, ID: order.Id}
+}
+
+func withOrderField(name string, field listing.Field) map[string]listing.Field {
+	fields := map[string]listing.Field{name: field}
+	for key, value := range orderFields {
+		fields[key] = value
+	}
+	return fields
 }
 
 // Find all the orders that are made by an user
@@ -248,13 +579,22 @@ func FindAllOrdersOfUser(userId uint, paginationQuery dto.PaginationQuery) (*dto
 	o := []dto.OrderDto{}
 	pageIndex := paginationQuery.PageIndex
 	itemsPerPage := paginationQuery.ItemsPerPage
-	filterStatus, ok := paginationQuery.Filters["status"]
 
-	params := []interface{}{userId}
-	countParams := []interface{}{userId}
+	filterQuery, filterParams, err := userOrdersSpec.Where(paginationQuery.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := userOrdersSpec.Page(paginationQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	params := append([]interface{}{userId}, filterParams...)
+	countParams := append([]interface{}{userId}, filterParams...)
 
-	whereQuery := "where ot2.id is null and  pp2.id is null and o.user_id = ?"
-	limitQuery := ""
+	whereQuery := "where ot2.id is null and o.user_id = ?" + filterQuery + page.Where
+	params = append(append(params, page.WhereParams...), page.LimitParams...)
 
 	countQuery := `select count(o.id)
 		from orders o
@@ -262,44 +602,34 @@ func FindAllOrdersOfUser(userId uint, paginationQuery dto.PaginationQuery) (*dto
         left join order_transactions ot2 on (o.id = ot2.order_id and
                                               (ot1.created_at < ot2.created_at or
                                                (ot1.created_at = ot2.created_at and ot1.id < ot2.id)))
-		where ot2.id is null and user_id = ?`
-	if ok {
-		whereQuery += " and ot1.status = ?"
-		countQuery += " and ot1.status = ?"
-		params = append(params, filterStatus)
-		countParams = append(countParams, filterStatus)
-	}
-
-	if itemsPerPage > 0 {
-		params = append(params, pageIndex*itemsPerPage, itemsPerPage)
-		limitQuery = " offset ? limit ?"
-	}
+		where ot2.id is null and o.user_id = ?` + filterQuery
 
 	res := db.Raw(`
-	select o.*, u.name as vendor_name, sum(coalesce(pp1.price,0) * oi.quantity) as total_price, ot1.created_at as status_change_time, ot1.status as status
+	select o.*, u.name as vendor_name,
+		`+orderAmountsFields+`, ot1.created_at as status_change_time, ot1.status as status
 		from orders o
 		left join users u on o.vendor_id = u.id
-        left join order_items oi on o.id = oi.order_id
-        left join product_prices pp1 on (oi.product_price_id = pp1.id)
-        left join product_prices pp2 on  (oi.product_price_id = pp2.id and
-                                         (pp1.created_at < pp2.created_at or (pp1.created_at = pp2.created_at and pp1.id < pp2.id)))
+        `+orderAmountsJoins+`
 
         left join order_transactions ot1 on (o.id = ot1.order_id)
         left join order_transactions ot2 on (o.id = ot2.order_id and
                                               (ot1.created_at < ot2.created_at or
                                                (ot1.created_at = ot2.created_at and ot1.id < ot2.id))) `+whereQuery+
-		`
-		 group by o.id, pp1.price, ot1.status, o.created_at, ot1.created_at, u.name 
-		 order by ot1.created_at desc`+limitQuery, params...).Scan(&o)
+		page.Order+page.Limit, params...).Scan(&o)
 
 	if res.Error != nil {
 		return nil, res.Error
 	}
 
-	total := 0
+	o, nextCursor := listing.NextPage(page, o, orderCursor)
 
-	if err := db.Raw(countQuery, countParams...).Scan(&total).Error; err != nil {
-		return nil, err
+	var total *int
+	if !paginationQuery.SkipTotal {
+		count := 0
+		if err := db.Raw(countQuery, countParams...).Scan(&count).Error; err != nil {
+			return nil, err
+		}
+		total = &count
 	}
 
 	return &dto.PaginationResponse[dto.OrderDto]{
@@ -307,6 +637,7 @@ func FindAllOrdersOfUser(userId uint, paginationQuery dto.PaginationQuery) (*dto
 		Total:        total,
 		PageIndex:    pageIndex,
 		ItemsPerPage: itemsPerPage,
+		NextCursor:   nextCursor,
 	}, nil
 }
 
@@ -316,13 +647,22 @@ func FindAllOrdersOfVendor(vendorId uint, paginationQuery dto.PaginationQuery) (
 	o := []dto.OrderDto{}
 	pageIndex := paginationQuery.PageIndex
 	itemsPerPage := paginationQuery.ItemsPerPage
-	filterStatus, ok := paginationQuery.Filters["status"]
 
-	params := []interface{}{vendorId}
-	countParams := []interface{}{vendorId}
+	filterQuery, filterParams, err := vendorOrdersSpec.Where(paginationQuery.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := vendorOrdersSpec.Page(paginationQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	params := append([]interface{}{vendorId}, filterParams...)
+	countParams := append([]interface{}{vendorId}, filterParams...)
 
-	whereQuery := "where ot2.id is null and  pp2.id is null and o.vendor_id = ?"
-	limitQuery := ""
+	whereQuery := "where ot2.id is null and o.vendor_id = ?" + filterQuery + page.Where
+	params = append(append(params, page.WhereParams...), page.LimitParams...)
 
 	countQuery := `select count(o.id)
 		from orders o
@@ -330,44 +670,34 @@ func FindAllOrdersOfVendor(vendorId uint, paginationQuery dto.PaginationQuery) (
         left join order_transactions ot2 on (o.id = ot2.order_id and
                                               (ot1.created_at < ot2.created_at or
                                                (ot1.created_at = ot2.created_at and ot1.id < ot2.id)))
-		where ot2.id is null and vendor_id = ?`
-	if ok {
-		whereQuery += " and ot1.status = ?"
-		countQuery += " and ot1.status = ?"
-		params = append(params, filterStatus)
-		countParams = append(countParams, filterStatus)
-	}
-
-	if itemsPerPage > 0 {
-		params = append(params, pageIndex*itemsPerPage, itemsPerPage)
-		limitQuery = " offset ? limit ?"
-	}
+		where ot2.id is null and o.vendor_id = ?` + filterQuery
 
-	res := db.Debug().Raw(`
-	select o.*, sum(coalesce(pp1.price,0) * oi.quantity) as total_price, ot1.created_at as status_change_time, ot1.status as status
+	res := db.Raw(`
+	select o.*,
+		`+orderAmountsFields+`, ot1.created_at as status_change_time, ot1.status as status
 		from orders o
 		left join users u on o.vendor_id = u.id
-        left join order_items oi on o.id = oi.order_id
-        left join product_prices pp1 on (oi.product_price_id = pp1.id)
-        left join product_prices pp2 on  (oi.product_price_id = pp2.id and
-                                         (pp1.created_at < pp2.created_at or (pp1.created_at = pp2.created_at and pp1.id < pp2.id)))
+        `+orderAmountsJoins+`
 
         join order_transactions ot1 on (o.id = ot1.order_id)
         left join order_transactions ot2 on (o.id = ot2.order_id and
                                               (ot1.created_at < ot2.created_at or
                                                (ot1.created_at = ot2.created_at and ot1.id < ot2.id))) `+whereQuery+
-		`
-		 group by o.id, pp1.price, ot1.status, o.created_at, ot1.created_at
-		 order by ot1.created_at desc`+limitQuery, params...).Scan(&o)
+		page.Order+page.Limit, params...).Scan(&o)
 
 	if res.Error != nil {
 		return nil, res.Error
 	}
 
-	total := 0
+	o, nextCursor := listing.NextPage(page, o, orderCursor)
 
-	if err := db.Raw(countQuery, countParams...).Scan(&total).Error; err != nil {
-		return nil, err
+	var total *int
+	if !paginationQuery.SkipTotal {
+		count := 0
+		if err := db.Raw(countQuery, countParams...).Scan(&count).Error; err != nil {
+			return nil, err
+		}
+		total = &count
 	}
 
 	return &dto.PaginationResponse[dto.OrderDto]{
@@ -375,59 +705,25 @@ func FindAllOrdersOfVendor(vendorId uint, paginationQuery dto.PaginationQuery) (
 		Total:        total,
 		PageIndex:    pageIndex,
 		ItemsPerPage: itemsPerPage,
+		NextCursor:   nextCursor,
 	}, nil
 }
 
 func FindOrderStatus(orderId uint) (models.OrderStatus, error) {
-	db := database.GetDBInstance()
+	return findOrderStatus(database.GetDBInstance(), orderId)
+}
+
+func findOrderStatus(db *gorm.DB, orderId uint) (models.OrderStatus, error) {
 	orderTransaction := models.OrderTransaction{}
-	err := db.Where("order_id = ?", orderId).Order("created_at DESC").First(&orderTransaction).Error
+	err := db.Where("order_id = ?", orderId).Order("created_at DESC, id DESC").First(&orderTransaction).Error
 
 	return orderTransaction.Status, err
 }
 
-func CancelOrder(id uint) error {
+func CancelOrder(id uint, req TransitionRequest) error {
 	db := database.GetDBInstance()
 
 	return db.Transaction(func(tx *gorm.DB) error {
-		latestOrderTransaction := models.OrderTransaction{}
-		err := tx.Where("order_id = ?", id).Order("created_at DESC").First(&latestOrderTransaction).Error
-
-		if err != nil {
-			return err
-		}
-
-		if latestOrderTransaction.Status == models.OrderCancelled ||
-			latestOrderTransaction.Status == models.OrderShipped ||
-			latestOrderTransaction.Status == models.OrderShipping {
-			return nil
-		}
-
-		order := models.Order{}
-		if err := tx.Preload("Items.Product").First(&order).Error; err != nil {
-			return err
-		}
-
-		transactionItems := []models.ProductTransaction{}
-		for _, item := range order.Items {
-			transactionItems = append(transactionItems, models.ProductTransaction{
-				Type:        models.TransactionTypeIn,
-				ProductID:   item.ProductID,
-				Quantity:    item.Quantity,
-				Description: fmt.Sprintf("cancel order %d", id),
-			})
-		}
-
-		if err := tx.Create(&transactionItems).Error; err != nil {
-			return err
-		}
-
-		orderTransaction := models.OrderTransaction{
-			OrderID:        id,
-			PreviousStatus: models.OrderShipping,
-			Status:         models.OrderCancelled,
-		}
-
-		return tx.Create(&orderTransaction).Error
+		return orderStateMachine.Apply(tx, id, TransitionCancel, req)
 	})
 }
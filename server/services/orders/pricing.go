@@ -0,0 +1,133 @@
+package orders
+
+import (
+	"order-system/common"
+	"order-system/config"
+	"order-system/models"
+	"order-system/services/products"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// a cart line with the price pinned when it was added
+type cartLinePrice struct {
+	ProductID      uint
+	ProductName    string
+	Quantity       decimal.Decimal
+	ProductPriceId uint
+	Price          decimal.Decimal
+	Currency       string
+}
+
+// Compare the prices pinned in the user cart to the current prices of the
+// ordered products. A cart line is moved to the current price when it went
+// down or when the buyer accepted it (acceptedPrices, by product). The
+// pinned price of a line is kept when it was still in effect at the start of
+// the grace period. Otherwise the checkout fails with the lines whose price
+// went up, or is now in another currency
+func repriceCart(tx *gorm.DB, userId uint, productIds []uint, acceptedPrices map[uint]uint) error {
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	lines := []cartLinePrice{}
+	err := tx.Raw(`
+		select ci.product_id, p.name as product_name, ci.quantity, ci.product_price_id, pp.price, pp.currency
+		from cart_items ci
+		inner join carts c on ci.cart_id = c.id
+		inner join products p on ci.product_id = p.id
+		inner join product_prices pp on ci.product_price_id = pp.id
+		where ci.product_id in (?) and c.user_id = ?
+		order by ci.product_id`, productIds, userId).Scan(&lines).Error
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	current, err := products.FindActivePrices(tx, productIds, now)
+	if err != nil {
+		return err
+	}
+
+	graced := map[uint]models.ProductPrice{}
+	if grace := config.GetConfig().PriceGracePeriod; grace > 0 {
+		graced, err = products.FindActivePrices(tx, productIds, now.Add(-grace))
+		if err != nil {
+			return err
+		}
+	}
+
+	repinned, changed, err := comparePrices(lines, current, graced, acceptedPrices)
+	if err != nil {
+		return err
+	}
+
+	if len(changed) > 0 {
+		return &common.PriceChangedError{Items: changed}
+	}
+
+	for productId, priceId := range repinned {
+		err := tx.Exec(`
+			update cart_items set product_price_id = ?, updated_at = now()
+			where product_id = ? and cart_id in (select id from carts where user_id = ?)`,
+			priceId, productId, userId).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The new prices of the cart lines (by product) and the lines whose price
+// went up without being accepted by the buyer. The prices in different
+// currencies are not compared, the new one has to be accepted
+func comparePrices(
+	lines []cartLinePrice,
+	current map[uint]models.ProductPrice,
+	graced map[uint]models.ProductPrice,
+	acceptedPrices map[uint]uint,
+) (map[uint]uint, []common.PriceChangedItem, error) {
+	repinned := make(map[uint]uint)
+	changed := []common.PriceChangedItem{}
+
+	for _, line := range lines {
+		price, ok := current[line.ProductID]
+		if !ok {
+			return nil, nil, common.ErrorPriceUnavailable
+		}
+
+		if price.ID == line.ProductPriceId {
+			continue
+		}
+
+		sameCurrency := price.Currency == line.Currency
+
+		if gracedPrice, ok := graced[line.ProductID]; ok && gracedPrice.ID == line.ProductPriceId &&
+			sameCurrency && line.Price.LessThanOrEqual(price.Price) {
+			continue
+		}
+
+		if (sameCurrency && price.Price.LessThanOrEqual(line.Price)) || acceptedPrices[line.ProductID] == price.ID {
+			repinned[line.ProductID] = price.ID
+			continue
+		}
+
+		changed = append(changed, common.PriceChangedItem{
+			ProductID:        line.ProductID,
+			ProductName:      line.ProductName,
+			Quantity:         line.Quantity,
+			PreviousPrice:    line.Price,
+			CurrentPrice:     price.Price,
+			ProductPriceID:   price.ID,
+			PreviousCurrency: line.Currency,
+			CurrentCurrency:  price.Currency,
+		})
+	}
+
+	return repinned, changed, nil
+}
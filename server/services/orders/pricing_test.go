@@ -0,0 +1,74 @@
+package orders
+
+import (
+	"order-system/common"
+	"order-system/models"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func productPrice(id uint, productId uint, price int64) models.ProductPrice {
+	return models.ProductPrice{ID: id, ProductID: productId, Price: decimal.NewFromInt(price)}
+}
+
+func TestComparePrices(t *testing.T) {
+	lines := []cartLinePrice{
+		{ProductID: 1, ProductPriceId: 10, Price: decimal.NewFromInt(5)},
+		{ProductID: 2, ProductPriceId: 20, Price: decimal.NewFromInt(5)},
+		{ProductID: 3, ProductPriceId: 30, Price: decimal.NewFromInt(5)},
+		{ProductID: 4, ProductPriceId: 40, Price: decimal.NewFromInt(5)},
+	}
+	current := map[uint]models.ProductPrice{
+		// unchanged
+		1: productPrice(10, 1, 5),
+		// went down
+		2: productPrice(21, 2, 4),
+		// went up
+		3: productPrice(31, 3, 6),
+		4: productPrice(41, 4, 7),
+	}
+
+	repinned, changed, err := comparePrices(lines, current, nil, nil)
+	if err != nil || len(repinned) != 1 || repinned[2] != 21 || len(changed) != 2 ||
+		changed[0].ProductID != 3 || changed[0].ProductPriceID != 31 || !changed[0].PreviousPrice.Equal(decimal.NewFromInt(5)) {
+		t.Error("actual: v", repinned, changed, err)
+	}
+
+	// accepted prices are taken, an accepted price which is not current anymore is not
+	repinned, changed, err = comparePrices(lines, current, nil, map[uint]uint{3: 31, 4: 40})
+	if err != nil || repinned[3] != 31 || len(changed) != 1 || changed[0].ProductID != 4 {
+		t.Error("actual: v", repinned, changed, err)
+	}
+
+	// the pinned price is honored while it was in effect at the start of the grace period
+	graced := map[uint]models.ProductPrice{3: productPrice(30, 3, 5), 4: productPrice(39, 4, 5)}
+	repinned, changed, err = comparePrices(lines, current, graced, nil)
+	if err != nil || repinned[3] != 0 || len(changed) != 1 || changed[0].ProductID != 4 {
+		t.Error("actual: v", repinned, changed, err)
+	}
+
+	delete(current, 1)
+	if _, _, err := comparePrices(lines, current, nil, nil); err != common.ErrorPriceUnavailable {
+		t.Log("expected: v", common.ErrorPriceUnavailable)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestComparePricesOfAnotherCurrency(t *testing.T) {
+	lines := []cartLinePrice{{ProductID: 1, ProductPriceId: 10, Price: decimal.NewFromInt(5), Currency: "USD"}}
+	lower := productPrice(11, 1, 4)
+	lower.Currency = "EUR"
+	current := map[uint]models.ProductPrice{1: lower}
+
+	// a lower amount in another currency has to be accepted too
+	repinned, changed, err := comparePrices(lines, current, nil, nil)
+	if err != nil || len(repinned) != 0 || len(changed) != 1 || changed[0].PreviousCurrency != "USD" || changed[0].CurrentCurrency != "EUR" {
+		t.Error("actual: v", repinned, changed, err)
+	}
+
+	repinned, changed, err = comparePrices(lines, current, nil, map[uint]uint{1: 11})
+	if err != nil || repinned[1] != 11 || len(changed) != 0 {
+		t.Error("actual: v", repinned, changed, err)
+	}
+}
@@ -0,0 +1,365 @@
+package orders
+
+import (
+	"fmt"
+	"order-system/common"
+	"order-system/models"
+	"order-system/services/payments"
+	"order-system/services/reservations"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransitionName string
+
+const (
+	TransitionPay     TransitionName = "pay"
+	TransitionShip    TransitionName = "ship"
+	TransitionDeliver TransitionName = "deliver"
+	TransitionCancel  TransitionName = "cancel"
+	TransitionRefund  TransitionName = "refund"
+	TransitionReturn  TransitionName = "return"
+)
+
+// Who asks for a transition and why, recorded
+// along with the new status of the order
+type TransitionRequest struct {
+	ActorID         uint
+	ActorRole       models.ActorRole
+	Reason          string
+	TrackingCarrier string
+	TrackingNumber  string
+}
+
+// Everything a guard or an effect needs to know about
+// the order going through a transition
+type TransitionContext struct {
+	Tx     *gorm.DB
+	Order  models.Order
+	Status models.OrderStatus
+	Intent models.PaymentIntent
+	Actor  models.ActorRole
+}
+
+// A guard returns an error when the transition must not happen
+type TransitionGuard func(ctx *TransitionContext) error
+
+// An effect runs in the same database transaction
+// right before the new status is recorded
+type TransitionEffect func(ctx *TransitionContext) error
+
+type Transition struct {
+	Name    TransitionName
+	From    []models.OrderStatus
+	To      models.OrderStatus
+	Actors  []models.ActorRole
+	Guards  []TransitionGuard
+	Effects []TransitionEffect
+}
+
+type StateMachine struct {
+	transitions map[TransitionName]Transition
+	// transitions tried (in order) when an order
+	// is moved to its "next" status
+	forward []TransitionName
+}
+
+func NewStateMachine(forward []TransitionName, transitions ...Transition) *StateMachine {
+	m := &StateMachine{
+		transitions: make(map[TransitionName]Transition),
+		forward:     forward,
+	}
+
+	for _, t := range transitions {
+		m.transitions[t.Name] = t
+	}
+
+	return m
+}
+
+var orderStateMachine = NewStateMachine(
+	[]TransitionName{TransitionShip, TransitionDeliver},
+	Transition{
+		Name:    TransitionPay,
+		From:    []models.OrderStatus{models.OrderPlaced},
+		To:      models.OrderPaid,
+		Actors:  []models.ActorRole{models.ActorSystem},
+		Guards:  []TransitionGuard{guardPaymentSucceeded},
+		Effects: []TransitionEffect{effectConsumeReservations},
+	},
+	// cash on delivery orders are shipped without being paid
+	Transition{
+		Name:   TransitionShip,
+		From:   []models.OrderStatus{models.OrderPlaced, models.OrderPaid},
+		To:     models.OrderShipping,
+		Actors: []models.ActorRole{models.ActorVendor},
+		Guards: []TransitionGuard{guardPaidOrCashOnDelivery},
+	},
+	Transition{
+		Name:    TransitionDeliver,
+		From:    []models.OrderStatus{models.OrderShipping},
+		To:      models.OrderShipped,
+		Actors:  []models.ActorRole{models.ActorVendor},
+		Effects: []TransitionEffect{effectSettleCashOnDelivery},
+	},
+	Transition{
+		Name:    TransitionCancel,
+		From:    []models.OrderStatus{models.OrderPlaced, models.OrderPaid},
+		To:      models.OrderCancelled,
+		Actors:  []models.ActorRole{models.ActorBuyer, models.ActorVendor, models.ActorSystem},
+		Effects: []TransitionEffect{effectReleaseStock, effectVoidPayment},
+	},
+	Transition{
+		Name:    TransitionReturn,
+		From:    []models.OrderStatus{models.OrderShipped},
+		To:      models.OrderReturned,
+		Actors:  []models.ActorRole{models.ActorVendor},
+		Effects: []TransitionEffect{effectRestock("return order %d")},
+	},
+	Transition{
+		Name:    TransitionRefund,
+		From:    []models.OrderStatus{models.OrderCancelled, models.OrderReturned},
+		To:      models.OrderRefunded,
+		Actors:  []models.ActorRole{models.ActorVendor, models.ActorSystem},
+		Guards:  []TransitionGuard{guardPaymentSucceeded},
+		Effects: []TransitionEffect{effectRefundPayment},
+	},
+)
+
+func (m *StateMachine) Transition(name TransitionName) (Transition, bool) {
+	t, ok := m.transitions[name]
+	return t, ok
+}
+
+// Check that the transition exists, starts from the given status
+// and can be made by the actor. Guards are not evaluated here
+func (m *StateMachine) Validate(name TransitionName, from models.OrderStatus, actor models.ActorRole) (Transition, error) {
+	t, ok := m.transitions[name]
+	if !ok {
+		return t, invalidTransition(name, from, common.ErrorTransitionNotAllowed)
+	}
+
+	if !containsStatus(t.From, from) {
+		return t, invalidTransition(name, from, common.ErrorTransitionNotAllowed)
+	}
+
+	if !containsActor(t.Actors, actor) {
+		return t, invalidTransition(name, from, common.ErrorInsufficientPermission)
+	}
+
+	return t, nil
+}
+
+// Validate the transition and evaluate its guards
+func (m *StateMachine) Check(name TransitionName, ctx *TransitionContext) (Transition, error) {
+	t, err := m.Validate(name, ctx.Status, ctx.Actor)
+	if err != nil {
+		return t, err
+	}
+
+	for _, guard := range t.Guards {
+		if err := guard(ctx); err != nil {
+			return t, invalidTransition(name, ctx.Status, err)
+		}
+	}
+
+	return t, nil
+}
+
+// Find the first forward transition that starts from the given status
+func (m *StateMachine) Next(from models.OrderStatus) (TransitionName, error) {
+	for _, name := range m.forward {
+		if containsStatus(m.transitions[name].From, from) {
+			return name, nil
+		}
+	}
+
+	return "", &common.InvalidTransitionError{
+		Transition: "advance",
+		From:       string(from),
+		Err:        common.ErrorOrderFinalStateReached,
+	}
+}
+
+// Find the transition that moves an order from a status to another one
+func (m *StateMachine) Resolve(from models.OrderStatus, to models.OrderStatus) (TransitionName, error) {
+	for name, t := range m.transitions {
+		if t.To == to && containsStatus(t.From, from) {
+			return name, nil
+		}
+	}
+
+	return "", &common.InvalidTransitionError{
+		Transition: fmt.Sprintf("move to %s", to),
+		From:       string(from),
+		Err:        common.ErrorTransitionNotAllowed,
+	}
+}
+
+// Move an order through a transition. The order row is locked
+// so concurrent transitions of the same order are serialized
+func (m *StateMachine) Apply(tx *gorm.DB, orderId uint, name TransitionName, req TransitionRequest) error {
+	ctx := TransitionContext{Tx: tx, Actor: req.ActorRole}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ctx.Order, orderId).Error; err != nil {
+		return err
+	}
+
+	status, err := findOrderStatus(tx, orderId)
+	if err != nil {
+		return err
+	}
+	ctx.Status = status
+
+	if err := tx.Where("order_id = ?", orderId).Limit(1).Find(&ctx.Intent).Error; err != nil {
+		return err
+	}
+
+	t, err := m.Check(name, &ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, effect := range t.Effects {
+		if err := effect(&ctx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&models.OrderTransaction{
+		PreviousStatus:  ctx.Status,
+		Status:          t.To,
+		OrderID:         orderId,
+		ActorID:         req.ActorID,
+		ActorRole:       req.ActorRole,
+		Reason:          req.Reason,
+		TrackingCarrier: req.TrackingCarrier,
+		TrackingNumber:  req.TrackingNumber,
+	}).Error
+}
+
+func invalidTransition(name TransitionName, from models.OrderStatus, err error) error {
+	return &common.InvalidTransitionError{
+		Transition: string(name),
+		From:       string(from),
+		Err:        err,
+	}
+}
+
+func containsStatus(statuses []models.OrderStatus, status models.OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func containsActor(actors []models.ActorRole, actor models.ActorRole) bool {
+	for _, a := range actors {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+func guardPaymentSucceeded(ctx *TransitionContext) error {
+	if ctx.Intent.Status != models.PaymentIntentSucceeded {
+		return common.ErrorOrderNotPaid
+	}
+	return nil
+}
+
+func guardPaidOrCashOnDelivery(ctx *TransitionContext) error {
+	if ctx.Status == models.OrderPlaced && ctx.Order.PaymentMethodID != models.PaymentMethodCOD {
+		return common.ErrorOrderPaymentPending
+	}
+	return nil
+}
+
+// the cash is collected when the order is delivered
+func effectSettleCashOnDelivery(ctx *TransitionContext) error {
+	if ctx.Order.PaymentMethodID != models.PaymentMethodCOD {
+		return nil
+	}
+	return payments.SettleIntent(ctx.Tx, ctx.Order.ID)
+}
+
+func effectVoidPayment(ctx *TransitionContext) error {
+	return payments.CancelIntent(ctx.Tx, ctx.Order.ID)
+}
+
+func effectRefundPayment(ctx *TransitionContext) error {
+	return payments.RefundIntent(ctx.Tx, ctx.Order.ID)
+}
+
+func effectConsumeReservations(ctx *TransitionContext) error {
+	return reservations.Consume(ctx.Tx, ctx.Order.ID)
+}
+
+// Release the holds of a cancelled order and put back
+// the stock that was already taken out of the ledger
+func effectReleaseStock(ctx *TransitionContext) error {
+	held, err := reservations.FindReservationsOfOrder(ctx.Tx, ctx.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	// orders placed before the reservations were introduced
+	// have their whole stock in the ledger
+	if len(held) == 0 {
+		return effectRestock("cancel order %d")(ctx)
+	}
+
+	transactionItems := []models.ProductTransaction{}
+	for _, r := range held {
+		if r.Status != models.ReservationConsumed {
+			continue
+		}
+
+		transactionItems = append(transactionItems, models.ProductTransaction{
+			Type:        models.TransactionTypeIn,
+			ProductID:   r.ProductID,
+			Quantity:    r.Quantity,
+			Description: fmt.Sprintf("cancel order %d", ctx.Order.ID),
+			WarehouseID: r.WarehouseID,
+		})
+	}
+
+	if len(transactionItems) > 0 {
+		if err := ctx.Tx.Create(&transactionItems).Error; err != nil {
+			return err
+		}
+	}
+
+	return reservations.Release(ctx.Tx, ctx.Order.ID)
+}
+
+// put the items of the order back to the stock
+func effectRestock(descriptionFormat string) TransitionEffect {
+	return func(ctx *TransitionContext) error {
+		items := []models.OrderItem{}
+		if err := ctx.Tx.Where("order_id = ?", ctx.Order.ID).Find(&items).Error; err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		transactionItems := []models.ProductTransaction{}
+		for _, item := range items {
+			transactionItems = append(transactionItems, models.ProductTransaction{
+				Type:        models.TransactionTypeIn,
+				ProductID:   item.ProductID,
+				Quantity:    item.Quantity,
+				Description: fmt.Sprintf(descriptionFormat, ctx.Order.ID),
+				WarehouseID: ctx.Order.WarehouseID,
+			})
+		}
+
+		return ctx.Tx.Create(&transactionItems).Error
+	}
+}
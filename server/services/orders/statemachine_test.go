@@ -0,0 +1,89 @@
+package orders
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/models"
+	"testing"
+)
+
+func TestStateMachineValidate(t *testing.T) {
+	cases := []struct {
+		name     TransitionName
+		from     models.OrderStatus
+		actor    models.ActorRole
+		expected error
+	}{
+		{TransitionShip, models.OrderPaid, models.ActorVendor, nil},
+		{TransitionShip, models.OrderPaid, models.ActorBuyer, common.ErrorInsufficientPermission},
+		{TransitionShip, models.OrderShipped, models.ActorVendor, common.ErrorTransitionNotAllowed},
+		{TransitionCancel, models.OrderPlaced, models.ActorBuyer, nil},
+		{TransitionCancel, models.OrderShipping, models.ActorBuyer, common.ErrorTransitionNotAllowed},
+		{TransitionPay, models.OrderPlaced, models.ActorVendor, common.ErrorInsufficientPermission},
+		{TransitionRefund, models.OrderReturned, models.ActorVendor, nil},
+		{"unknown", models.OrderPlaced, models.ActorSystem, common.ErrorTransitionNotAllowed},
+	}
+
+	for _, c := range cases {
+		_, err := orderStateMachine.Validate(c.name, c.from, c.actor)
+		if c.expected == nil && err != nil {
+			t.Errorf("%s from %s by %s: unexpected error %v", c.name, c.from, c.actor, err)
+		}
+
+		if c.expected != nil {
+			var transitionErr *common.InvalidTransitionError
+			if !errors.As(err, &transitionErr) || !errors.Is(err, c.expected) {
+				t.Errorf("%s from %s by %s: expected %v, got %v", c.name, c.from, c.actor, c.expected, err)
+			}
+		}
+	}
+}
+
+func TestStateMachineGuards(t *testing.T) {
+	ctx := TransitionContext{
+		Order:  models.Order{PaymentMethodID: models.PaymentMethodCredit},
+		Status: models.OrderPlaced,
+		Actor:  models.ActorVendor,
+	}
+
+	if _, err := orderStateMachine.Check(TransitionShip, &ctx); !errors.Is(err, common.ErrorOrderPaymentPending) {
+		t.Errorf("expected an unpaid order not to be shipped, got %v", err)
+	}
+
+	ctx.Order.PaymentMethodID = models.PaymentMethodCOD
+	if _, err := orderStateMachine.Check(TransitionShip, &ctx); err != nil {
+		t.Errorf("expected a cash on delivery order to be shipped, got %v", err)
+	}
+
+	ctx.Actor = models.ActorSystem
+	if _, err := orderStateMachine.Check(TransitionPay, &ctx); !errors.Is(err, common.ErrorOrderNotPaid) {
+		t.Errorf("expected an order without payment not to be paid, got %v", err)
+	}
+
+	ctx.Intent.Status = models.PaymentIntentSucceeded
+	if _, err := orderStateMachine.Check(TransitionPay, &ctx); err != nil {
+		t.Errorf("expected a confirmed payment to pay the order, got %v", err)
+	}
+}
+
+func TestStateMachineNext(t *testing.T) {
+	next, err := orderStateMachine.Next(models.OrderShipping)
+	if err != nil || next != TransitionDeliver {
+		t.Errorf("expected %s, got %s (%v)", TransitionDeliver, next, err)
+	}
+
+	if _, err := orderStateMachine.Next(models.OrderCancelled); !errors.Is(err, common.ErrorOrderFinalStateReached) {
+		t.Errorf("expected the final state to be reached, got %v", err)
+	}
+}
+
+func TestStateMachineResolve(t *testing.T) {
+	name, err := orderStateMachine.Resolve(models.OrderShipped, models.OrderReturned)
+	if err != nil || name != TransitionReturn {
+		t.Errorf("expected %s, got %s (%v)", TransitionReturn, name, err)
+	}
+
+	if _, err := orderStateMachine.Resolve(models.OrderPlaced, models.OrderShipped); !errors.Is(err, common.ErrorTransitionNotAllowed) {
+		t.Errorf("expected the transition not to be allowed, got %v", err)
+	}
+}
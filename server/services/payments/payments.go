@@ -1,9 +1,15 @@
 package payments
 
 import (
+	"order-system/common"
 	"order-system/database"
 	"order-system/handlers/dto"
 	"order-system/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func FindAllPaymentMethod() ([]dto.PaymentMethodDto, error) {
@@ -25,3 +31,180 @@ func FindAllPaymentMethod() ([]dto.PaymentMethodDto, error) {
 
 	return result, nil
 }
+
+// Create the payment intent of a newly created order, the amount is
+// computed from the order items and adjustments in the order currency
+func CreateIntent(tx *gorm.DB, orderId uint, paymentMethodId string) error {
+	provider, err := GetProvider(paymentMethodId)
+	if err != nil {
+		return err
+	}
+
+	order := models.Order{}
+	if err := tx.First(&order, orderId).Error; err != nil {
+		return err
+	}
+
+	amount := decimal.Zero
+	if err := tx.Raw(`
+		select coalesce((select sum(round(pp.price * oi.exchange_rate, 2) * oi.quantity) from order_items oi
+			inner join product_prices pp on oi.product_price_id = pp.id
+			where oi.order_id = ?), 0) +
+			coalesce((select sum(oa.amount) from order_adjustments oa where oa.order_id = ?), 0)`,
+		orderId, orderId).Scan(&amount).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.PaymentIntent{
+		OrderID:         orderId,
+		PaymentMethodID: paymentMethodId,
+		Provider:        provider.Name(),
+		Amount:          amount,
+		Currency:        order.Currency,
+		Status:          models.PaymentIntentPending,
+	}).Error
+}
+
+func FindIntentOfOrder(orderId uint) (models.PaymentIntent, error) {
+	db := database.GetDBInstance()
+	intent := models.PaymentIntent{}
+	err := db.Where("order_id = ?", orderId).First(&intent).Error
+
+	return intent, err
+}
+
+// Find the orders whose payment is still waiting for
+// a confirmation from their provider
+func FindPendingIntentOrderIds() ([]uint, error) {
+	db := database.GetDBInstance()
+	orderIds := []uint{}
+	err := db.Model(&models.PaymentIntent{}).
+		Where("status = ? and attempts > 0 and payment_method_id != ?", models.PaymentIntentPending, models.PaymentMethodCOD).
+		Pluck("order_id", &orderIds).Error
+
+	return orderIds, err
+}
+
+func lockIntentOfOrder(tx *gorm.DB, orderId uint) (models.PaymentIntent, error) {
+	intent := models.PaymentIntent{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderId).First(&intent).Error
+
+	return intent, err
+}
+
+func applyChargeResult(tx *gorm.DB, intent *models.PaymentIntent, result ChargeResult) error {
+	intent.Status = result.Status
+	intent.FailureReason = result.FailureReason
+	if len(result.Reference) > 0 {
+		intent.ProviderReference = result.Reference
+	}
+	if result.Status == models.PaymentIntentSucceeded {
+		now := time.Now()
+		intent.ConfirmedAt = &now
+	}
+
+	return tx.Save(intent).Error
+}
+
+// Start a payment attempt of an order.
+// Only a fresh intent or a failed one can be charged,
+// which also makes retrying a declined payment possible
+func ChargeIntent(tx *gorm.DB, orderId uint) (models.PaymentIntent, error) {
+	intent, err := lockIntentOfOrder(tx, orderId)
+	if err != nil {
+		return intent, err
+	}
+
+	fresh := intent.Status == models.PaymentIntentPending && intent.Attempts == 0
+	if !fresh && intent.Status != models.PaymentIntentFailed {
+		return intent, common.ErrorPaymentNotRetryable
+	}
+
+	provider, err := GetProvider(intent.PaymentMethodID)
+	if err != nil {
+		return intent, err
+	}
+
+	now := time.Now()
+	intent.Attempts += 1
+	intent.LastAttemptAt = &now
+
+	result, err := provider.Charge(intent)
+	if err != nil {
+		return intent, err
+	}
+
+	return intent, applyChargeResult(tx, &intent, result)
+}
+
+// Ask the provider about a pending payment attempt
+func RefreshIntent(tx *gorm.DB, orderId uint) (models.PaymentIntent, error) {
+	intent, err := lockIntentOfOrder(tx, orderId)
+	if err != nil {
+		return intent, err
+	}
+
+	if intent.Status != models.PaymentIntentPending || intent.Attempts == 0 {
+		return intent, nil
+	}
+
+	provider, err := GetProvider(intent.PaymentMethodID)
+	if err != nil {
+		return intent, err
+	}
+
+	result, err := provider.Status(intent)
+	if err != nil {
+		return intent, err
+	}
+
+	return intent, applyChargeResult(tx, &intent, result)
+}
+
+// Mark a pending payment as collected, used by
+// cash on delivery orders once they are delivered
+func SettleIntent(tx *gorm.DB, orderId uint) error {
+	intent, err := lockIntentOfOrder(tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if intent.Status != models.PaymentIntentPending {
+		return nil
+	}
+
+	return applyChargeResult(tx, &intent, ChargeResult{Status: models.PaymentIntentSucceeded})
+}
+
+// Give the collected payment of an order back through its provider,
+// the intent is only marked as refunded once the provider has done it
+func RefundIntent(tx *gorm.DB, orderId uint) error {
+	intent, err := lockIntentOfOrder(tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if intent.Status != models.PaymentIntentSucceeded {
+		return nil
+	}
+
+	provider, err := GetProvider(intent.PaymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	if err := provider.Refund(intent); err != nil {
+		return err
+	}
+
+	intent.Status = models.PaymentIntentRefunded
+	return tx.Save(&intent).Error
+}
+
+// Void an unpaid intent of a cancelled order
+func CancelIntent(tx *gorm.DB, orderId uint) error {
+	return tx.Model(&models.PaymentIntent{}).
+		Where("order_id = ? and status in (?)", orderId,
+			[]models.PaymentIntentStatus{models.PaymentIntentPending, models.PaymentIntentFailed}).
+		Update("status", models.PaymentIntentCancelled).Error
+}
@@ -0,0 +1,70 @@
+package payments
+
+import (
+	"order-system/common"
+	"order-system/config"
+	"order-system/models"
+)
+
+// The outcome of a charge as reported by a payment provider
+type ChargeResult struct {
+	Status        models.PaymentIntentStatus
+	Reference     string
+	FailureReason string
+}
+
+type PaymentProvider interface {
+	Name() string
+	// Start a new payment attempt for the intent
+	Charge(intent models.PaymentIntent) (ChargeResult, error)
+	// Ask the provider about the outcome of a pending attempt
+	Status(intent models.PaymentIntent) (ChargeResult, error)
+	// Give a collected payment back to the buyer
+	Refund(intent models.PaymentIntent) error
+}
+
+// payment method id -> provider
+var providers = map[string]PaymentProvider{}
+
+func InitProviders(config *config.Config) {
+	RegisterProvider(models.PaymentMethodCOD, &CashOnDeliveryProvider{})
+	RegisterProvider(models.PaymentMethodCredit, NewSimulatedProvider(
+		config.PaymentSimulatorDeclineRate,
+		config.PaymentSimulatorDelay,
+	))
+}
+
+func RegisterProvider(paymentMethodId string, provider PaymentProvider) {
+	providers[paymentMethodId] = provider
+}
+
+func GetProvider(paymentMethodId string) (PaymentProvider, error) {
+	provider, ok := providers[paymentMethodId]
+	if !ok {
+		return nil, common.ErrorPaymentMethodInvalid
+	}
+
+	return provider, nil
+}
+
+// Cash on delivery payments are never confirmed by the provider,
+// they are settled when the order is delivered
+type CashOnDeliveryProvider struct {
+}
+
+func (p *CashOnDeliveryProvider) Name() string {
+	return "cash_on_delivery"
+}
+
+func (p *CashOnDeliveryProvider) Charge(intent models.PaymentIntent) (ChargeResult, error) {
+	return ChargeResult{Status: models.PaymentIntentPending}, nil
+}
+
+func (p *CashOnDeliveryProvider) Status(intent models.PaymentIntent) (ChargeResult, error) {
+	return ChargeResult{Status: models.PaymentIntentPending}, nil
+}
+
+// The cash is handed back by the vendor, there is nothing to call
+func (p *CashOnDeliveryProvider) Refund(intent models.PaymentIntent) error {
+	return nil
+}
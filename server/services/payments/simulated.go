@@ -0,0 +1,73 @@
+package payments
+
+import (
+	"fmt"
+	"math/rand"
+	"order-system/models"
+	"time"
+)
+
+// A local provider used to exercise the payment flow.
+// A charge is declined with the probability of DeclineRate,
+// otherwise it is confirmed once Delay has passed since the attempt
+type SimulatedProvider struct {
+	DeclineRate float64
+	Delay       time.Duration
+
+	random func() float64
+	now    func() time.Time
+}
+
+func NewSimulatedProvider(declineRate float64, delay time.Duration) *SimulatedProvider {
+	return &SimulatedProvider{
+		DeclineRate: declineRate,
+		Delay:       delay,
+		random:      rand.Float64,
+		now:         time.Now,
+	}
+}
+
+func (p *SimulatedProvider) Name() string {
+	return "simulator"
+}
+
+func (p *SimulatedProvider) Charge(intent models.PaymentIntent) (ChargeResult, error) {
+	result := ChargeResult{
+		Reference: fmt.Sprintf("sim_%d_%d", intent.OrderID, intent.Attempts),
+	}
+
+	if p.random() < p.DeclineRate {
+		result.Status = models.PaymentIntentFailed
+		result.FailureReason = "card_declined"
+		return result, nil
+	}
+
+	if p.Delay > 0 {
+		result.Status = models.PaymentIntentPending
+		return result, nil
+	}
+
+	result.Status = models.PaymentIntentSucceeded
+	return result, nil
+}
+
+func (p *SimulatedProvider) Status(intent models.PaymentIntent) (ChargeResult, error) {
+	result := ChargeResult{
+		Status:    models.PaymentIntentPending,
+		Reference: intent.ProviderReference,
+	}
+
+	if intent.LastAttemptAt != nil && p.now().Sub(*intent.LastAttemptAt) >= p.Delay {
+		result.Status = models.PaymentIntentSucceeded
+	}
+
+	return result, nil
+}
+
+func (p *SimulatedProvider) Refund(intent models.PaymentIntent) error {
+	if len(intent.ProviderReference) == 0 {
+		return fmt.Errorf("simulator: no charge to refund for order %d", intent.OrderID)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package payments
+
+import (
+	"order-system/models"
+	"testing"
+	"time"
+)
+
+func TestSimulatedProviderDecline(t *testing.T) {
+	provider := NewSimulatedProvider(0.5, 0)
+	provider.random = func() float64 { return 0.1 }
+
+	result, err := provider.Charge(models.PaymentIntent{OrderID: 1, Attempts: 1})
+	if err != nil {
+		t.Error("error while charging", err)
+	}
+
+	if result.Status != models.PaymentIntentFailed || result.FailureReason != "card_declined" {
+		t.Log("expected: v", []interface{}{models.PaymentIntentFailed, "card_declined"})
+		t.Error("actual: v", []interface{}{result.Status, result.FailureReason})
+	}
+}
+
+func TestSimulatedProviderImmediateSuccess(t *testing.T) {
+	provider := NewSimulatedProvider(0.5, 0)
+	provider.random = func() float64 { return 0.9 }
+
+	result, err := provider.Charge(models.PaymentIntent{OrderID: 1, Attempts: 2})
+	if err != nil {
+		t.Error("error while charging", err)
+	}
+
+	if result.Status != models.PaymentIntentSucceeded || result.Reference != "sim_1_2" {
+		t.Log("expected: v", []interface{}{models.PaymentIntentSucceeded, "sim_1_2"})
+		t.Error("actual: v", []interface{}{result.Status, result.Reference})
+	}
+}
+
+func TestSimulatedProviderDelay(t *testing.T) {
+	attemptedAt := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	provider := NewSimulatedProvider(0, time.Minute)
+	intent := models.PaymentIntent{OrderID: 1, Attempts: 1, LastAttemptAt: &attemptedAt}
+
+	result, err := provider.Charge(intent)
+	if err != nil || result.Status != models.PaymentIntentPending {
+		t.Errorf("expected a pending charge, got %v (%v)", result.Status, err)
+	}
+
+	provider.now = func() time.Time { return attemptedAt.Add(time.Second * 30) }
+	result, _ = provider.Status(intent)
+	if result.Status != models.PaymentIntentPending {
+		t.Errorf("expected pending before the delay, got %v", result.Status)
+	}
+
+	provider.now = func() time.Time { return attemptedAt.Add(time.Minute) }
+	result, _ = provider.Status(intent)
+	if result.Status != models.PaymentIntentSucceeded {
+		t.Errorf("expected succeeded after the delay, got %v", result.Status)
+	}
+}
+
+func TestSimulatedProviderRefund(t *testing.T) {
+	provider := NewSimulatedProvider(0, 0)
+
+	if err := provider.Refund(models.PaymentIntent{OrderID: 1, ProviderReference: "sim_1_1"}); err != nil {
+		t.Error("error while refunding", err)
+	}
+
+	// nothing was charged
+	if err := provider.Refund(models.PaymentIntent{OrderID: 1}); err == nil {
+		t.Error("expected the refund of an uncharged intent to fail")
+	}
+}
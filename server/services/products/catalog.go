@@ -0,0 +1,414 @@
+package products
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/services/currencies"
+	"order-system/utils"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	CatalogFormatCSV        = "csv"
+	CatalogFormatJSONLines  = "jsonl"
+	catalogActionCreated    = "created"
+	catalogActionUpdated    = "updated"
+	catalogInitialStockDesc = "initial stock (catalog import)"
+)
+
+var catalogColumns = []string{"sku", "name", "description", "unit", "barcode", "price", "currency", "stock"}
+
+// Parse a catalog in CSV, the first line is the header
+// and the columns are found by name
+func ParseCatalogCSV(r io.Reader) ([]dto.CatalogRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, common.ErrorInvalidCSV
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := index["sku"]; !ok {
+		return nil, common.ErrorInvalidCSV
+	}
+
+	rows := []dto.CatalogRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, common.ErrorInvalidCSV
+		}
+
+		values := make(map[string]string)
+		for _, column := range catalogColumns {
+			if i, ok := index[column]; ok && i < len(record) {
+				values[column] = strings.TrimSpace(record[i])
+			}
+		}
+
+		row := dto.CatalogRow{
+			Line:        line,
+			SKU:         values["sku"],
+			Name:        values["name"],
+			Description: values["description"],
+			Unit:        models.Unit(values["unit"]),
+			Barcode:     values["barcode"],
+			Currency:    values["currency"],
+		}
+
+		if len(values["price"]) > 0 {
+			price, err := decimal.NewFromString(values["price"])
+			if err != nil {
+				row.Error = "price_not_numeric"
+			}
+			row.Price = decimal.NewNullDecimal(price)
+		}
+
+		if len(values["stock"]) > 0 && len(row.Error) == 0 {
+			if row.Stock, err = decimal.NewFromString(values["stock"]); err != nil {
+				row.Error = "stock_not_numeric"
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Parse a catalog in JSON lines, one product per line
+func ParseCatalogJSONLines(r io.Reader) ([]dto.CatalogRow, error) {
+	scanner := bufio.NewScanner(r)
+	rows := []dto.CatalogRow{}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := dto.CatalogRow{}
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			row = dto.CatalogRow{Error: "invalid_json"}
+		}
+
+		// the line, the product and the action are set by the import
+		row.Line = line
+		row.ProductID = 0
+		row.Action = ""
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func WriteCatalogCSV(w io.Writer, rows []dto.CatalogRow) error {
+	writer := csv.NewWriter(w)
+	writer.Write(catalogColumns)
+
+	for _, row := range rows {
+		writer.Write([]string{
+			row.SKU,
+			row.Name,
+			row.Description,
+			string(row.Unit),
+			row.Barcode,
+			catalogPrice(row),
+			row.Currency,
+			row.Stock.String(),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func catalogPrice(row dto.CatalogRow) string {
+	if !row.Price.Valid {
+		return ""
+	}
+	return row.Price.Decimal.String()
+}
+
+func WriteCatalogJSONLines(w io.Writer, rows []dto.CatalogRow) error {
+	encoder := json.NewEncoder(w)
+
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Find the catalog of a vendor with the current price
+// and the stock of each product
+func FindCatalogOfVendor(vendorId uint) ([]dto.CatalogRow, error) {
+	db := database.GetDBInstance()
+	rows := []dto.CatalogRow{}
+
+	err := db.Raw(`select coalesce(p.sku, '') as sku, p.name, p.description, p.unit, coalesce(p.barcode, '') as barcode,
+		coalesce(pp.price, 0) as price, coalesce(pp.currency, '') as currency, coalesce(ps.quantity, 0) as stock
+		from products p
+		left join product_stocks ps on p.id = ps.product_id
+		left join (`+activePricesQuery("now()")+`) pp on p.id = pp.product_id
+		where p.vendor_id = ? and p.deleted_at is null and not p.has_variants
+		order by p.sku, p.id`, vendorId).Scan(&rows).Error
+
+	return rows, err
+}
+
+// Create or update the products of a vendor by their SKU. The initial stock
+// of a row is only used when its product is created, the stock of existing
+// products is changed through product transactions. A new price is recorded
+// when it (or its currency) differs from the current one, a blank price keeps
+// the current one and is refused for a new product. The valid rows are applied
+// and the invalid ones are reported
+func ImportCatalog(vendorId uint, defaultWarehouseId *uint, rows []dto.CatalogRow) (*dto.CatalogImportReport, error) {
+	db := database.GetDBInstance()
+	report := &dto.CatalogImportReport{Rows: rows}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		existing, err := findCatalogProducts(tx, vendorId, rows)
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool)
+		for i := range report.Rows {
+			row := &report.Rows[i]
+			if len(row.Error) == 0 {
+				row.Error = validateCatalogRow(row, seen)
+			}
+
+			if len(row.Error) > 0 {
+				report.Errors++
+				continue
+			}
+
+			if product, ok := existing[row.SKU]; ok {
+				if product.HasVariants {
+					row.Error = common.ErrorProductHasVariants.Error()
+					report.Errors++
+					continue
+				}
+
+				if err := updateCatalogProduct(tx, product, row); err != nil {
+					return err
+				}
+				report.Updated++
+				continue
+			}
+
+			if !row.Price.Valid {
+				row.Error = "price_required"
+				report.Errors++
+				continue
+			}
+
+			if err := createCatalogProduct(tx, vendorId, defaultWarehouseId, row); err != nil {
+				return err
+			}
+			report.Created++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func validateCatalogRow(row *dto.CatalogRow, seen map[string]bool) string {
+	if len(row.SKU) == 0 {
+		return "sku_required"
+	}
+
+	if seen[row.SKU] {
+		return "sku_duplicated"
+	}
+	seen[row.SKU] = true
+
+	if len(strings.TrimSpace(row.Name)) == 0 {
+		return "name_required"
+	}
+
+	if row.Price.Valid && row.Price.Decimal.IsNegative() {
+		return "price_negative"
+	}
+
+	currency, err := currencies.NormalizeCurrency(row.Currency)
+	if err != nil {
+		return err.Error()
+	}
+	row.Currency = currency
+
+	if len(row.Unit) == 0 {
+		row.Unit = models.UnitPiece
+	}
+
+	if !row.Unit.IsValid() {
+		return common.ErrorInvalidUnit.Error()
+	}
+
+	if len(row.Barcode) > 0 && !utils.IsValidGTIN(row.Barcode) {
+		return common.ErrorInvalidBarcode.Error()
+	}
+
+	if row.Stock.IsNegative() {
+		return "stock_negative"
+	}
+
+	if !row.Unit.IsValidQuantity(row.Stock) {
+		return common.ErrorInvalidQuantity.Error()
+	}
+
+	return ""
+}
+
+type catalogProduct struct {
+	ID          uint            `gorm:"column:id"`
+	SKU         string          `gorm:"column:sku"`
+	Price       decimal.Decimal `gorm:"column:price"`
+	Currency    string          `gorm:"column:currency"`
+	HasVariants bool            `gorm:"column:has_variants"`
+	ParentID    *uint           `gorm:"column:parent_id"`
+}
+
+// Find the products of the vendor with the SKUs of the rows
+// along with their current price
+func findCatalogProducts(tx *gorm.DB, vendorId uint, rows []dto.CatalogRow) (map[string]catalogProduct, error) {
+	skus := []string{}
+	for _, row := range rows {
+		if len(row.SKU) > 0 {
+			skus = append(skus, row.SKU)
+		}
+	}
+
+	existing := make(map[string]catalogProduct)
+	if len(skus) == 0 {
+		return existing, nil
+	}
+
+	found := []catalogProduct{}
+	err := tx.Raw(`select p.id, p.sku, coalesce(pp.price, 0) as price, coalesce(pp.currency, '') as currency, p.has_variants, p.parent_id
+		from products p
+		left join (`+activePricesQuery("now()")+`) pp on p.id = pp.product_id
+		where p.vendor_id = ? and p.sku in (?) and p.deleted_at is null
+		for update of p`, vendorId, skus).Scan(&found).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range found {
+		existing[product.SKU] = product
+	}
+
+	return existing, nil
+}
+
+func updateCatalogProduct(tx *gorm.DB, product catalogProduct, row *dto.CatalogRow) error {
+	row.ProductID = product.ID
+	row.Action = catalogActionUpdated
+
+	changes := map[string]interface{}{
+		"name":        row.Name,
+		"description": row.Description,
+		"unit":        row.Unit,
+		"barcode":     utils.NilIfEmpty(row.Barcode),
+	}
+
+	// the name and the unit of a variant are the ones of its parent
+	if product.ParentID != nil {
+		delete(changes, "name")
+		delete(changes, "unit")
+	}
+
+	err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(changes).Error
+
+	if err != nil || !catalogPriceChanged(product, row) {
+		return err
+	}
+
+	return tx.Create(&models.ProductPrice{
+		ProductID: product.ID,
+		Price:     row.Price.Decimal,
+		Currency:  row.Currency,
+	}).Error
+}
+
+// Whether the row changes the price of an existing product,
+// a blank price keeps the current one
+func catalogPriceChanged(product catalogProduct, row *dto.CatalogRow) bool {
+	if !row.Price.Valid {
+		return false
+	}
+	return !product.Price.Equal(row.Price.Decimal) || product.Currency != row.Currency
+}
+
+func createCatalogProduct(tx *gorm.DB, vendorId uint, defaultWarehouseId *uint, row *dto.CatalogRow) error {
+	sku := row.SKU
+	product := models.Product{
+		Name:        row.Name,
+		Description: row.Description,
+		Unit:        row.Unit,
+		VendorID:    vendorId,
+		SKU:         &sku,
+		Barcode:     utils.NilIfEmpty(row.Barcode),
+	}
+
+	if err := tx.Create(&product).Error; err != nil {
+		return err
+	}
+
+	row.ProductID = product.ID
+	row.Action = catalogActionCreated
+
+	if err := tx.Create(&models.ProductPrice{
+		ProductID: product.ID,
+		Price:     row.Price.Decimal,
+		Currency:  row.Currency,
+	}).Error; err != nil {
+		return err
+	}
+
+	if row.Stock.IsZero() {
+		return nil
+	}
+
+	return tx.Create(&models.ProductTransaction{
+		ProductID:   product.ID,
+		Quantity:    row.Stock,
+		Type:        models.TransactionTypeIn,
+		Description: catalogInitialStockDesc,
+		WarehouseID: defaultWarehouseId,
+	}).Error
+}
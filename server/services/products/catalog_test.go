@@ -0,0 +1,121 @@
+package products
+
+import (
+	"bytes"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+var catalog = []dto.CatalogRow{
+	{SKU: "TEA-01", Name: "Green tea", Description: "Loose leaf, 100g", Unit: "box", Barcode: "4006381333931", Price: decimal.NewNullDecimal(decimal.NewFromFloat(4.5)), Stock: decimal.NewFromInt(20)},
+	{SKU: "TEA-02", Name: "Black tea, \"strong\"", Unit: "box", Price: decimal.NewNullDecimal(decimal.NewFromInt(5))},
+}
+
+func TestCatalogCSVRoundTrip(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := WriteCatalogCSV(b, catalog); err != nil {
+		t.Fatal("error while writing catalog", err)
+	}
+
+	rows, err := ParseCatalogCSV(b)
+	if err != nil {
+		t.Fatal("error while parsing catalog", err)
+	}
+
+	assertSameCatalog(t, rows)
+}
+
+func TestCatalogJSONLinesRoundTrip(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := WriteCatalogJSONLines(b, catalog); err != nil {
+		t.Fatal("error while writing catalog", err)
+	}
+	b.WriteString("{not json\n")
+
+	rows, err := ParseCatalogJSONLines(b)
+	if err != nil {
+		t.Fatal("error while parsing catalog", err)
+	}
+
+	if len(rows) != 3 || rows[2].Error != "invalid_json" || rows[2].Line != 3 {
+		t.Error("actual: v", rows)
+	}
+
+	assertSameCatalog(t, rows[:2])
+}
+
+func TestCatalogCSVBlankPrice(t *testing.T) {
+	rows, err := ParseCatalogCSV(strings.NewReader("sku,name,price\nTEA-01,Green tea,\nTEA-02,Black tea,5\n"))
+	if err != nil || len(rows) != 2 {
+		t.Fatal("actual: v", rows, err)
+	}
+
+	if rows[0].Price.Valid || rows[0].Error != "" {
+		t.Error("actual: v", rows[0])
+	}
+
+	// the current price of an existing product is kept
+	current := catalogProduct{Price: decimal.NewFromFloat(4.5), Currency: "USD"}
+	for i, expected := range []bool{false, true} {
+		if validateCatalogRow(&rows[i], map[string]bool{}) != "" {
+			t.Error("actual: v", rows[i])
+		}
+
+		if changed := catalogPriceChanged(current, &rows[i]); changed != expected {
+			t.Log("expected: v", expected)
+			t.Error("actual: v", changed)
+		}
+	}
+
+	// and written back blank
+	b := &bytes.Buffer{}
+	if err := WriteCatalogCSV(b, rows[:1]); err != nil || !strings.Contains(b.String(), "TEA-01,Green tea,,piece,,,") {
+		t.Error("actual: v", b.String(), err)
+	}
+}
+
+func TestValidateCatalogRow(t *testing.T) {
+	seen := make(map[string]bool)
+	rows := []dto.CatalogRow{
+		{SKU: "A", Name: "a"},
+		{SKU: "A", Name: "a again"},
+		{SKU: "", Name: "no sku"},
+		{SKU: "B", Name: "b", Unit: "kg", Stock: decimal.NewFromInt(-1)},
+		{SKU: "C", Name: "c", Unit: "litre"},
+		{SKU: "F", Name: "f", Barcode: "4006381333932"},
+		{SKU: "D", Name: "d", Unit: "box", Stock: decimal.NewFromFloat(1.5)},
+		{SKU: "E", Name: "e", Unit: "kg", Stock: decimal.NewFromFloat(1.5)},
+	}
+	expected := []string{"", "sku_duplicated", "sku_required", "stock_negative", "invalid_unit", "invalid_barcode", "invalid_quantity", ""}
+
+	for i := range rows {
+		if e := validateCatalogRow(&rows[i], seen); e != expected[i] {
+			t.Log("expected: v", expected[i])
+			t.Error("actual: v", e)
+		}
+	}
+
+	if rows[0].Unit != models.UnitPiece {
+		t.Log("expected: v", models.UnitPiece)
+		t.Error("actual: v", rows[0].Unit)
+	}
+}
+
+func assertSameCatalog(t *testing.T, rows []dto.CatalogRow) {
+	if len(rows) != len(catalog) {
+		t.Fatalf("expected %d rows, got %d", len(catalog), len(rows))
+	}
+
+	for i, row := range rows {
+		expected := catalog[i]
+		if row.SKU != expected.SKU || row.Name != expected.Name || row.Description != expected.Description ||
+			row.Unit != expected.Unit || row.Barcode != expected.Barcode || row.Price.Valid != expected.Price.Valid || !row.Price.Decimal.Equal(expected.Price.Decimal) || !row.Stock.Equal(expected.Stock) || row.Error != "" {
+			t.Log("expected: v", expected)
+			t.Error("actual: v", row)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package products_test
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/database"
+	"order-system/models"
+	"order-system/services/carts"
+	"order-system/services/products"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const parallelWorkers = 20
+
+var one = decimal.NewFromInt(1)
+
+func TestConcurrentStockExports(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	initialQuantity, err := products.FindProductStockQuantity(productId)
+	if err != nil {
+		t.Fatal("error while getting stock quantity", err)
+	}
+
+	// twice as many exports of one unit as the stock can afford
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	succeeded, rejected := 0, 0
+	for i := int64(0); i < initialQuantity.IntPart()*2; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := products.ExportProductStock(productId, one, "concurrent export")
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err == nil {
+				succeeded += 1
+			} else if errors.Is(err, common.ErrorInsufficientQuantity) {
+				rejected += 1
+			} else {
+				t.Error("unexpected error while exporting", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	quantity, err := products.FindProductStockQuantity(productId)
+	if err != nil {
+		t.Fatal("error while getting stock quantity", err)
+	}
+
+	if !quantity.IsZero() || int64(succeeded) != initialQuantity.IntPart() || int64(rejected) != initialQuantity.IntPart() {
+		t.Log("expected: v", []interface{}{0, initialQuantity, initialQuantity})
+		t.Error("actual: v", []interface{}{quantity, succeeded, rejected})
+	}
+}
+
+func TestConcurrentStockImportsAndExports(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	if err := products.ImportProductStock(productId, decimal.NewFromInt(parallelWorkers), "initial import"); err != nil {
+		t.Fatal("error while importing", err)
+	}
+
+	initialQuantity, err := products.FindProductStockQuantity(productId)
+	if err != nil {
+		t.Fatal("error while getting stock quantity", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelWorkers; i += 1 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := products.ImportProductStock(productId, one, "concurrent import"); err != nil {
+				t.Error("error while importing", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := products.ExportProductStock(productId, one, "concurrent export"); err != nil {
+				t.Error("error while exporting", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	quantity, err := products.FindProductStockQuantity(productId)
+	if err != nil {
+		t.Fatal("error while getting stock quantity", err)
+	}
+
+	if !quantity.Equal(initialQuantity) {
+		t.Logf("expected: %s", initialQuantity)
+		t.Errorf("actual: %s", quantity)
+	}
+}
+
+func TestConcurrentAddItemToCart(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	cartId := uint(1)
+	price, err := products.FindProductPrice(productId, time.Now())
+	if err != nil {
+		t.Fatal("error while getting product price", err)
+	}
+
+	stockQuantity, err := products.FindProductStockQuantity(productId)
+	if err != nil {
+		t.Fatal("error while getting stock quantity", err)
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	succeeded := 0
+	for i := 0; i < parallelWorkers; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := carts.AddItemToCart(cartId, productId, one, price.ID)
+
+			if err != nil && !errors.Is(err, common.ErrorInsufficientQuantity) {
+				t.Error("unexpected error while adding to cart", err)
+			}
+
+			if err == nil {
+				lock.Lock()
+				succeeded += 1
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	cartItems := []models.CartItem{}
+	if err := database.GetDBInstance().Where("cart_id = ? and product_id = ?", cartId, productId).
+		Find(&cartItems).Error; err != nil {
+		t.Fatal("error while getting cart items", err)
+	}
+
+	// a single cart item holds every successful addition,
+	// and never more than the stock quantity
+	if len(cartItems) != 1 ||
+		!cartItems[0].Quantity.Equal(decimal.NewFromInt(int64(succeeded))) ||
+		cartItems[0].Quantity.GreaterThan(stockQuantity) {
+		t.Log("expected: v", []interface{}{1, succeeded, stockQuantity})
+		t.Error("actual: v", cartItems)
+	}
+}
@@ -0,0 +1,306 @@
+package products
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/services/media"
+
+	"gorm.io/gorm"
+)
+
+// Store the images of a product with their thumbnails, after its
+// current images and in the given order. The first image of a
+// product becomes its primary image
+func AddProductImages(productId uint, images []*media.Image) ([]dto.ProductImageDto, error) {
+	store := media.GetStore()
+	created := []models.ProductImage{}
+
+	for _, image := range images {
+		name, err := randomName()
+		if err != nil {
+			return nil, err
+		}
+
+		productImage := models.ProductImage{
+			ProductID:    productId,
+			Key:          fmt.Sprintf("products/%d/%s.%s", productId, name, image.Extension),
+			ThumbnailKey: fmt.Sprintf("products/%d/%s_thumb.%s", productId, name, image.ThumbnailExtension),
+			ContentType:  image.ContentType,
+			Size:         int64(len(image.Content)),
+			Width:        image.Width,
+			Height:       image.Height,
+		}
+		created = append(created, productImage)
+
+		err = store.Put(productImage.Key, bytes.NewReader(image.Content))
+		if err == nil {
+			err = store.Put(productImage.ThumbnailKey, bytes.NewReader(image.Thumbnail))
+		}
+
+		if err != nil {
+			deleteImageBlobs(created)
+			return nil, err
+		}
+	}
+
+	db := database.GetDBInstance()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// the positions and the primary image are read then written
+		if err := LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		current := []models.ProductImage{}
+		if err := tx.Where("product_id = ?", productId).Order("position").Find(&current).Error; err != nil {
+			return err
+		}
+
+		position, hasPrimary := 0, false
+		for _, image := range current {
+			position = image.Position + 1
+			hasPrimary = hasPrimary || image.IsPrimary
+		}
+
+		for i := range created {
+			created[i].Position = position + i
+			created[i].IsPrimary = !hasPrimary && i == 0
+		}
+
+		return tx.Create(&created).Error
+	})
+
+	if err != nil {
+		deleteImageBlobs(created)
+		return nil, err
+	}
+
+	result := []dto.ProductImageDto{}
+	for _, image := range created {
+		result = append(result, productImageDto(image))
+	}
+
+	return result, nil
+}
+
+func FindProductImages(productId uint) ([]dto.ProductImageDto, error) {
+	images, err := findProductImages([]uint{productId})
+	if err != nil {
+		return nil, err
+	}
+
+	if images[productId] == nil {
+		return []dto.ProductImageDto{}, nil
+	}
+
+	return images[productId], nil
+}
+
+// Set the order of the images of a product, imageIds
+// has to list all of them and only them
+func ReorderProductImages(productId uint, imageIds []uint) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		current := []uint{}
+		if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", productId).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+
+		if !isPermutation(current, imageIds) {
+			return common.ErrorInvalidImageOrder
+		}
+
+		for position, id := range imageIds {
+			err := tx.Model(&models.ProductImage{}).Where("id = ?", id).Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func SetPrimaryProductImage(productId uint, imageId uint) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		if _, err := findProductImage(tx, productId, imageId); err != nil {
+			return err
+		}
+
+		// in two steps, the unique index of the primary image is checked on each row
+		err := tx.Model(&models.ProductImage{}).Where("product_id = ? and is_primary", productId).
+			Update("is_primary", false).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ProductImage{}).Where("id = ?", imageId).Update("is_primary", true).Error
+	})
+}
+
+// Delete an image of a product, the next image
+// becomes the primary one if needed
+func DeleteProductImage(productId uint, imageId uint) error {
+	db := database.GetDBInstance()
+	deleted := models.ProductImage{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		image, err := findProductImage(tx, productId, imageId)
+		if err != nil {
+			return err
+		}
+		deleted = *image
+
+		if err := tx.Delete(image).Error; err != nil {
+			return err
+		}
+
+		if !image.IsPrimary {
+			return nil
+		}
+
+		next := models.ProductImage{}
+		err = tx.Where("product_id = ?", productId).Order("position, id").Limit(1).Find(&next).Error
+		if err != nil || next.ID == 0 {
+			return err
+		}
+
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// the image is already gone, a file left behind is only wasted space
+	deleteImageBlobs([]models.ProductImage{deleted})
+	return nil
+}
+
+func findProductImage(db *gorm.DB, productId uint, imageId uint) (*models.ProductImage, error) {
+	image := models.ProductImage{}
+	if err := db.Where("product_id = ?", productId).First(&image, imageId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrorResourceNotFound
+		}
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+// Find the images of the given products, ordered by position
+func findProductImages(productIds []uint) (map[uint][]dto.ProductImageDto, error) {
+	result := make(map[uint][]dto.ProductImageDto)
+	if len(productIds) == 0 {
+		return result, nil
+	}
+
+	images := []models.ProductImage{}
+	err := database.GetDBInstance().Where("product_id in (?)", productIds).
+		Order("product_id, position, id").Find(&images).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		result[image.ProductID] = append(result[image.ProductID], productImageDto(image))
+	}
+
+	return result, nil
+}
+
+func fillImages(items []dto.Product) error {
+	productIds := []uint{}
+	for _, item := range items {
+		productIds = append(productIds, item.ID)
+	}
+
+	images, err := findProductImages(productIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].Images = images[items[i].ID]
+		if items[i].Images == nil {
+			items[i].Images = []dto.ProductImageDto{}
+		}
+	}
+
+	return nil
+}
+
+func productImageDto(image models.ProductImage) dto.ProductImageDto {
+	store := media.GetStore()
+
+	return dto.ProductImageDto{
+		ID:           image.ID,
+		URL:          store.URL(image.Key),
+		ThumbnailURL: store.URL(image.ThumbnailKey),
+		Width:        image.Width,
+		Height:       image.Height,
+		Position:     image.Position,
+		Primary:      image.IsPrimary,
+	}
+}
+
+func deleteImageBlobs(images []models.ProductImage) {
+	store := media.GetStore()
+	for _, image := range images {
+		store.Delete(image.Key)
+		store.Delete(image.ThumbnailKey)
+	}
+}
+
+func isPermutation(current []uint, ids []uint) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+
+	seen := make(map[uint]bool)
+	for _, id := range current {
+		seen[id] = true
+	}
+
+	for _, id := range ids {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+
+	return true
+}
+
+// a random file name, the names of the uploads are not trusted
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
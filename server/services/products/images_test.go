@@ -0,0 +1,21 @@
+package products
+
+import "testing"
+
+func TestIsPermutation(t *testing.T) {
+	current := []uint{3, 1, 2}
+
+	valid := [][]uint{{1, 2, 3}, {3, 1, 2}}
+	for _, ids := range valid {
+		if !isPermutation(current, ids) {
+			t.Error("actual: v", ids)
+		}
+	}
+
+	invalid := [][]uint{{1, 2}, {1, 2, 4}, {1, 1, 2}, {1, 2, 3, 3}, {}}
+	for _, ids := range invalid {
+		if isPermutation(current, ids) {
+			t.Error("actual: v", ids)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package products
+
+import (
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"strings"
+	"time"
+)
+
+const filterDateLayout = "2006-01-02"
+
+// The ledger of a product with the running balance computed
+// over every transaction, so the filters do not change the balances
+const stockMovementsQuery = `
+	select pt.id, pt.created_at, pt.type, pt.description, pt.quantity, pt.warehouse_id, coalesce(w.name, '') as warehouse_name,
+		sum(pt.quantity) over (order by pt.created_at, pt.id) as balance
+		from product_transactions pt
+		left join warehouses w on w.id = pt.warehouse_id
+	where pt.product_id = ?`
+
+// Find the product transactions of a product, newest first.
+// Supported filters: `type`, `from` and `to` (dates, `to` is inclusive)
+// and `description` (case insensitive text search)
+func FindStockMovements(productId uint, paginationQuery dto.PaginationQuery) (*dto.PaginationResponse[dto.StockMovementDto], error) {
+	db := database.GetDBInstance()
+	movements := []dto.StockMovementDto{}
+	pageIndex := paginationQuery.PageIndex
+	itemsPerPage := paginationQuery.ItemsPerPage
+
+	whereQuery, filterParams, err := stockMovementFilters(paginationQuery.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	params := append([]interface{}{productId}, filterParams...)
+	countParams := append([]interface{}{productId}, filterParams...)
+	limitQuery := ""
+
+	if itemsPerPage > 0 {
+		params = append(params, pageIndex*itemsPerPage, itemsPerPage)
+		limitQuery = " offset ? limit ?"
+	}
+
+	err = db.Raw(`select m.* from (`+stockMovementsQuery+`) m `+whereQuery+
+		` order by m.created_at desc, m.id desc`+limitQuery, params...).Scan(&movements).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	err = db.Raw(`select count(m.id) from (`+stockMovementsQuery+`) m `+whereQuery, countParams...).Scan(&total).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginationResponse[dto.StockMovementDto]{
+		Items:        movements,
+		Total:        &total,
+		PageIndex:    pageIndex,
+		ItemsPerPage: itemsPerPage,
+	}, nil
+}
+
+func stockMovementFilters(filters map[string]string) (string, []interface{}, error) {
+	conditions := []string{}
+	params := []interface{}{}
+
+	if transactionType, ok := filters["type"]; ok && len(transactionType) > 0 {
+		conditions = append(conditions, "m.type = ?")
+		params = append(params, transactionType)
+	}
+
+	if from, ok := filters["from"]; ok && len(from) > 0 {
+		fromDate, err := parseFilterDate(from)
+		if err != nil {
+			return "", nil, err
+		}
+
+		conditions = append(conditions, "m.created_at >= ?")
+		params = append(params, fromDate)
+	}
+
+	if to, ok := filters["to"]; ok && len(to) > 0 {
+		toDate, err := parseFilterDate(to)
+		if err != nil {
+			return "", nil, err
+		}
+
+		// the whole day is included
+		if len(to) == len(filterDateLayout) {
+			conditions = append(conditions, "m.created_at < ?")
+			params = append(params, toDate.AddDate(0, 0, 1))
+		} else {
+			conditions = append(conditions, "m.created_at <= ?")
+			params = append(params, toDate)
+		}
+	}
+
+	if description, ok := filters["description"]; ok && len(description) > 0 {
+		conditions = append(conditions, "m.description ilike ?")
+		params = append(params, "%"+description+"%")
+	}
+
+	if len(conditions) == 0 {
+		return "", params, nil
+	}
+
+	return "where " + strings.Join(conditions, " and "), params, nil
+}
+
+// Dates are given as "2006-01-02" or RFC 3339
+func parseFilterDate(value string) (time.Time, error) {
+	if date, err := time.Parse(filterDateLayout, value); err == nil {
+		return date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return date, common.ErrorInvalidFilter
+	}
+
+	return date, nil
+}
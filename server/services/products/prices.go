@@ -0,0 +1,177 @@
+package products
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/database"
+	"order-system/models"
+	"order-system/services/currencies"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// how far in the past a new price may start, the clock of a client may be late
+const priceClockSkew = time.Minute
+
+// The price of each product in effect at an instant, an SQL expression
+// such as now() or a `?` (given twice). Among the prices in effect, the
+// one which took effect last wins: a sale overrides the regular price
+// during its window, and the regular price is back when the sale ends
+func activePricesQuery(instant string) string {
+	return `select distinct on (product_id) * from product_prices
+		where deleted_at is null and effective_from <= ` + instant + `
+			and (effective_until is null or effective_until > ` + instant + `)
+		order by product_id, effective_from desc, id desc`
+}
+
+// Record a new price of a product in the base currency taking effect
+// right away, a product with variants has no price of its own
+func SetProductPrice(productId uint, price decimal.Decimal) error {
+	_, err := ScheduleProductPrice(productId, price, "", nil, nil)
+	return err
+}
+
+// Record a price of a product in a currency (the base currency when empty)
+// taking effect at from (right away when empty) until until (for good when
+// empty). A price cannot start in the past, the prices already in effect
+// are history
+func ScheduleProductPrice(productId uint, price decimal.Decimal, currency string, from *time.Time, until *time.Time) (*models.ProductPrice, error) {
+	db := database.GetDBInstance()
+
+	if err := checkSellable(db, productId); err != nil {
+		return nil, err
+	}
+
+	currency, err := currencies.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	productPrice := &models.ProductPrice{
+		ProductID:      productId,
+		Price:          price,
+		Currency:       currency,
+		EffectiveFrom:  now,
+		EffectiveUntil: until,
+	}
+
+	if from != nil {
+		productPrice.EffectiveFrom = *from
+	}
+
+	if err := validatePriceWindow(productPrice, now); err != nil {
+		return nil, err
+	}
+
+	if err := db.Create(productPrice).Error; err != nil {
+		return nil, err
+	}
+
+	return productPrice, nil
+}
+
+func validatePriceWindow(price *models.ProductPrice, now time.Time) error {
+	if price.EffectiveFrom.Before(now.Add(-priceClockSkew)) {
+		return common.ErrorInvalidPriceWindow
+	}
+
+	if price.EffectiveUntil != nil && !price.EffectiveUntil.After(price.EffectiveFrom) {
+		return common.ErrorInvalidPriceWindow
+	}
+
+	return nil
+}
+
+// Find the prices of a product by their start, the latest first.
+// Only the prices which have not started yet when upcoming is set
+func GetProductPrices(productId uint, upcoming bool) ([]models.ProductPrice, error) {
+	db := database.GetDBInstance()
+	prices := []models.ProductPrice{}
+
+	query := db.Where("product_id = ?", productId)
+	if upcoming {
+		query = query.Where("effective_from > ?", time.Now())
+	}
+
+	err := query.Order("effective_from desc, id desc").Find(&prices).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// Cancel a price which has not started yet, the
+// prices which have been in effect are kept
+func CancelProductPrice(productId uint, priceId uint) error {
+	db := database.GetDBInstance()
+	price := models.ProductPrice{}
+
+	if err := db.Where("product_id = ?", productId).First(&price, priceId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrorResourceNotFound
+		}
+		return err
+	}
+
+	// the start is checked again by the delete, the price may start meanwhile
+	res := db.Where("effective_from > ?", time.Now()).Delete(&price)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return common.ErrorPriceNotCancellable
+	}
+
+	return nil
+}
+
+// Find the price of a product in effect at an instant
+func FindProductPrice(productId uint, at time.Time) (models.ProductPrice, error) {
+	db := database.GetDBInstance()
+	price := models.ProductPrice{}
+
+	if err := checkSellable(db, productId); err != nil {
+		return price, err
+	}
+
+	prices, err := FindActivePrices(db, []uint{productId}, at)
+	if err != nil {
+		return price, err
+	}
+
+	price, ok := prices[productId]
+	if !ok {
+		return price, gorm.ErrRecordNotFound
+	}
+
+	return price, nil
+}
+
+// Find the prices of the given products in effect at an instant,
+// the products without a price at this instant are left out
+func FindActivePrices(db *gorm.DB, productIds []uint, at time.Time) (map[uint]models.ProductPrice, error) {
+	result := make(map[uint]models.ProductPrice)
+	if len(productIds) == 0 {
+		return result, nil
+	}
+
+	prices := []models.ProductPrice{}
+	err := db.Raw(`select pp.* from (`+activePricesQuery("?")+`) pp where pp.product_id in (?)`, at, at, productIds).
+		Scan(&prices).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, price := range prices {
+		result[price.ProductID] = price
+	}
+
+	return result, nil
+}
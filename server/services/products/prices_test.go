@@ -0,0 +1,41 @@
+package products
+
+import (
+	"order-system/common"
+	"order-system/models"
+	"testing"
+	"time"
+)
+
+func TestValidatePriceWindow(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		instant := now.Add(time.Duration(hours) * time.Hour)
+		return &instant
+	}
+
+	valid := []models.ProductPrice{
+		{EffectiveFrom: now},
+		{EffectiveFrom: now.Add(-30 * time.Second)},
+		{EffectiveFrom: *at(12), EffectiveUntil: at(60)},
+	}
+
+	for _, price := range valid {
+		if err := validatePriceWindow(&price, now); err != nil {
+			t.Error("actual: v", price, err)
+		}
+	}
+
+	invalid := []models.ProductPrice{
+		{EffectiveFrom: *at(-1)},
+		{EffectiveFrom: *at(12), EffectiveUntil: at(12)},
+		{EffectiveFrom: *at(12), EffectiveUntil: at(2)},
+	}
+
+	for _, price := range invalid {
+		if err := validatePriceWindow(&price, now); err != common.ErrorInvalidPriceWindow {
+			t.Log("expected: v", common.ErrorInvalidPriceWindow)
+			t.Error("actual: v", price, err)
+		}
+	}
+}
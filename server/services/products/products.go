@@ -1,34 +1,211 @@
 package products
 
 import (
-	"fmt"
+	"order-system/common"
 	"order-system/database"
 	"order-system/handlers/dto"
 	"order-system/models"
+	"order-system/services/categories"
+	"order-system/services/currencies"
+	"order-system/services/listing"
+	"order-system/services/reservations"
+	"order-system/services/taxes"
 	"order-system/utils"
+	"strconv"
 
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func CreateProduct(product models.Product) error {
+// The products with their current price and the stock which can be sold,
+// the stock held by unpaid orders is not available. The prices are compared
+// by their value in the base currency (base_price), a currency without a
+// rate counts as the base currency
+var availableProductsQuery = `
+	select p.*, coalesce(ps.quantity, 0) - coalesce(sr.quantity, 0) as stock_quantity, pp.id as product_price_id, pp.price as product_price,
+			pp.currency as product_currency, pp.price * coalesce(er.rate, 1) as base_price
+		from products p
+		left join product_stocks ps on p.id = ps.product_id
+		left join (` + reservations.ActiveHoldsQuery + `) sr on p.id = sr.product_id
+		join (` + activePricesQuery("now()") + `) pp on p.id = pp.product_id
+		left join (` + currencies.CurrentRatesQuery + `) er on pp.currency = er.currency
+		where p.deleted_at is null`
+
+// The products of the vendors with their current price,
+// their stock and the part of it held by unpaid orders
+var vendorProductsQuery = `
+	select p.*, coalesce(ps.quantity, 0) as stock_quantity, coalesce(sr.quantity, 0) as reserved_quantity, pp.id as product_price_id, pp.price as product_price,
+			pp.currency as product_currency, pp.price * coalesce(er.rate, 1) as base_price
+		from products p
+		left join product_stocks ps on p.id = ps.product_id
+		left join (` + reservations.ActiveHoldsQuery + `) sr on p.id = sr.product_id
+		left join (` + activePricesQuery("now()") + `) pp on p.id = pp.product_id
+		left join (` + currencies.CurrentRatesQuery + `) er on pp.currency = er.currency
+		where p.deleted_at is null`
+
+// The listings show the products without a parent, the variants of a
+// product are grouped under it: the stock of the product is the stock of
+// all its variants and its price is the lowest of their prices
+var availableListingQuery = `
+	select p.*, g.stock_quantity, g.product_price_id, g.product_price, g.product_currency, g.base_price
+		from products p
+		join (select coalesce(a.parent_id, a.id) as listing_id, sum(a.stock_quantity) as stock_quantity,
+				case when count(a.parent_id) = 0 then max(a.product_price_id) end as product_price_id,
+				(array_agg(a.product_price order by a.base_price))[1] as product_price,
+				(array_agg(a.product_currency order by a.base_price))[1] as product_currency,
+				min(a.base_price) as base_price
+			from (` + availableProductsQuery + `) a
+			group by coalesce(a.parent_id, a.id)) g on g.listing_id = p.id
+		where p.deleted_at is null`
+
+var vendorListingQuery = `
+	select p.*, g.stock_quantity, g.reserved_quantity, g.product_price_id, g.product_price, g.product_currency, g.base_price
+		from products p
+		join (select coalesce(v.parent_id, v.id) as listing_id, sum(v.stock_quantity) as stock_quantity,
+				sum(v.reserved_quantity) as reserved_quantity,
+				case when count(v.parent_id) = 0 then max(v.product_price_id) end as product_price_id,
+				(array_agg(v.product_price order by v.base_price))[1] as product_price,
+				(array_agg(v.product_currency order by v.base_price))[1] as product_currency,
+				min(v.base_price) as base_price
+			from (` + vendorProductsQuery + `) v
+			group by coalesce(v.parent_id, v.id)) g on g.listing_id = p.id
+		where p.deleted_at is null`
+
+// The fields of the product listings, over the `d` listing. The price is
+// filtered and sorted on by its value in the base currency
+var productFields = map[string]listing.Field{
+	"id":            {Column: "d.id", Type: listing.Integer},
+	"name":          {Column: "d.name", Type: listing.String},
+	"sku":           {Column: "d.sku", Type: listing.String},
+	"barcode":       {Column: "d.barcode", Type: listing.String},
+	"unit":          {Column: "d.unit", Type: listing.String},
+	"createdAt":     {Column: "d.created_at", Type: listing.Date},
+	"price":         {Column: "d.base_price", Type: listing.Decimal},
+	"stockQuantity": {Column: "d.stock_quantity", Type: listing.Decimal},
+}
+
+// the products are paginated by keyset on their creation
+var productKeyset = &listing.Keyset{TimeColumn: "d.created_at", IDColumn: "d.id"}
+
+var vendorProductsSpec = listing.Spec{
+	Fields: withProductFields(map[string]listing.Field{
+		"categoryId":       {Column: "d.category_id", Type: listing.Integer},
+		"reservedQuantity": {Column: "d.reserved_quantity", Type: listing.Decimal},
+	}),
+	DefaultSort: "-createdAt",
+	TieBreaker:  "d.id desc",
+	Keyset:      productKeyset,
+}
+
+// the category is matched with its descendants by the finder
+var availableProductsSpec = listing.Spec{
+	Fields: withProductFields(map[string]listing.Field{
+		"vendorId": {Column: "d.vendor_id", Type: listing.Integer},
+	}),
+	Custom:      []string{"q", "category", "inStock"},
+	DefaultSort: "name",
+	TieBreaker:  "d.id",
+	Keyset:      productKeyset,
+}
+
+func withProductFields(fields map[string]listing.Field) map[string]listing.Field {
+	for key, value := range productFields {
+		fields[key] = value
+	}
+	return fields
+}
+
+// Create a product, a product with options is
+// sold through the variants created under it
+func CreateProduct(product *models.Product, options []string) error {
 	db := database.GetDBInstance()
-	res := db.Preload("Vendor").Create(&product)
-	return res.Error
+
+	if len(product.Unit) == 0 {
+		product.Unit = models.UnitPiece
+	}
+
+	taxCategory, err := taxes.NormalizeTaxCategory(product.TaxCategory)
+	if err != nil {
+		return err
+	}
+	product.TaxCategory = taxCategory
+
+	if err := validateProductIdentity(db, 0, product.VendorID, product.Unit, product.SKU, product.Barcode); err != nil {
+		return err
+	}
+
+	if product.CategoryID != nil {
+		if err := categories.CheckCategoryExists(db, *product.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	options, err = normalizeOptions(options)
+	if err != nil {
+		return err
+	}
+	product.HasVariants = len(options) > 0
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Vendor").Create(product).Error; err != nil {
+			return err
+		}
+
+		for position, name := range options {
+			err := tx.Create(&models.ProductOption{ProductID: product.ID, Name: name, Position: position}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Check the unit of measure and the barcode of a product, and that
+// no other product of the vendor already uses its SKU
+func validateProductIdentity(db *gorm.DB, productId uint, vendorId uint, unit models.Unit, sku *string, barcode *string) error {
+	if !unit.IsValid() {
+		return common.ErrorInvalidUnit
+	}
+
+	if barcode != nil && !utils.IsValidGTIN(*barcode) {
+		return common.ErrorInvalidBarcode
+	}
+
+	if sku == nil {
+		return nil
+	}
+
+	count := int64(0)
+	err := db.Model(&models.Product{}).
+		Where("vendor_id = ? and sku = ? and id <> ?", vendorId, *sku, productId).
+		Count(&count).Error
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return common.ErrorSKUExists
+	}
+
+	return nil
 }
 
+// Find a product with its current price and the stock which can
+// be sold, the stock held by unpaid orders is not available
 func FindProductById(id uint) (dto.ProductWithPrice, error) {
 	o := dto.ProductWithPrice{}
 	db := database.GetDBInstance()
 	res := db.Raw(`
-	select p.*, sum(coalesce(pt.quantity,0)) as stock_quantity, pp1.price as price
+	select p.*, coalesce(ps.quantity, 0) - coalesce(sr.quantity, 0) as stock_quantity, pp.price as price, pp.currency as product_currency
 		from products p
-        left join product_transactions pt on p.id = pt.product_id
-        left join product_prices pp1 on (p.id = pp1.product_id)
-        left join product_prices pp2 on  (p.id = pp2.product_id and
-                                         (pp1.created_at < pp2.created_at or (pp1.created_at = pp2.created_at and pp1.id < pp2.id)))
-	where pp2.id is null and  p.id = ?
-	group by p.id, pp1.price`, id).Scan(&o)
+        left join product_stocks ps on p.id = ps.product_id
+        left join (`+reservations.ActiveHoldsQuery+`) sr on p.id = sr.product_id
+        left join (`+activePricesQuery("now()")+`) pp on p.id = pp.product_id
+	where p.id = ?`, id).Scan(&o)
 
 	if res.Error != nil {
 		return dto.ProductWithPrice{}, res.Error
@@ -43,25 +220,56 @@ func FindProductsOfVendor(vendorId uint, paginationQuery dto.PaginationQuery) (*
 	pageIndex := paginationQuery.PageIndex
 	itemsPerPage := paginationQuery.ItemsPerPage
 
-	err := db.Raw(`select p.*, sum(coalesce(pt.quantity, 0)) as stock_quantity, pp1.id as product_price_id, pp1.price as product_price
-		from products p
-		left join product_transactions pt on p.id = pt.product_id
-		left join product_prices pp1 on (p.id = pp1.product_id)
-		left join product_prices pp2 on  (p.id = pp2.product_id and
-										(pp1.created_at < pp2.created_at or (pp1.created_at = pp2.created_at and pp1.id < pp2.id)))
-		where pp2.id is null and vendor_id = ?
-		group by p.id, pp1.price, pp1.id
-		order by p.created_at desc offset ? limit ?`, vendorId, pageIndex*itemsPerPage, itemsPerPage).Scan(&o).Error
+	filterQuery, filterParams, err := vendorProductsSpec.Where(paginationQuery.Filters)
+	if err != nil {
+		return nil, err
+	}
 
+	page, err := vendorProductsSpec.Page(paginationQuery)
 	if err != nil {
 		return nil, err
 	}
 
-	total := 0
-	err = db.Raw(`select count(p.id) as quantity
-		from products p
-		where vendor_id = ?`, vendorId).Scan(&total).Error
+	whereQuery := " where d.vendor_id = ?" + filterQuery
+	countParams := append([]interface{}{vendorId}, filterParams...)
+	params := append(append(append([]interface{}{}, countParams...), page.WhereParams...), page.LimitParams...)
+
+	err = db.Raw(`select d.* from (`+vendorListingQuery+`) d`+whereQuery+page.Where+page.Order+page.Limit,
+		params...).Scan(&o).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	o, nextCursor := listing.NextPage(page, o, productCursor)
+
+	productIds := []uint{}
+	for _, product := range o {
+		productIds = append(productIds, product.ID)
+	}
+
+	warehouseStocks, err := findWarehouseStocks(productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range o {
+		o[i].Warehouses = warehouseStocks[o[i].ID]
+	}
+
+	if err := fillBreadcrumbs(o); err != nil {
+		return nil, err
+	}
+
+	if err := fillImages(o); err != nil {
+		return nil, err
+	}
+
+	if err := fillVariants(o, vendorProductsQuery); err != nil {
+		return nil, err
+	}
 
+	total, err := countProducts(db, paginationQuery, vendorListingQuery, whereQuery, countParams)
 	if err != nil {
 		return nil, err
 	}
@@ -71,149 +279,368 @@ func FindProductsOfVendor(vendorId uint, paginationQuery dto.PaginationQuery) (*
 		Total:        total,
 		PageIndex:    pageIndex,
 		ItemsPerPage: itemsPerPage,
+		NextCursor:   nextCursor,
 	}, nil
 }
 
+// Find the products a user can buy, the products of the user
+// are excluded. See buildProductSearch for the filters
 func FindAvailableProducts(userId uint, paginationQuery dto.PaginationQuery) (*dto.PaginationResponse[dto.Product], error) {
 	o := []dto.Product{}
 	db := database.GetDBInstance()
 	pageIndex := paginationQuery.PageIndex
 	itemsPerPage := paginationQuery.ItemsPerPage
 
-	err := db.Raw(`select d.* from (
-			select p.*, sum(coalesce(pt.quantity, 0)) as stock_quantity, pp1.id as product_price_id, pp1.price as product_price
-				from products p
-				left join product_transactions pt on p.id = pt.product_id
-				join product_prices pp1 on (p.id = pp1.product_id)
-				left join product_prices pp2 on  (p.id = pp2.product_id and
-												(pp1.created_at < pp2.created_at or (pp1.created_at = pp2.created_at and pp1.id < pp2.id)))
-			where pp2.id is null
-			group by p.id, pp1.price, pp1.id
-		) d where stock_quantity >0 and vendor_id  != ?
-		offset ? limit ?`, userId, pageIndex*itemsPerPage, itemsPerPage).Scan(&o).Error
-
+	search, err := buildProductSearch(db, paginationQuery)
 	if err != nil {
 		return nil, err
 	}
 
-	total := 0
-	err = db.Raw(`select count(d.id) from (
-			select p.id, sum(coalesce(pt.quantity, 0)) as stock_quantity
-				from products p
-				left join product_transactions pt on p.id = pt.product_id
-				group by p.id
-		) d where stock_quantity >0
-	`).Scan(&total).Error
+	whereQuery := " where d.vendor_id != ?" + search.where
+	countParams := append([]interface{}{userId}, search.whereParams...)
+	params := append(append([]interface{}{}, search.columnParams...), countParams...)
+	params = append(append(params, search.page.WhereParams...), search.page.LimitParams...)
+
+	var total *int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := setSearchSimilarityThreshold(tx); err != nil {
+			return err
+		}
+
+		err := tx.Raw(`select d.*`+search.columns+` from (`+availableListingQuery+`) d`+whereQuery+search.page.Where+
+			search.order+search.page.Limit, params...).Scan(&o).Error
+
+		if err != nil {
+			return err
+		}
+
+		total, err = countProducts(tx, paginationQuery, availableListingQuery, whereQuery, countParams)
+		return err
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	o, nextCursor := listing.NextPage(search.page, o, productCursor)
+
+	if err := fillBreadcrumbs(o); err != nil {
+		return nil, err
+	}
+
+	if err := fillImages(o); err != nil {
+		return nil, err
+	}
+
+	if err := fillVariants(o, availableProductsQuery); err != nil {
+		return nil, err
+	}
+
 	return &dto.PaginationResponse[dto.Product]{
 		Items:        o,
 		Total:        total,
 		PageIndex:    pageIndex,
 		ItemsPerPage: itemsPerPage,
+		NextCursor:   nextCursor,
 	}, nil
 }
 
-// Find the stock quantity of a product
-// by summing all of its product transaction quantity
-func FindProductStockQuantity(productId uint) (int, error) {
-	db := database.GetDBInstance()
+// Count the products of a listing, unless the client opted out
+func countProducts(db *gorm.DB, paginationQuery dto.PaginationQuery, listingQuery string, whereQuery string, params []interface{}) (*int, error) {
+	if paginationQuery.SkipTotal {
+		return nil, nil
+	}
 
 	total := 0
-	res := db.Raw(`select sum(coalesce(pt.quantity, 0)) from products p
-    left join product_transactions pt on p.id = pt.product_id
-    where p.id = ?`, productId).Scan(&total)
+	err := db.Raw(`select count(d.id) from (`+listingQuery+`) d`+whereQuery, params...).
+		Scan(&total).Error
+
+	if err != nil {
+		return nil, err
+	}
 
-	return total, res.Error
+	return &total, nil
 }
 
-func UpdateProduct(id uint, product dto.UpdateProductDto) error {
-	db := database.GetDBInstance()
-	return db.Model(&models.Product{}).Where("id = ?", id).Updates(&models.Product{
-		Name:        product.Name,
-		Description: product.Description,
-	}).Error
+func productCursor(product dto.Product) listing.Cursor {
+	return listing.Cursor{Time: product.CreatedAt, ID: product.ID}
 }
 
-func SetProductPrice(productId uint, price decimal.Decimal) error {
-	db := database.GetDBInstance()
+// Restrict the products to a category (the `category` filter)
+// and all of its descendants
+func categoryFilter(db *gorm.DB, value string) (string, []interface{}, error) {
+	if len(value) == 0 {
+		return "", nil, nil
+	}
 
-	return db.Create(&models.ProductPrice{
-		ProductID: productId,
-		Price:     price,
-	}).Error
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return "", nil, common.ErrorInvalidFilter
+	}
+
+	ids, err := categories.FindDescendantIds(db, uint(id))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(ids) == 0 {
+		return "", nil, common.ErrorCategoryNotFound
+	}
+
+	return " and category_id in (?)", []interface{}{ids}, nil
 }
 
-func GetProductPrices(productId uint) ([]models.ProductPrice, error) {
+func fillBreadcrumbs(items []dto.Product) error {
+	categoryIds := []uint{}
+	for _, item := range items {
+		if item.CategoryID != nil {
+			categoryIds = append(categoryIds, *item.CategoryID)
+		}
+	}
+
+	breadcrumbs, err := categories.FindBreadcrumbs(categoryIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].Categories = []dto.CategoryRefDto{}
+		if items[i].CategoryID != nil {
+			items[i].Categories = breadcrumbs[*items[i].CategoryID]
+		}
+	}
+
+	return nil
+}
+
+// Find the stock of the given products in each warehouse
+func findWarehouseStocks(productIds []uint) (map[uint][]dto.WarehouseStockDto, error) {
 	db := database.GetDBInstance()
-	prices := []models.ProductPrice{}
+	stocks := []dto.WarehouseStockDto{}
+	result := make(map[uint][]dto.WarehouseStockDto)
 
-	err := db.Where("product_id = ?", productId).Find(&prices).Error
+	if len(productIds) == 0 {
+		return result, nil
+	}
+
+	err := db.Raw(`
+		select ws.product_id, ws.warehouse_id, w.name as warehouse_name, ws.quantity
+			from warehouse_stocks ws
+			inner join warehouses w on ws.warehouse_id = w.id
+		where ws.product_id in (?) and w.deleted_at is null
+		order by w.priority asc, w.id asc`, productIds).Scan(&stocks).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	return prices, nil
+	for _, stock := range stocks {
+		result[stock.ProductID] = append(result[stock.ProductID], stock)
+	}
+
+	return result, nil
 }
 
-func FindProductLatestPrice(productId uint) (models.ProductPrice, error) {
-	db := database.GetDBInstance()
-	price := models.ProductPrice{}
-	res := db.Where("product_id = ?", productId).Order("created_at DESC").First(&price)
+// Find the available stock quantity of a product,
+// its stock balance minus the quantity held by unpaid orders
+func FindProductStockQuantity(productId uint) (decimal.Decimal, error) {
+	return findProductStockQuantity(database.GetDBInstance(), productId)
+}
+
+func findProductStockQuantity(db *gorm.DB, productId uint) (decimal.Decimal, error) {
+	total := decimal.Zero
+	err := db.Raw(`select coalesce(ps.quantity, 0) - coalesce(sr.quantity, 0) from products p
+    left join product_stocks ps on p.id = ps.product_id
+    left join (`+reservations.ActiveHoldsQuery+`) sr on p.id = sr.product_id
+    where p.id = ?`, productId).Row().Scan(&total)
 
-	return price, res.Error
+	return total, err
 }
 
-func ImportProductStock(productId uint, quantity int, description string) error {
+// Update a product, its unit and its tax category are kept when none is
+// given and an empty SKU or barcode is removed. The name, the unit, the
+// category and the tax category of a variant are the ones of its parent
+func UpdateProduct(id uint, product dto.UpdateProductDto) error {
 	db := database.GetDBInstance()
+	current := models.Product{}
+	if err := db.First(&current, id).Error; err != nil {
+		return err
+	}
 
-	return db.Transaction(func(tx *gorm.DB) error {
+	name, unit, categoryId := product.Name, product.Unit, product.CategoryID
+	if len(unit) == 0 {
+		unit = current.Unit
+	}
+
+	taxCategory := current.TaxCategory
+	if len(product.TaxCategory) > 0 {
+		category, err := taxes.NormalizeTaxCategory(product.TaxCategory)
+		if err != nil {
+			return err
+		}
+		taxCategory = category
+	}
 
-		tableName := utils.GetModelTableName(models.ProductTransaction{})
+	if current.ParentID != nil {
+		name, unit, categoryId, taxCategory = current.Name, current.Unit, current.CategoryID, current.TaxCategory
+	}
+	sku := utils.NilIfEmpty(product.SKU)
+	barcode := utils.NilIfEmpty(product.Barcode)
 
-		if err := tx.Raw(fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE;", tableName)).Error; err != nil {
+	if err := validateProductIdentity(db, id, current.VendorID, unit, sku, barcode); err != nil {
+		return err
+	}
+
+	if categoryId != nil {
+		if err := categories.CheckCategoryExists(db, *categoryId); err != nil {
 			return err
 		}
+	}
 
-		err := tx.Create(&models.ProductTransaction{
-			ProductID:   productId,
-			Quantity:    quantity,
-			Type:        models.TransactionTypeIn,
-			Description: description,
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"name":         name,
+			"description":  product.Description,
+			"unit":         unit,
+			"sku":          sku,
+			"barcode":      barcode,
+			"category_id":  categoryId,
+			"tax_category": taxCategory,
 		}).Error
 
-		if err != nil {
+		if err != nil || !current.HasVariants {
 			return err
 		}
-		return nil
+
+		current.Name, current.Unit, current.CategoryID, current.TaxCategory = name, unit, categoryId, taxCategory
+		return updateVariantsOfParent(tx, current)
 	})
 }
 
-func ExportProductStock(productId uint, quantity int, description string) error {
+func SetLowStockThreshold(productId uint, threshold decimal.Decimal) error {
 	db := database.GetDBInstance()
+	return db.Model(&models.Product{}).Where("id = ?", productId).
+		Update("low_stock_threshold", threshold).Error
+}
 
-	return db.Transaction(func(tx *gorm.DB) error {
+// Lock the rows of the given products until the end of the transaction,
+// every change of their stock has to take these locks first.
+// The rows are locked in the same order to avoid deadlocks
+func LockProducts(tx *gorm.DB, productIds []uint) error {
+	locked := []models.Product{}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id in (?)", productIds).
+		Order("id").
+		Find(&locked).Error
+}
+
+// Check that a quantity of a product is positive and allowed
+// by the unit of measure of the product. A product with variants
+// has no stock, its variants are stocked and sold instead
+func ValidateQuantity(db *gorm.DB, productId uint, quantity decimal.Decimal) error {
+	product := models.Product{}
+	if err := db.Select("id", "unit", "has_variants").First(&product, productId).Error; err != nil {
+		return err
+	}
+
+	if product.HasVariants {
+		return common.ErrorProductHasVariants
+	}
+
+	if !quantity.IsPositive() || !product.Unit.IsValidQuantity(quantity) {
+		return common.ErrorInvalidQuantity
+	}
+
+	return nil
+}
+
+func checkSellable(db *gorm.DB, productId uint) error {
+	product := models.Product{}
+	if err := db.Select("id", "has_variants").First(&product, productId).Error; err != nil {
+		return err
+	}
+
+	if product.HasVariants {
+		return common.ErrorProductHasVariants
+	}
+
+	return nil
+}
+
+func ImportProductStock(productId uint, quantity decimal.Decimal, description string) error {
+	return ImportWarehouseStock(productId, nil, quantity, description)
+}
 
-		tableName := utils.GetModelTableName(models.ProductTransaction{})
+// Take a quantity of a product out of the stock,
+// the available quantity is checked while the product is locked
+func ExportProductStock(productId uint, quantity decimal.Decimal, description string) error {
+	return ExportWarehouseStock(productId, nil, quantity, description)
+}
+
+// Put a quantity of a product in a warehouse (if any)
+func ImportWarehouseStock(productId uint, warehouseId *uint, quantity decimal.Decimal, description string) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
 
-		if err := tx.Raw(fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE;", tableName)).Error; err != nil {
+		if err := ValidateQuantity(tx, productId, quantity); err != nil {
 			return err
 		}
 
-		err := tx.Create(&models.ProductTransaction{
+		return tx.Create(&models.ProductTransaction{
 			ProductID:   productId,
-			Quantity:    -quantity,
-			Type:        models.TransactionTypeOut,
+			Quantity:    quantity,
+			Type:        models.TransactionTypeIn,
 			Description: description,
+			WarehouseID: warehouseId,
 		}).Error
+	})
+}
+
+// Take a quantity of a product out of a warehouse (if any), both
+// the available quantity of the product and the stock of the warehouse
+// are checked while the product is locked
+func ExportWarehouseStock(productId uint, warehouseId *uint, quantity decimal.Decimal, description string) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		if err := ValidateQuantity(tx, productId, quantity); err != nil {
+			return err
+		}
 
+		available, err := findProductStockQuantity(tx, productId)
 		if err != nil {
 			return err
 		}
-		return nil
+
+		if warehouseId != nil {
+			inWarehouse := decimal.Zero
+			if err := tx.Raw(`select coalesce(sum(quantity), 0) from warehouse_stocks where product_id = ? and warehouse_id = ?`,
+				productId, *warehouseId).Row().Scan(&inWarehouse); err != nil {
+				return err
+			}
+
+			available = decimal.Min(available, inWarehouse)
+		}
+
+		if available.LessThan(quantity) {
+			return common.ErrorInsufficientQuantity
+		}
+
+		return tx.Create(&models.ProductTransaction{
+			ProductID:   productId,
+			Quantity:    quantity.Neg(),
+			Type:        models.TransactionTypeOut,
+			Description: description,
+			WarehouseID: warehouseId,
+		}).Error
 	})
 }
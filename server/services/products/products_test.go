@@ -1,17 +1,29 @@
 package products_test
 
 import (
+	"errors"
+	"order-system/common"
 	"order-system/database"
+	"order-system/handlers/dto"
 	"order-system/models"
+	"order-system/services/categories"
 	"order-system/services/products"
+	"order-system/services/reservations"
+	"order-system/services/warehouses"
 	"os"
 	"path"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
+// shorthand for the quantities of the fixtures
+var d = decimal.NewFromInt
+
 func TestProductPrice(t *testing.T) {
 	database.InitTestDB()
 	defer database.DropTestDB()
@@ -19,7 +31,7 @@ func TestProductPrice(t *testing.T) {
 	productId := 1
 	expectedPrice := decimal.NewFromFloat(100)
 
-	price, err := products.FindProductLatestPrice(uint(productId))
+	price, err := products.FindProductPrice(uint(productId), time.Now())
 	if err != nil {
 		t.Error("error while getting product price")
 	}
@@ -37,7 +49,7 @@ func TestProductStockQuantity(t *testing.T) {
 	productId := 1
 	expectedPrice := decimal.NewFromFloat(100)
 
-	price, err := products.FindProductLatestPrice(uint(productId))
+	price, err := products.FindProductPrice(uint(productId), time.Now())
 	if err != nil {
 		t.Error("error while getting product price")
 	}
@@ -53,10 +65,10 @@ func TestImportProduct(t *testing.T) {
 	defer database.DropTestDB()
 
 	productId := 1
-	expectedQuantity := 10
+	expectedQuantity := d(10)
 	expectedDescription := "test 1"
 
-	if err := products.ImportProductStock(uint(productId), 10, "test 1"); err != nil {
+	if err := products.ImportProductStock(uint(productId), d(10), "test 1"); err != nil {
 		t.Error("error while importing product")
 	}
 
@@ -70,7 +82,7 @@ func TestImportProduct(t *testing.T) {
 	}
 
 	if productTransaction.Description != expectedDescription ||
-		productTransaction.Quantity != expectedQuantity ||
+		!productTransaction.Quantity.Equal(expectedQuantity) ||
 		productTransaction.Type != models.TransactionTypeIn {
 		t.Log("expected: v", []interface{}{expectedQuantity, expectedDescription, models.TransactionTypeIn})
 		t.Error("actual: v", []interface{}{productTransaction.Quantity, productTransaction.Description, productTransaction.Type})
@@ -82,10 +94,10 @@ func TestExportProduct(t *testing.T) {
 	defer database.DropTestDB()
 
 	productId := 1
-	expectedQuantity := 10
+	expectedQuantity := d(10)
 	expectedDescription := "test 1"
 
-	if err := products.ExportProductStock(uint(productId), 10, "test 1"); err != nil {
+	if err := products.ExportProductStock(uint(productId), d(10), "test 1"); err != nil {
 		t.Error("error while exporting product")
 	}
 
@@ -99,16 +111,561 @@ func TestExportProduct(t *testing.T) {
 	}
 
 	if productTransaction.Description != expectedDescription ||
-		productTransaction.Quantity != -expectedQuantity ||
+		!productTransaction.Quantity.Equal(expectedQuantity.Neg()) ||
 		productTransaction.Type != models.TransactionTypeOut {
-		t.Log("expected: v", []interface{}{-expectedQuantity, expectedDescription, models.TransactionTypeOut})
+		t.Log("expected: v", []interface{}{expectedQuantity.Neg(), expectedDescription, models.TransactionTypeOut})
 		t.Error("actual: v", []interface{}{productTransaction.Quantity, productTransaction.Description, productTransaction.Type})
 	}
 }
 
+func TestStockBalance(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	if err := products.ImportProductStock(productId, d(10), "import"); err != nil {
+		t.Error("error while importing product", err)
+	}
+
+	if err := products.ExportProductStock(productId, d(3), "export"); err != nil {
+		t.Error("error while exporting product", err)
+	}
+
+	balance := models.ProductStock{}
+	if err := database.GetDBInstance().First(&balance, productId).Error; err != nil {
+		t.Error("error while getting stock balance", err)
+	}
+
+	expectedQuantity := d(5 + 10 - 3)
+	if !balance.Quantity.Equal(expectedQuantity) {
+		t.Logf("expected: %s", expectedQuantity)
+		t.Errorf("actual: %s", balance.Quantity)
+	}
+}
+
+func TestReconcileStock(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	testDb := database.GetDBInstance()
+	if err := testDb.Model(&models.ProductStock{}).Where("product_id = ?", productId).
+		Update("quantity", 999).Error; err != nil {
+		t.Error("error while corrupting stock balance", err)
+	}
+
+	drifts, err := products.ReconcileStock()
+	if err != nil {
+		t.Error("error while reconciling stock", err)
+	}
+
+	if len(drifts) != 1 || drifts[0].ProductID != productId ||
+		!drifts[0].BalanceQuantity.Equal(d(999)) || !drifts[0].LedgerQuantity.Equal(d(5)) {
+		t.Error("actual: v", drifts)
+	}
+
+	quantity, err := products.FindProductStockQuantity(productId)
+	if err != nil || !quantity.Equal(d(5)) {
+		t.Errorf("expected the balance to be fixed, got %s (%v)", quantity, err)
+	}
+}
+
+func TestReconcileWarehouseStock(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	testDb := database.GetDBInstance()
+	warehouse := models.Warehouse{VendorID: 2, Name: "Main"}
+	if err := testDb.Create(&warehouse).Error; err != nil {
+		t.Fatal("error while creating warehouse", err)
+	}
+
+	if err := products.ImportWarehouseStock(productId, &warehouse.ID, d(10), "restock"); err != nil {
+		t.Fatal("error while importing product", err)
+	}
+
+	if err := testDb.Model(&models.WarehouseStock{}).Where("product_id = ? and warehouse_id = ?", productId, warehouse.ID).
+		Update("quantity", 4).Error; err != nil {
+		t.Error("error while corrupting warehouse stock balance", err)
+	}
+
+	drifts, err := products.ReconcileStock()
+	if err != nil {
+		t.Error("error while reconciling stock", err)
+	}
+
+	if len(drifts) != 1 || drifts[0].ProductID != productId || drifts[0].WarehouseID == nil ||
+		*drifts[0].WarehouseID != warehouse.ID || !drifts[0].BalanceQuantity.Equal(d(4)) || !drifts[0].LedgerQuantity.Equal(d(10)) {
+		t.Error("actual: v", drifts)
+	}
+
+	balance := models.WarehouseStock{}
+	if err := testDb.Where("product_id = ? and warehouse_id = ?", productId, warehouse.ID).First(&balance).Error; err != nil ||
+		!balance.Quantity.Equal(d(10)) {
+		t.Errorf("expected the warehouse balance to be fixed, got %s (%v)", balance.Quantity, err)
+	}
+}
+
+// create an order of the vendor of the product with a single item
+func createTestOrder(t *testing.T, productId uint, quantity decimal.Decimal) models.Order {
+	testDb := database.GetDBInstance()
+	product := models.Product{}
+	if err := testDb.First(&product, productId).Error; err != nil {
+		t.Fatal("error while getting product", err)
+	}
+
+	price := models.ProductPrice{}
+	if err := testDb.Where("product_id = ?", productId).First(&price).Error; err != nil {
+		t.Fatal("error while getting product price", err)
+	}
+
+	order := models.Order{
+		UserID:          1,
+		VendorID:        product.VendorID,
+		PaymentMethodID: models.PaymentMethodCredit,
+		Items:           []models.OrderItem{{ProductID: productId, Quantity: quantity, ProductPriceId: price.ID}},
+	}
+	if err := testDb.Create(&order).Error; err != nil {
+		t.Fatal("error while creating order", err)
+	}
+
+	return order
+}
+
+func TestFindProductByIdWithHolds(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	order := createTestOrder(t, productId, d(2))
+	if err := reservations.HoldOrders(database.GetDBInstance(), []uint{order.ID}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal("error while holding order", err)
+	}
+
+	product, err := products.FindProductById(productId)
+	if err != nil {
+		t.Error("error while getting product", err)
+	}
+
+	if !product.StockQuantity.Equal(d(3)) {
+		t.Log("expected: v", d(3))
+		t.Error("actual: v", product.StockQuantity)
+	}
+}
+
+func TestPickFulfillingWarehouseWithHolds(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	testDb := database.GetDBInstance()
+	first := models.Warehouse{VendorID: 2, Name: "First", Priority: 0}
+	second := models.Warehouse{VendorID: 2, Name: "Second", Priority: 1}
+	for _, warehouse := range []*models.Warehouse{&first, &second} {
+		if err := testDb.Create(warehouse).Error; err != nil {
+			t.Fatal("error while creating warehouse", err)
+		}
+
+		if err := products.ImportWarehouseStock(productId, &warehouse.ID, d(3), "restock"); err != nil {
+			t.Fatal("error while importing product", err)
+		}
+	}
+
+	// the first order holds all the stock of the first warehouse,
+	// the second one must be fulfilled by the other warehouse
+	expected := []uint{first.ID, second.ID}
+	for _, warehouseId := range expected {
+		order := createTestOrder(t, productId, d(3))
+		err := testDb.Transaction(func(tx *gorm.DB) error {
+			picked, err := warehouses.PickFulfillingWarehouse(tx, order.ID)
+			if err != nil {
+				return err
+			}
+
+			if picked == nil || *picked != warehouseId {
+				t.Log("expected: v", warehouseId)
+				t.Error("actual: v", picked)
+				return nil
+			}
+
+			if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("warehouse_id", *picked).Error; err != nil {
+				return err
+			}
+
+			return reservations.HoldOrders(tx, []uint{order.ID}, time.Now().Add(time.Hour))
+		})
+
+		if err != nil {
+			t.Fatal("error while fulfilling order", err)
+		}
+	}
+
+	// both warehouses are held entirely
+	order := createTestOrder(t, productId, d(1))
+	if _, err := warehouses.PickFulfillingWarehouse(testDb, order.ID); !errors.Is(err, common.ErrorNoFulfillingWarehouse) {
+		t.Log("expected: v", common.ErrorNoFulfillingWarehouse)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestPickFulfillingWarehouseLegacyStock(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	// the stock of the fixtures predates the warehouses,
+	// it goes to the default warehouse of the vendor
+	testDb := database.GetDBInstance()
+	database.AutoMigrate(testDb)
+
+	productId := uint(1)
+	warehouse, err := warehouses.FindOrCreateDefaultWarehouse(2)
+	if err != nil {
+		t.Fatal("error while getting default warehouse", err)
+	}
+
+	order := createTestOrder(t, productId, d(5))
+	picked, err := warehouses.PickFulfillingWarehouse(testDb, order.ID)
+	if err != nil || picked == nil || *picked != warehouse.ID {
+		t.Log("expected: v", warehouse.ID)
+		t.Error("actual: v", picked, err)
+	}
+
+	legacy := int64(0)
+	testDb.Model(&models.ProductTransaction{}).Where("warehouse_id is null").Count(&legacy)
+	if legacy != 0 {
+		t.Log("expected: v", 0)
+		t.Error("actual: v", legacy)
+	}
+}
+
+func TestFindStockMovements(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	if err := products.ImportProductStock(productId, d(10), "restock from supplier"); err != nil {
+		t.Error("error while importing product", err)
+	}
+
+	if err := products.ExportProductStock(productId, d(3), "damaged items"); err != nil {
+		t.Error("error while exporting product", err)
+	}
+
+	res, err := products.FindStockMovements(productId, dto.PaginationQuery{ItemsPerPage: 2})
+	if err != nil {
+		t.Error("error while finding stock movements", err)
+	}
+
+	// the fixture transaction of 5 comes first
+	if *res.Total != 3 || len(res.Items) != 2 ||
+		!res.Items[0].Balance.Equal(d(12)) || !res.Items[1].Balance.Equal(d(15)) {
+		t.Error("actual: v", res)
+	}
+
+	res, err = products.FindStockMovements(productId, dto.PaginationQuery{
+		Filters: map[string]string{"type": string(models.TransactionTypeOut), "description": "DAMAGED"},
+	})
+	if err != nil {
+		t.Error("error while finding stock movements", err)
+	}
+
+	if *res.Total != 1 || !res.Items[0].Quantity.Equal(d(-3)) || !res.Items[0].Balance.Equal(d(12)) {
+		t.Error("actual: v", res)
+	}
+
+	_, err = products.FindStockMovements(productId, dto.PaginationQuery{
+		Filters: map[string]string{"from": "yesterday"},
+	})
+	if !errors.Is(err, common.ErrorInvalidFilter) {
+		t.Log("expected: v", common.ErrorInvalidFilter)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestImportStockRows(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	// products 1 and 2 belong to vendor 2, product 3 to vendor 3
+	vendorId := uint(2)
+	rows := []dto.StockImportRow{
+		{Line: 2, ProductID: 1, Quantity: d(10), Type: models.TransactionTypeIn},
+		{Line: 3, ProductID: 2, Quantity: d(3), Type: models.TransactionTypeOut},
+	}
+
+	report, err := products.ImportStockRows(vendorId, rows, true)
+	if err != nil {
+		t.Error("error while importing stock", err)
+	}
+
+	quantity, _ := products.FindProductStockQuantity(1)
+	if report.Errors != 0 || report.Applied || !quantity.Equal(d(5)) {
+		t.Errorf("expected a dry run without any change, got %+v (stock %s)", report, quantity)
+	}
+
+	testDb := database.GetDBInstance()
+	vendorWarehouses := int64(0)
+	testDb.Model(&models.Warehouse{}).Where("vendor_id = ?", vendorId).Count(&vendorWarehouses)
+	if vendorWarehouses != 0 {
+		t.Log("expected: v", 0)
+		t.Error("actual: v", vendorWarehouses)
+	}
+
+	invalidRows := append(rows, dto.StockImportRow{Line: 4, ProductID: 3, Quantity: d(1), Type: models.TransactionTypeIn})
+	report, err = products.ImportStockRows(vendorId, invalidRows, false)
+	if err != nil {
+		t.Error("error while importing stock", err)
+	}
+
+	quantity, _ = products.FindProductStockQuantity(1)
+	if report.Errors != 1 || report.Applied || !quantity.Equal(d(5)) {
+		t.Errorf("expected nothing to be applied, got %+v (stock %s)", report, quantity)
+	}
+
+	report, err = products.ImportStockRows(vendorId, rows, false)
+	if err != nil {
+		t.Error("error while importing stock", err)
+	}
+
+	quantity, _ = products.FindProductStockQuantity(1)
+	if !report.Applied || !quantity.Equal(d(15)) {
+		t.Errorf("expected every row to be applied, got %+v (stock %s)", report, quantity)
+	}
+
+	warehouse, err := products.FindDefaultWarehouse(testDb, vendorId, false)
+	if err != nil || warehouse == nil || report.Rows[0].WarehouseID == nil || *report.Rows[0].WarehouseID != warehouse.ID {
+		t.Log("expected: v", warehouse)
+		t.Error("actual: v", report.Rows[0].WarehouseID, err)
+	}
+}
+
+func TestLowStockAlert(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	productId := uint(1)
+	if err := products.SetLowStockThreshold(productId, d(3)); err != nil {
+		t.Error("error while setting threshold", err)
+	}
+
+	// 5 -> 4 -> 2 -> 1, only the move from 4 to 2 crosses the threshold
+	for _, quantity := range []int64{1, 2, 1} {
+		if err := products.ExportProductStock(productId, d(quantity), "export"); err != nil {
+			t.Error("error while exporting product", err)
+		}
+	}
+
+	alerts := []models.StockAlert{}
+	if err := database.GetDBInstance().Where("product_id = ?", productId).Find(&alerts).Error; err != nil {
+		t.Error("error while getting stock alerts", err)
+	}
+
+	if len(alerts) != 1 || !alerts[0].Quantity.Equal(d(2)) || !alerts[0].Threshold.Equal(d(3)) || alerts[0].VendorID != 2 {
+		t.Error("actual: v", alerts)
+	}
+}
+
+func TestFindAvailableProductsByCategory(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	food, err := categories.CreateCategory(dto.CategoryRequestDto{Name: "Food"})
+	if err != nil {
+		t.Fatal("error while creating category", err)
+	}
+	drinks, _ := categories.CreateCategory(dto.CategoryRequestDto{Name: "Drinks", ParentID: &food.ID})
+	tea, _ := categories.CreateCategory(dto.CategoryRequestDto{Name: "Tea", ParentID: &drinks.ID})
+
+	testDb := database.GetDBInstance()
+	testDb.Model(&models.Product{}).Where("id = ?", 3).Update("category_id", tea.ID)
+	testDb.Model(&models.Product{}).Where("id = ?", 4).Update("category_id", food.ID)
+
+	// products 3 and 4 belong to vendor 3, both are visible to the regular user 1
+	res, err := products.FindAvailableProducts(1, dto.PaginationQuery{
+		ItemsPerPage: 10,
+		Filters:      map[string]string{"category": strconv.Itoa(int(drinks.ID))},
+	})
+	if err != nil {
+		t.Fatal("error while finding products", err)
+	}
+
+	if *res.Total != 1 || len(res.Items) != 1 || res.Items[0].ID != 3 ||
+		len(res.Items[0].Categories) != 3 || res.Items[0].Categories[0].ID != food.ID {
+		t.Error("actual: v", res)
+	}
+
+	res, _ = products.FindAvailableProducts(1, dto.PaginationQuery{
+		ItemsPerPage: 10,
+		Filters:      map[string]string{"category": strconv.Itoa(int(food.ID))},
+	})
+	if *res.Total != 2 {
+		t.Log("expected: v", 2)
+		t.Error("actual: v", *res.Total)
+	}
+
+	err = categories.UpdateCategory(food.ID, dto.CategoryRequestDto{Name: "Food", ParentID: &tea.ID})
+	if !errors.Is(err, common.ErrorCategoryCycle) {
+		t.Log("expected: v", common.ErrorCategoryCycle)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestSearchAvailableProducts(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	testDb := database.GetDBInstance()
+	testDb.Model(&models.Product{}).Where("id = ?", 3).Updates(map[string]interface{}{"name": "Green tea", "description": "Loose leaf"})
+	testDb.Model(&models.Product{}).Where("id = ?", 4).Updates(map[string]interface{}{"name": "Black tea", "description": "Strong"})
+
+	search := func(filters map[string]string) *dto.PaginationResponse[dto.Product] {
+		res, err := products.FindAvailableProducts(1, dto.PaginationQuery{ItemsPerPage: 10, Filters: filters})
+		if err != nil {
+			t.Fatal("error while searching products", err)
+		}
+		return res
+	}
+
+	res := search(map[string]string{"q": "gree te"})
+	if *res.Total != 1 || res.Items[0].ID != 3 || res.Items[0].NameHighlight != "<mark>Green</mark> <mark>tea</mark>" {
+		t.Error("actual: v", res)
+	}
+
+	// misspelled
+	res = search(map[string]string{"q": "gren"})
+	if *res.Total != 1 || res.Items[0].ID != 3 {
+		t.Error("actual: v", res)
+	}
+
+	res = search(map[string]string{"q": "tea", "vendorId": "3"})
+	if *res.Total != 2 {
+		t.Log("expected: v", 2)
+		t.Error("actual: v", *res.Total)
+	}
+
+	res = search(map[string]string{"q": "tea", "price[lte]": "50"})
+	if *res.Total != 0 {
+		t.Log("expected: v", 0)
+		t.Error("actual: v", *res.Total)
+	}
+}
+
 func TestMain(m *testing.M) {
 	cwd, _ := os.Getwd()
 	godotenv.Load(path.Join(cwd, "..", "..", ".env.testing"))
 	code := m.Run()
 	os.Exit(code)
 }
+
+func TestProductVariants(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	shirt := models.Product{Name: "T-shirt", VendorID: 3}
+	if err := products.CreateProduct(&shirt, []string{"Size", "Color"}); err != nil {
+		t.Fatal("error while creating product", err)
+	}
+
+	small, err := products.CreateVariant(shirt.ID, dto.CreateVariantDto{Options: map[string]string{"size": "S", "Color": "Red"}})
+	if err != nil {
+		t.Fatal("error while creating variant", err)
+	}
+	large, _ := products.CreateVariant(shirt.ID, dto.CreateVariantDto{Options: map[string]string{"Size": "L", "Color": "Red"}})
+
+	if small.Name != "T-shirt - S / Red" || *small.ParentID != shirt.ID {
+		t.Error("actual: v", small)
+	}
+
+	_, err = products.CreateVariant(shirt.ID, dto.CreateVariantDto{Options: map[string]string{"Size": "s", "Color": "red"}})
+	if !errors.Is(err, common.ErrorVariantExists) {
+		t.Log("expected: v", common.ErrorVariantExists)
+		t.Error("actual: v", err)
+	}
+
+	// the product itself is sold through its variants
+	if err := products.SetProductPrice(shirt.ID, d(10)); !errors.Is(err, common.ErrorProductHasVariants) {
+		t.Log("expected: v", common.ErrorProductHasVariants)
+		t.Error("actual: v", err)
+	}
+
+	products.SetProductPrice(small.ID, d(10))
+	products.SetProductPrice(large.ID, d(12))
+	products.ImportProductStock(small.ID, d(2), "")
+	products.ImportProductStock(large.ID, d(3), "")
+
+	res, err := products.FindAvailableProducts(1, dto.PaginationQuery{
+		ItemsPerPage: 10,
+		Filters:      map[string]string{"q": "shirt"},
+	})
+	if err != nil {
+		t.Fatal("error while finding products", err)
+	}
+
+	if *res.Total != 1 || res.Items[0].ID != shirt.ID || !res.Items[0].StockQuantity.Equal(d(5)) ||
+		!res.Items[0].ProductPrice.Equal(d(10)) || len(res.Items[0].Variants) != 2 || len(res.Items[0].Options) != 2 {
+		t.Error("actual: v", res)
+	}
+
+	variant := res.Items[0].Variants[0]
+	if variant.ID != small.ID || variant.Options[0].Name != "Size" || variant.Options[0].Value != "S" {
+		t.Error("actual: v", variant)
+	}
+
+	// the variants follow the name of their parent
+	products.UpdateProduct(shirt.ID, dto.UpdateProductDto{Name: "Tee"})
+	variants, _ := products.FindVariants(shirt.ID)
+	if variants[1].Name != "Tee - L / Red" {
+		t.Error("actual: v", variants[1].Name)
+	}
+}
+
+func TestScheduledProductPrices(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	// product 1 costs 100 from the fixtures
+	productId := uint(1)
+	now := time.Now()
+	saleStart, saleEnd := now.Add(time.Hour), now.Add(3*time.Hour)
+
+	sale, err := products.ScheduleProductPrice(productId, d(80), "", &saleStart, &saleEnd)
+	if err != nil {
+		t.Fatal("error while scheduling price", err)
+	}
+
+	instants := map[time.Time]decimal.Decimal{
+		now:                     d(100),
+		now.Add(2 * time.Hour):  d(80),
+		saleEnd:                 d(100),
+		now.Add(24 * time.Hour): d(100),
+	}
+
+	for at, expected := range instants {
+		price, err := products.FindProductPrice(productId, at)
+		if err != nil || !price.Price.Equal(expected) {
+			t.Log("expected: v", at, expected)
+			t.Error("actual: v", price.Price, err)
+		}
+	}
+
+	upcoming, _ := products.GetProductPrices(productId, true)
+	if len(upcoming) != 1 || upcoming[0].ID != sale.ID {
+		t.Error("actual: v", upcoming)
+	}
+
+	if err := products.CancelProductPrice(productId, sale.ID); err != nil {
+		t.Error("actual: v", err)
+	}
+
+	if price, _ := products.FindProductPrice(productId, now.Add(2*time.Hour)); !price.Price.Equal(d(100)) {
+		t.Error("actual: v", price.Price)
+	}
+
+	// a price in effect is history
+	current, _ := products.FindProductPrice(productId, time.Now())
+	if err := products.CancelProductPrice(productId, current.ID); !errors.Is(err, common.ErrorPriceNotCancellable) {
+		t.Log("expected: v", common.ErrorPriceNotCancellable)
+		t.Error("actual: v", err)
+	}
+}
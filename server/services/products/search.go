@@ -0,0 +1,123 @@
+package products
+
+import (
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/services/listing"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// how close (0 to 1) a misspelled search has to be to the name of a product
+	searchSimilarityThreshold = 0.4
+	searchHeadlineOptions     = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+)
+
+// The ids of the products matching a search, on the products themselves so
+// the index on their search document (idx_products_search) and the trigram
+// index on their name (idx_products_name_trgm) are used. The columns are
+// not qualified in the document, it works on `products` as on `d`.
+// `<%` compares the word similarity to pg_trgm.word_similarity_threshold,
+// see setSearchSimilarityThreshold
+const productMatchQuery = `select id from products where ` + models.ProductSearchDocument + ` @@ to_tsquery('simple', ?)
+	union select id from products where ? <% name`
+
+// The conditions and the ordering of a product listing. Besides the
+// fields of availableProductsSpec, the filters are:
+//   - q: full-text search over the name and the description, every word
+//     is matched as a prefix and a misspelled name is still found
+//   - inStock: "false" to include the products out of stock
+//   - category: id of a category, with its descendants
+//
+// The columns are computed over the `d` listing, which has the columns
+// of the product with its stock_quantity and product_price. A search is
+// ordered by relevance unless another sort or a cursor is requested
+type productSearch struct {
+	columns      string
+	columnParams []interface{}
+	where        string
+	whereParams  []interface{}
+	order        string
+	page         *listing.Page
+}
+
+func buildProductSearch(db *gorm.DB, paginationQuery dto.PaginationQuery) (*productSearch, error) {
+	filters := paginationQuery.Filters
+	where, whereParams, err := availableProductsSpec.Where(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := availableProductsSpec.Page(paginationQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &productSearch{where: where, whereParams: whereParams, order: page.Order, page: page}
+
+	switch filters["inStock"] {
+	case "", "true":
+		search.where += " and d.stock_quantity > 0"
+	case "false":
+	default:
+		return nil, common.ErrorInvalidFilter
+	}
+
+	categoryQuery, categoryParams, err := categoryFilter(db, filters["category"])
+	if err != nil {
+		return nil, err
+	}
+	search.where += categoryQuery
+	search.whereParams = append(search.whereParams, categoryParams...)
+
+	text := strings.TrimSpace(filters["q"])
+	if len(text) == 0 {
+		return search, nil
+	}
+
+	tsQuery := buildPrefixTsQuery(text)
+	if len(tsQuery) == 0 {
+		return nil, common.ErrorInvalidFilter
+	}
+
+	document := models.ProductSearchDocument
+	search.where += " and d.id in (" + productMatchQuery + ")"
+	search.whereParams = append(search.whereParams, tsQuery, text)
+
+	search.columns = `, ts_rank(` + document + `, to_tsquery('simple', ?)) + word_similarity(?, d.name) as search_rank,
+		ts_headline('simple', d.name, to_tsquery('simple', ?), ?) as name_highlight,
+		ts_headline('simple', coalesce(d.description, ''), to_tsquery('simple', ?), ?) as description_highlight`
+	search.columnParams = []interface{}{tsQuery, text, tsQuery, searchHeadlineOptions, tsQuery, searchHeadlineOptions}
+	if !page.Keyset && len(strings.TrimSpace(paginationQuery.Sort)) == 0 {
+		search.order = " order by search_rank desc, d.name, d.id"
+	}
+
+	return search, nil
+}
+
+// Set the similarity a misspelled search needs with the name of a product
+// to match it, for the rest of the transaction only
+func setSearchSimilarityThreshold(tx *gorm.DB) error {
+	return tx.Exec("select set_config('pg_trgm.word_similarity_threshold', ?, true)",
+		strconv.FormatFloat(searchSimilarityThreshold, 'f', -1, 64)).Error
+}
+
+// Turn the words of a search into a tsquery matching all of them
+// as prefixes, "green te" gives "green:* & te:*". Anything but
+// letters and digits is dropped so the input cannot break the query
+func buildPrefixTsQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,98 @@
+package products
+
+import (
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestBuildPrefixTsQuery(t *testing.T) {
+	queries := map[string]string{
+		"green te":            "green:* & te:*",
+		"  Green   TEA ":      "green:* & tea:*",
+		"tea' | !mug:* & (a)": "tea:* & mug:* & a:*",
+		"thé vert":            "thé:* & vert:*",
+		"!!! &":               "",
+	}
+
+	for text, expected := range queries {
+		if actual := buildPrefixTsQuery(text); actual != expected {
+			t.Log("expected: v", expected)
+			t.Error("actual: v", actual)
+		}
+	}
+}
+
+func TestBuildProductSearch(t *testing.T) {
+	search, err := buildProductSearch(nil, dto.PaginationQuery{Filters: map[string]string{"price[gte]": "10", "vendorId": "2", "inStock": "false"}})
+	if err != nil {
+		t.Fatal("error while building search", err)
+	}
+
+	if search.where != " and d.base_price >= ? and d.vendor_id = ?" || len(search.whereParams) != 2 ||
+		search.order != " order by d.name asc, d.id" {
+		t.Error("actual: v", search)
+	}
+
+	search, _ = buildProductSearch(nil, dto.PaginationQuery{Filters: map[string]string{"q": "green te"}})
+	if len(search.columnParams) != 6 || search.order != " order by search_rank desc, d.name, d.id" {
+		t.Error("actual: v", search)
+	}
+
+	// matched on the products, where the search document and the names are indexed
+	if search.where != " and d.stock_quantity > 0 and d.id in ("+productMatchQuery+")" || len(search.whereParams) != 2 {
+		t.Error("actual: v", search.where)
+	}
+
+	search, _ = buildProductSearch(nil, dto.PaginationQuery{Filters: map[string]string{"q": "green te"}, Sort: "-price"})
+	if search.order != " order by d.base_price desc, d.id" {
+		t.Error("actual: v", search)
+	}
+
+	for _, filters := range []map[string]string{{"price[lte]": "ten"}, {"vendor": "2"}, {"q": "&&"}, {"inStock": "maybe"}} {
+		if _, err := buildProductSearch(nil, dto.PaginationQuery{Filters: filters}); err != common.ErrorInvalidFilter {
+			t.Log("expected: v", common.ErrorInvalidFilter)
+			t.Error("actual: v", err)
+		}
+	}
+
+	cursor := ""
+	search, _ = buildProductSearch(nil, dto.PaginationQuery{Filters: map[string]string{"q": "green te"}, Cursor: &cursor, ItemsPerPage: 10})
+	if search.order != " order by d.created_at desc, d.id desc" || search.page.Limit != " limit ?" {
+		t.Error("actual: v", search)
+	}
+}
+
+func TestProductMatchQueryPlan(t *testing.T) {
+	database.InitTestDB()
+	defer database.DropTestDB()
+
+	// the fixtures are too few for the planner to pick an index by itself
+	plan := []string{}
+	err := database.GetDBInstance().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("set local enable_seqscan = off").Error; err != nil {
+			return err
+		}
+
+		if err := setSearchSimilarityThreshold(tx); err != nil {
+			return err
+		}
+
+		return tx.Raw("explain "+productMatchQuery, buildPrefixTsQuery("green te"), "green te").Scan(&plan).Error
+	})
+
+	if err != nil {
+		t.Fatal("error while explaining search", err)
+	}
+
+	for _, index := range []string{"idx_products_search", "idx_products_name_trgm"} {
+		if !strings.Contains(strings.Join(plan, "\n"), index) {
+			t.Log("expected: v", index)
+			t.Error("actual: v", plan)
+		}
+	}
+}
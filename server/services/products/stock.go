@@ -0,0 +1,173 @@
+package products
+
+import (
+	"errors"
+	"order-system/database"
+	"order-system/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The difference between the stock balance of a product (or of
+// a product in a warehouse when WarehouseID is set) and the sum
+// of its product transactions
+type StockDrift struct {
+	ProductID       uint            `gorm:"column:product_id"`
+	WarehouseID     *uint           `gorm:"column:warehouse_id"`
+	LedgerQuantity  decimal.Decimal `gorm:"column:ledger_quantity"`
+	BalanceQuantity decimal.Decimal `gorm:"column:balance_quantity"`
+}
+
+const stockDriftQuery = `
+	select p.id as product_id
+		from products p
+		left join (select product_id, sum(quantity) as quantity from product_transactions group by product_id) l on p.id = l.product_id
+		left join product_stocks ps on p.id = ps.product_id
+	where coalesce(l.quantity, 0) != coalesce(ps.quantity, 0) or ps.product_id is null`
+
+const warehouseStockDriftQuery = `
+	select coalesce(l.product_id, ws.product_id) as product_id
+		from (select product_id, warehouse_id, sum(quantity) as quantity from product_transactions
+			where warehouse_id is not null group by product_id, warehouse_id) l
+		full join warehouse_stocks ws on l.product_id = ws.product_id and l.warehouse_id = ws.warehouse_id
+	where coalesce(l.quantity, 0) != coalesce(ws.quantity, 0)`
+
+// Recompute the stock balances of the products and of the products
+// in each warehouse from the ledger, the drifted balances are reported and fixed
+func ReconcileStock() ([]StockDrift, error) {
+	db := database.GetDBInstance()
+	productIds := []uint{}
+
+	err := db.Raw(stockDriftQuery + " union " + warehouseStockDriftQuery + " order by product_id").Scan(&productIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reported := []StockDrift{}
+	for _, productId := range productIds {
+		var fixed []StockDrift
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			fixed, err = reconcileProductStock(tx, productId)
+			return err
+		})
+
+		if err != nil {
+			return reported, err
+		}
+
+		reported = append(reported, fixed...)
+	}
+
+	return reported, nil
+}
+
+// The product is locked, so no ledger entry can be added while its
+// balances are recomputed. A missing balance of a product without
+// any transaction is created but not reported as a drift
+func reconcileProductStock(tx *gorm.DB, productId uint) ([]StockDrift, error) {
+	drift := StockDrift{ProductID: productId}
+
+	if err := LockProducts(tx, []uint{productId}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Raw(`select coalesce(sum(quantity), 0) from product_transactions where product_id = ?`, productId).
+		Row().Scan(&drift.LedgerQuantity); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Raw(`select coalesce(sum(quantity), 0) from product_stocks where product_id = ?`, productId).
+		Row().Scan(&drift.BalanceQuantity); err != nil {
+		return nil, err
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+	}).Create(&models.ProductStock{
+		ProductID: productId,
+		Quantity:  drift.LedgerQuantity,
+		UpdatedAt: time.Now(),
+	}).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := []StockDrift{}
+	if !drift.LedgerQuantity.Equal(drift.BalanceQuantity) {
+		drifts = append(drifts, drift)
+	}
+
+	warehouseDrifts := []StockDrift{}
+	err = tx.Raw(`select coalesce(l.warehouse_id, ws.warehouse_id) as warehouse_id,
+			coalesce(l.quantity, 0) as ledger_quantity, coalesce(ws.quantity, 0) as balance_quantity
+		from (select warehouse_id, sum(quantity) as quantity from product_transactions
+			where product_id = ? and warehouse_id is not null group by warehouse_id) l
+		full join (select * from warehouse_stocks where product_id = ?) ws on l.warehouse_id = ws.warehouse_id
+		where coalesce(l.quantity, 0) != coalesce(ws.quantity, 0)
+		order by 1`, productId, productId).Scan(&warehouseDrifts).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range warehouseDrifts {
+		warehouseDrift := &warehouseDrifts[i]
+		warehouseDrift.ProductID = productId
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "warehouse_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(&models.WarehouseStock{
+			ProductID:   productId,
+			WarehouseID: *warehouseDrift.WarehouseID,
+			Quantity:    warehouseDrift.LedgerQuantity,
+			UpdatedAt:   time.Now(),
+		}).Error
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append(drifts, warehouseDrifts...), nil
+}
+
+// Find the default warehouse of a vendor, the stock changes without any
+// location go there. A vendor without any warehouse gets a default one
+// when create is set, otherwise nil is returned
+func FindDefaultWarehouse(tx *gorm.DB, vendorId uint, create bool) (*models.Warehouse, error) {
+	warehouse := models.Warehouse{}
+	err := tx.Where("vendor_id = ?", vendorId).
+		Order("is_default desc, priority asc, id asc").
+		First(&warehouse).Error
+
+	if err == nil {
+		return &warehouse, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !create {
+		return nil, nil
+	}
+
+	warehouse = models.Warehouse{
+		VendorID:  vendorId,
+		Name:      "Main warehouse",
+		IsDefault: true,
+	}
+
+	if err := tx.Create(&warehouse).Error; err != nil {
+		return nil, err
+	}
+
+	return &warehouse, nil
+}
@@ -0,0 +1,366 @@
+package products
+
+import (
+	"encoding/csv"
+	"io"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/services/reservations"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var stockImportColumns = []string{"product_id", "sku", "warehouse_id", "quantity", "type", "description"}
+
+type stockKey struct {
+	productId   uint
+	warehouseId uint
+}
+
+// Parse a bulk stock import, the first line is the header. The columns
+// are found by name: product_id or sku, quantity, type, description
+// and warehouse_id (optional). A row which cannot be parsed
+// keeps its error and is reported along with the others
+func ParseStockImportCSV(r io.Reader) ([]dto.StockImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, common.ErrorInvalidCSV
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	_, hasId := index["product_id"]
+	_, hasSku := index["sku"]
+	_, hasQuantity := index["quantity"]
+	_, hasType := index["type"]
+	if !(hasId || hasSku) || !hasQuantity || !hasType {
+		return nil, common.ErrorInvalidCSV
+	}
+
+	rows := []dto.StockImportRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, common.ErrorInvalidCSV
+		}
+
+		values := make(map[string]string)
+		for _, column := range stockImportColumns {
+			if i, ok := index[column]; ok && i < len(record) {
+				values[column] = strings.TrimSpace(record[i])
+			}
+		}
+
+		rows = append(rows, parseStockImportRow(line, values))
+	}
+
+	return rows, nil
+}
+
+func parseStockImportRow(line int, values map[string]string) dto.StockImportRow {
+	row := dto.StockImportRow{
+		Line:        line,
+		SKU:         values["sku"],
+		Type:        models.TransactionType(strings.ToLower(values["type"])),
+		Description: values["description"],
+	}
+
+	if len(values["product_id"]) > 0 {
+		id, err := strconv.ParseUint(values["product_id"], 10, 64)
+		if err != nil {
+			row.Error = "product_id_not_numeric"
+			return row
+		}
+		row.ProductID = uint(id)
+	}
+
+	if len(values["warehouse_id"]) > 0 {
+		id, err := strconv.ParseUint(values["warehouse_id"], 10, 64)
+		if err != nil {
+			row.Error = "warehouse_id_not_numeric"
+			return row
+		}
+		warehouseId := uint(id)
+		row.WarehouseID = &warehouseId
+	}
+
+	quantity, err := decimal.NewFromString(values["quantity"])
+	if err != nil {
+		row.Error = "quantity_not_numeric"
+		return row
+	}
+	row.Quantity = quantity
+
+	return row
+}
+
+// Validate the rows of a bulk stock import against the products and
+// the stock of the vendor, then apply all of them at once. Nothing is
+// applied when any row is invalid or on a dry run. The products are locked
+// so the report matches what is written. The rows without a warehouse go to
+// the default warehouse of the vendor, which a dry run does not create
+func ImportStockRows(vendorId uint, rows []dto.StockImportRow, dryRun bool) (*dto.StockImportReport, error) {
+	db := database.GetDBInstance()
+	report := &dto.StockImportReport{DryRun: dryRun, Rows: rows}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		productsById, productsBySku, err := findImportedProducts(tx, vendorId, rows)
+		if err != nil {
+			return err
+		}
+
+		productIds := []uint{}
+		for id, product := range productsById {
+			if product.VendorID == vendorId {
+				productIds = append(productIds, id)
+			}
+		}
+
+		if len(productIds) > 0 {
+			if err := LockProducts(tx, productIds); err != nil {
+				return err
+			}
+		}
+
+		available, inWarehouses, err := findImportedStocks(tx, productIds)
+		if err != nil {
+			return err
+		}
+
+		var defaultWarehouseId *uint
+		defaultWarehouse, err := FindDefaultWarehouse(tx, vendorId, !dryRun)
+		if err != nil {
+			return err
+		}
+		if defaultWarehouse != nil {
+			defaultWarehouseId = &defaultWarehouse.ID
+		}
+
+		vendorWarehouses := make(map[uint]bool)
+		warehouseIds := []uint{}
+		if err := tx.Table("warehouses").Where("vendor_id = ? and deleted_at is null", vendorId).
+			Pluck("id", &warehouseIds).Error; err != nil {
+			return err
+		}
+		for _, id := range warehouseIds {
+			vendorWarehouses[id] = true
+		}
+
+		transactionItems := []models.ProductTransaction{}
+		for i := range report.Rows {
+			row := &report.Rows[i]
+			if len(row.Error) == 0 {
+				row.Error = validateStockImportRow(row, vendorId, defaultWarehouseId,
+					productsById, productsBySku, vendorWarehouses, available, inWarehouses)
+			}
+
+			if len(row.Error) > 0 {
+				report.Errors++
+				continue
+			}
+
+			quantity := row.Quantity
+			if row.Type == models.TransactionTypeOut {
+				quantity = quantity.Neg()
+			}
+
+			transactionItems = append(transactionItems, models.ProductTransaction{
+				ProductID:   row.ProductID,
+				Quantity:    quantity,
+				Type:        row.Type,
+				Description: row.Description,
+				WarehouseID: row.WarehouseID,
+			})
+		}
+
+		if report.Errors > 0 || dryRun || len(transactionItems) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&transactionItems).Error; err != nil {
+			return err
+		}
+
+		report.Applied = true
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Check a row and update the simulated stock, so the next rows
+// of the same product see the quantity left by this one
+func validateStockImportRow(
+	row *dto.StockImportRow,
+	vendorId uint,
+	defaultWarehouseId *uint,
+	productsById map[uint]models.Product,
+	productsBySku map[string]models.Product,
+	vendorWarehouses map[uint]bool,
+	available map[uint]decimal.Decimal,
+	inWarehouses map[stockKey]decimal.Decimal,
+) string {
+	if !row.Quantity.IsPositive() {
+		return "quantity_empty"
+	}
+
+	if row.Type != models.TransactionTypeIn && row.Type != models.TransactionTypeOut {
+		return "invalid_stock_change_type"
+	}
+
+	var product models.Product
+	var ok bool
+	if row.ProductID > 0 {
+		product, ok = productsById[row.ProductID]
+	} else {
+		product, ok = productsBySku[row.SKU]
+	}
+
+	if !ok {
+		return common.ErrorResourceNotFound.Error()
+	}
+
+	if product.VendorID != vendorId {
+		return common.ErrorInsufficientPermission.Error()
+	}
+
+	row.ProductID = product.ID
+
+	if product.HasVariants {
+		return common.ErrorProductHasVariants.Error()
+	}
+
+	if !product.Unit.IsValidQuantity(row.Quantity) {
+		return common.ErrorInvalidQuantity.Error()
+	}
+
+	if row.WarehouseID == nil {
+		row.WarehouseID = defaultWarehouseId
+	} else if !vendorWarehouses[*row.WarehouseID] {
+		return "warehouse_not_found"
+	}
+
+	key := stockKey{productId: row.ProductID}
+	if row.WarehouseID != nil {
+		key.warehouseId = *row.WarehouseID
+	}
+
+	if row.Type == models.TransactionTypeOut {
+		if available[row.ProductID].LessThan(row.Quantity) ||
+			(row.WarehouseID != nil && inWarehouses[key].LessThan(row.Quantity)) {
+			return common.ErrorInsufficientQuantity.Error()
+		}
+
+		available[row.ProductID] = available[row.ProductID].Sub(row.Quantity)
+		inWarehouses[key] = inWarehouses[key].Sub(row.Quantity)
+	} else {
+		available[row.ProductID] = available[row.ProductID].Add(row.Quantity)
+		inWarehouses[key] = inWarehouses[key].Add(row.Quantity)
+	}
+
+	row.Balance = available[row.ProductID]
+	return ""
+}
+
+// Find the products referenced by the rows, either by id
+// or by a SKU of the vendor
+func findImportedProducts(tx *gorm.DB, vendorId uint, rows []dto.StockImportRow) (map[uint]models.Product, map[string]models.Product, error) {
+	ids := []uint{}
+	skus := []string{}
+	for _, row := range rows {
+		if row.ProductID > 0 {
+			ids = append(ids, row.ProductID)
+		} else if len(row.SKU) > 0 {
+			skus = append(skus, row.SKU)
+		}
+	}
+
+	byId := make(map[uint]models.Product)
+	bySku := make(map[string]models.Product)
+
+	found := []models.Product{}
+	if len(ids) > 0 {
+		if err := tx.Where("id in (?)", ids).Find(&found).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(skus) > 0 {
+		withSku := []models.Product{}
+		if err := tx.Where("vendor_id = ? and sku in (?)", vendorId, skus).Find(&withSku).Error; err != nil {
+			return nil, nil, err
+		}
+		found = append(found, withSku...)
+	}
+
+	for _, product := range found {
+		byId[product.ID] = product
+		if product.SKU != nil && product.VendorID == vendorId {
+			bySku[*product.SKU] = product
+		}
+	}
+
+	return byId, bySku, nil
+}
+
+func findImportedStocks(tx *gorm.DB, productIds []uint) (map[uint]decimal.Decimal, map[stockKey]decimal.Decimal, error) {
+	available := make(map[uint]decimal.Decimal)
+	inWarehouses := make(map[stockKey]decimal.Decimal)
+
+	if len(productIds) == 0 {
+		return available, inWarehouses, nil
+	}
+
+	type productQuantity struct {
+		ProductID   uint            `gorm:"column:product_id"`
+		WarehouseID uint            `gorm:"column:warehouse_id"`
+		Quantity    decimal.Decimal `gorm:"column:quantity"`
+	}
+
+	quantities := []productQuantity{}
+	err := tx.Raw(`select p.id as product_id, coalesce(ps.quantity, 0) - coalesce(sr.quantity, 0) as quantity from products p
+		left join product_stocks ps on p.id = ps.product_id
+		left join (`+reservations.ActiveHoldsQuery+`) sr on p.id = sr.product_id
+		where p.id in (?)`, productIds).Scan(&quantities).Error
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, q := range quantities {
+		available[q.ProductID] = q.Quantity
+	}
+
+	quantities = []productQuantity{}
+	err = tx.Raw(`select product_id, warehouse_id, quantity from warehouse_stocks where product_id in (?)`, productIds).
+		Scan(&quantities).Error
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, q := range quantities {
+		inWarehouses[stockKey{productId: q.ProductID, warehouseId: q.WarehouseID}] = q.Quantity
+	}
+
+	return available, inWarehouses, nil
+}
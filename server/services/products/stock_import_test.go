@@ -0,0 +1,82 @@
+package products
+
+import (
+	"order-system/common"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseStockImportCSV(t *testing.T) {
+	rows, err := ParseStockImportCSV(strings.NewReader(
+		"SKU,Quantity,Type,Description\n" +
+			"A-1,10,IN,pallet 42\n" +
+			"A-2,ten,out,\n"))
+
+	if err != nil {
+		t.Fatal("error while parsing csv", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+
+	if rows[0].Line != 2 || rows[0].SKU != "A-1" || !rows[0].Quantity.Equal(decimal.NewFromInt(10)) ||
+		rows[0].Type != models.TransactionTypeIn || rows[0].Description != "pallet 42" {
+		t.Error("actual: v", rows[0])
+	}
+
+	if rows[1].Error != "quantity_not_numeric" {
+		t.Log("expected: v", "quantity_not_numeric")
+		t.Error("actual: v", rows[1].Error)
+	}
+
+	if _, err := ParseStockImportCSV(strings.NewReader("name,quantity\nfoo,1\n")); err != common.ErrorInvalidCSV {
+		t.Log("expected: v", common.ErrorInvalidCSV)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestValidateStockImportRows(t *testing.T) {
+	vendorId := uint(1)
+	productsById := map[uint]models.Product{
+		1: {Base: models.Base{BaseWithPrimaryKey: models.BaseWithPrimaryKey{ID: 1}}, VendorID: vendorId},
+		2: {Base: models.Base{BaseWithPrimaryKey: models.BaseWithPrimaryKey{ID: 2}}, VendorID: 2},
+	}
+
+	available := map[uint]decimal.Decimal{1: decimal.NewFromInt(5)}
+	inWarehouses := map[stockKey]decimal.Decimal{}
+	validate := func(productId uint, quantity float64, transactionType models.TransactionType) string {
+		row := dto.StockImportRow{ProductID: productId, Quantity: decimal.NewFromFloat(quantity), Type: transactionType}
+		return validateStockImportRow(&row, vendorId, nil, productsById, nil, nil, available, inWarehouses)
+	}
+
+	if e := validate(1, 4, models.TransactionTypeOut); e != "" {
+		t.Error("expected the first export to be valid, got", e)
+	}
+
+	// only 1 left after the previous row
+	if e := validate(1, 2, models.TransactionTypeOut); e != common.ErrorInsufficientQuantity.Error() {
+		t.Log("expected: v", common.ErrorInsufficientQuantity.Error())
+		t.Error("actual: v", e)
+	}
+
+	// the product is counted by piece
+	if e := validate(1, 0.5, models.TransactionTypeIn); e != common.ErrorInvalidQuantity.Error() {
+		t.Log("expected: v", common.ErrorInvalidQuantity.Error())
+		t.Error("actual: v", e)
+	}
+
+	if e := validate(2, 1, models.TransactionTypeIn); e != common.ErrorInsufficientPermission.Error() {
+		t.Log("expected: v", common.ErrorInsufficientPermission.Error())
+		t.Error("actual: v", e)
+	}
+
+	if e := validate(3, 1, models.TransactionTypeIn); e != common.ErrorResourceNotFound.Error() {
+		t.Log("expected: v", common.ErrorResourceNotFound.Error())
+		t.Error("actual: v", e)
+	}
+}
@@ -0,0 +1,330 @@
+package products
+
+import (
+	"encoding/json"
+	"errors"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Check the option axes of a new product, trimmed,
+// not empty and unique whatever their case
+func normalizeOptions(options []string) ([]string, error) {
+	result := []string{}
+	seen := make(map[string]bool)
+
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if len(option) == 0 || seen[strings.ToLower(option)] {
+			return nil, common.ErrorInvalidVariant
+		}
+
+		seen[strings.ToLower(option)] = true
+		result = append(result, option)
+	}
+
+	return result, nil
+}
+
+// Create a variant of a product with options, it takes one value for each
+// option. The variant is a product of its own, with its own prices and
+// stock, it gets the unit, the category and the tax category of its parent
+func CreateVariant(parentId uint, payload dto.CreateVariantDto) (*models.Product, error) {
+	db := database.GetDBInstance()
+	variant := &models.Product{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// the siblings of the variant are checked then a new one is added
+		if err := LockProducts(tx, []uint{parentId}); err != nil {
+			return err
+		}
+
+		parent := models.Product{}
+		if err := tx.First(&parent, parentId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrorResourceNotFound
+			}
+			return err
+		}
+
+		options := []models.ProductOption{}
+		if err := tx.Where("product_id = ?", parentId).Order("position").Find(&options).Error; err != nil {
+			return err
+		}
+
+		if !parent.HasVariants || len(options) == 0 {
+			return common.ErrorInvalidVariant
+		}
+
+		values, err := matchVariantOptions(options, payload.Options)
+		if err != nil {
+			return err
+		}
+
+		key := variantKey(values)
+		count := int64(0)
+		err = tx.Unscoped().Model(&models.Product{}).
+			Where("parent_id = ? and variant_key = ?", parentId, key).
+			Count(&count).Error
+
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return common.ErrorVariantExists
+		}
+
+		sku := utils.NilIfEmpty(payload.SKU)
+		barcode := utils.NilIfEmpty(payload.Barcode)
+		if err := validateProductIdentity(tx, 0, parent.VendorID, parent.Unit, sku, barcode); err != nil {
+			return err
+		}
+
+		description := payload.Description
+		if len(description) == 0 {
+			description = parent.Description
+		}
+
+		*variant = models.Product{
+			Name:        variantName(parent.Name, values),
+			Description: description,
+			VendorID:    parent.VendorID,
+			Unit:        parent.Unit,
+			CategoryID:  parent.CategoryID,
+			TaxCategory: parent.TaxCategory,
+			SKU:         sku,
+			Barcode:     barcode,
+			ParentID:    &parent.ID,
+			VariantKey:  &key,
+		}
+
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+
+		variantValues := []models.ProductVariantValue{}
+		for i, option := range options {
+			variantValues = append(variantValues, models.ProductVariantValue{
+				VariantID: variant.ID,
+				OptionID:  option.ID,
+				Value:     values[i],
+			})
+		}
+
+		return tx.Create(&variantValues).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// Find the variants of a product with their stock and latest price
+func FindVariants(parentId uint) ([]dto.ProductVariantDto, error) {
+	variants, err := findVariants(vendorProductsQuery, []uint{parentId})
+	if err != nil {
+		return nil, err
+	}
+
+	if variants[parentId] == nil {
+		return []dto.ProductVariantDto{}, nil
+	}
+
+	return variants[parentId], nil
+}
+
+// The values of the options of a variant in the order of the options,
+// the options are matched whatever their case
+func matchVariantOptions(options []models.ProductOption, values map[string]string) ([]string, error) {
+	if len(values) != len(options) {
+		return nil, common.ErrorInvalidVariant
+	}
+
+	byName := make(map[string]string)
+	for name, value := range values {
+		byName[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+
+	result := []string{}
+	for _, option := range options {
+		value := byName[strings.ToLower(option.Name)]
+		if len(value) == 0 {
+			return nil, common.ErrorInvalidVariant
+		}
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+// "T-shirt - M / Red"
+func variantName(parentName string, values []string) string {
+	return parentName + " - " + strings.Join(values, " / ")
+}
+
+// the values whatever their case, `m` and `M` are the same size
+func variantKey(values []string) string {
+	lower := []string{}
+	for _, value := range values {
+		lower = append(lower, strings.ToLower(value))
+	}
+
+	b, _ := json.Marshal(lower)
+	return string(b)
+}
+
+// Update the variants of a product after the product itself,
+// they follow its name, its unit, its category and its tax category
+func updateVariantsOfParent(tx *gorm.DB, parent models.Product) error {
+	variants := []models.Product{}
+	if err := tx.Select("id").Where("parent_id = ?", parent.ID).Find(&variants).Error; err != nil {
+		return err
+	}
+
+	variantIds := []uint{}
+	for _, variant := range variants {
+		variantIds = append(variantIds, variant.ID)
+	}
+
+	options, err := findVariantOptions(tx, variantIds)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range variants {
+		values := []string{}
+		for _, option := range options[variant.ID] {
+			values = append(values, option.Value)
+		}
+
+		err := tx.Model(&models.Product{}).Where("id = ?", variant.ID).Updates(map[string]interface{}{
+			"name":         variantName(parent.Name, values),
+			"unit":         parent.Unit,
+			"category_id":  parent.CategoryID,
+			"tax_category": parent.TaxCategory,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Find the variants of the given products over a product
+// listing query, with the values of their options
+func findVariants(listingQuery string, parentIds []uint) (map[uint][]dto.ProductVariantDto, error) {
+	result := make(map[uint][]dto.ProductVariantDto)
+	if len(parentIds) == 0 {
+		return result, nil
+	}
+
+	db := database.GetDBInstance()
+	variants := []dto.ProductVariantDto{}
+	err := db.Raw(`select v.* from (`+listingQuery+`) v where v.parent_id in (?) order by v.parent_id, v.id`, parentIds).
+		Scan(&variants).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	variantIds := []uint{}
+	for _, variant := range variants {
+		variantIds = append(variantIds, variant.ID)
+	}
+
+	options, err := findVariantOptions(db, variantIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range variants {
+		variant.Options = options[variant.ID]
+		result[variant.ParentID] = append(result[variant.ParentID], variant)
+	}
+
+	return result, nil
+}
+
+// Find the option values of the given variants, in the order of the options
+func findVariantOptions(db *gorm.DB, variantIds []uint) (map[uint][]dto.VariantOptionDto, error) {
+	result := make(map[uint][]dto.VariantOptionDto)
+	if len(variantIds) == 0 {
+		return result, nil
+	}
+
+	values := []struct {
+		VariantID uint
+		dto.VariantOptionDto
+	}{}
+
+	err := db.Raw(`select pvv.variant_id, po.name, pvv.value
+		from product_variant_values pvv
+		join product_options po on po.id = pvv.option_id
+		where pvv.variant_id in (?)
+		order by pvv.variant_id, po.position, po.id`, variantIds).Scan(&values).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		result[value.VariantID] = append(result[value.VariantID], value.VariantOptionDto)
+	}
+
+	return result, nil
+}
+
+// Attach their options and their variants to the products with variants
+func fillVariants(items []dto.Product, listingQuery string) error {
+	parentIds := []uint{}
+	for _, item := range items {
+		if item.HasVariants {
+			parentIds = append(parentIds, item.ID)
+		}
+	}
+
+	if len(parentIds) == 0 {
+		return nil
+	}
+
+	options := []models.ProductOption{}
+	err := database.GetDBInstance().Where("product_id in (?)", parentIds).Order("product_id, position").
+		Find(&options).Error
+
+	if err != nil {
+		return err
+	}
+
+	variants, err := findVariants(listingQuery, parentIds)
+	if err != nil {
+		return err
+	}
+
+	optionNames := make(map[uint][]string)
+	for _, option := range options {
+		optionNames[option.ProductID] = append(optionNames[option.ProductID], option.Name)
+	}
+
+	for i := range items {
+		if items[i].HasVariants {
+			items[i].Options = optionNames[items[i].ID]
+			items[i].Variants = variants[items[i].ID]
+			if items[i].Variants == nil {
+				items[i].Variants = []dto.ProductVariantDto{}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package products
+
+import (
+	"order-system/common"
+	"order-system/models"
+	"testing"
+)
+
+func TestNormalizeOptions(t *testing.T) {
+	options, err := normalizeOptions([]string{" Size ", "Color"})
+	if err != nil || len(options) != 2 || options[0] != "Size" {
+		t.Error("actual: v", options, err)
+	}
+
+	for _, invalid := range [][]string{{"Size", "size"}, {""}, {"Size", "  "}} {
+		if _, err := normalizeOptions(invalid); err != common.ErrorInvalidVariant {
+			t.Log("expected: v", common.ErrorInvalidVariant)
+			t.Error("actual: v", invalid, err)
+		}
+	}
+}
+
+func TestMatchVariantOptions(t *testing.T) {
+	options := []models.ProductOption{{Name: "Size"}, {Name: "Color"}}
+
+	values, err := matchVariantOptions(options, map[string]string{"color": " Red ", "SIZE": "M"})
+	if err != nil || len(values) != 2 || values[0] != "M" || values[1] != "Red" {
+		t.Error("actual: v", values, err)
+	}
+
+	invalid := []map[string]string{
+		{"Size": "M"},
+		{"Size": "M", "Color": ""},
+		{"Size": "M", "Material": "Cotton"},
+		{"Size": "M", "Color": "Red", "Material": "Cotton"},
+	}
+
+	for _, value := range invalid {
+		if _, err := matchVariantOptions(options, value); err != common.ErrorInvalidVariant {
+			t.Log("expected: v", common.ErrorInvalidVariant)
+			t.Error("actual: v", value, err)
+		}
+	}
+}
+
+func TestVariantNameAndKey(t *testing.T) {
+	if name := variantName("T-shirt", []string{"M", "Red"}); name != "T-shirt - M / Red" {
+		t.Error("actual: v", name)
+	}
+
+	if variantKey([]string{"M", "Red"}) != variantKey([]string{"m", "RED"}) {
+		t.Error("actual: v", variantKey([]string{"M", "Red"}))
+	}
+
+	// the values are kept apart whatever they contain
+	if variantKey([]string{"a / b", "c"}) == variantKey([]string{"a", "b / c"}) {
+		t.Error("actual: v", variantKey([]string{"a / b", "c"}))
+	}
+}
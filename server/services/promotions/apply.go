@@ -0,0 +1,214 @@
+package promotions
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// an order item with the price it is ordered at
+type orderLine struct {
+	OrderID   uint
+	ProductID uint
+	ParentID  uint
+	VendorID  uint
+	Amount    decimal.Decimal
+}
+
+type orderDiscount struct {
+	OrderID uint
+	Amount  decimal.Decimal
+}
+
+// Apply a coupon to the orders of a checkout, the discount of each order is
+// stored as an adjustment of the order. The coupon is locked until the end
+// of the transaction so its uses are counted one checkout at a time
+func ApplyCoupon(tx *gorm.DB, userId uint, code string, orderIds []uint) error {
+	coupon := models.Coupon{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", normalizeCode(code)).First(&coupon).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrorCouponNotApplicable
+		}
+		return err
+	}
+
+	if !isCouponOpen(coupon, time.Now()) {
+		return common.ErrorCouponNotApplicable
+	}
+
+	if err := checkCouponUses(tx, coupon, userId); err != nil {
+		return err
+	}
+
+	productIds := []uint{}
+	if err := tx.Model(&models.CouponProduct{}).Where("coupon_id = ?", coupon.ID).Pluck("product_id", &productIds).Error; err != nil {
+		return err
+	}
+
+	lines := []orderLine{}
+	err = tx.Raw(`
+		select oi.order_id, oi.product_id, coalesce(p.parent_id, 0) as parent_id, o.vendor_id, round(pp.price * oi.exchange_rate, 2) * oi.quantity as amount
+		from order_items oi
+		inner join orders o on oi.order_id = o.id
+		inner join products p on oi.product_id = p.id
+		inner join product_prices pp on oi.product_price_id = pp.id
+		where oi.order_id in (?)
+		order by oi.order_id, oi.id`, orderIds).Scan(&lines).Error
+
+	if err != nil {
+		return err
+	}
+
+	// the orders of a checkout are in the same currency
+	order := models.Order{}
+	if err := tx.Select("currency, exchange_rate").First(&order, orderIds[0]).Error; err != nil {
+		return err
+	}
+
+	discounts, err := computeDiscounts(inOrderCurrency(coupon, order.ExchangeRate), productIds, lines)
+	if err != nil {
+		return err
+	}
+
+	adjustments := []models.OrderAdjustment{}
+	for _, discount := range discounts {
+		adjustments = append(adjustments, models.OrderAdjustment{
+			OrderID:     discount.OrderID,
+			Type:        models.AdjustmentDiscount,
+			Description: "Coupon " + coupon.Code,
+			Amount:      discount.Amount.Neg(),
+			CouponID:    &coupon.ID,
+		})
+	}
+
+	if err := tx.Create(&adjustments).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.CouponRedemption{CouponID: coupon.ID, UserID: userId}).Error
+}
+
+func isCouponOpen(coupon models.Coupon, now time.Time) bool {
+	if !coupon.Active {
+		return false
+	}
+
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return false
+	}
+
+	return coupon.EndsAt == nil || now.Before(*coupon.EndsAt)
+}
+
+func checkCouponUses(tx *gorm.DB, coupon models.Coupon, userId uint) error {
+	if coupon.MaxUses != nil {
+		uses := int64(0)
+		err := tx.Model(&models.CouponRedemption{}).Where("coupon_id = ?", coupon.ID).Count(&uses).Error
+		if err != nil {
+			return err
+		}
+
+		if uses >= int64(*coupon.MaxUses) {
+			return common.ErrorCouponUsageExceeded
+		}
+	}
+
+	if coupon.MaxUsesPerUser != nil {
+		uses := int64(0)
+		err := tx.Model(&models.CouponRedemption{}).Where("coupon_id = ? and user_id = ?", coupon.ID, userId).
+			Count(&uses).Error
+		if err != nil {
+			return err
+		}
+
+		if uses >= int64(*coupon.MaxUsesPerUser) {
+			return common.ErrorCouponUsageExceeded
+		}
+	}
+
+	return nil
+}
+
+// The coupon with its amounts, which are in the base currency, converted
+// into the currency of the orders. exchangeRate is the value of one unit
+// of the currency of the orders in the base currency
+func inOrderCurrency(coupon models.Coupon, exchangeRate decimal.Decimal) models.Coupon {
+	if !exchangeRate.IsPositive() || exchangeRate.Equal(decimal.NewFromInt(1)) {
+		return coupon
+	}
+
+	coupon.MinSpend = coupon.MinSpend.Div(exchangeRate).Round(2)
+	if coupon.Type == models.CouponFixed {
+		coupon.Value = coupon.Value.Div(exchangeRate).Round(2)
+	}
+
+	return coupon
+}
+
+// The discount of each order over its eligible items, in the order of the
+// lines. A percentage is taken off each order, a fixed amount (up to the
+// eligible total) is shared between the orders by their eligible total
+func computeDiscounts(coupon models.Coupon, productIds []uint, lines []orderLine) ([]orderDiscount, error) {
+	inScope := make(map[uint]bool)
+	for _, productId := range productIds {
+		inScope[productId] = true
+	}
+
+	eligible := []orderDiscount{}
+	total := decimal.Zero
+
+	for _, line := range lines {
+		if coupon.VendorID != nil && line.VendorID != *coupon.VendorID {
+			continue
+		}
+
+		// a product of the coupon covers its variants
+		if len(inScope) > 0 && !inScope[line.ProductID] && !inScope[line.ParentID] {
+			continue
+		}
+
+		if len(eligible) == 0 || eligible[len(eligible)-1].OrderID != line.OrderID {
+			eligible = append(eligible, orderDiscount{OrderID: line.OrderID})
+		}
+
+		eligible[len(eligible)-1].Amount = eligible[len(eligible)-1].Amount.Add(line.Amount)
+		total = total.Add(line.Amount)
+	}
+
+	if !total.IsPositive() || total.LessThan(coupon.MinSpend) {
+		return nil, common.ErrorCouponNotApplicable
+	}
+
+	discounts := []orderDiscount{}
+
+	if coupon.Type == models.CouponPercentage {
+		for _, order := range eligible {
+			amount := order.Amount.Mul(coupon.Value).Div(hundred).Round(2)
+			discounts = append(discounts, orderDiscount{OrderID: order.OrderID, Amount: amount})
+		}
+
+		return discounts, nil
+	}
+
+	amount := decimal.Min(coupon.Value, total)
+	shared := decimal.Zero
+
+	for i, order := range eligible {
+		share := amount.Sub(shared)
+		if i < len(eligible)-1 {
+			share = amount.Mul(order.Amount).Div(total).Round(2)
+		}
+
+		shared = shared.Add(share)
+		discounts = append(discounts, orderDiscount{OrderID: order.OrderID, Amount: share})
+	}
+
+	return discounts, nil
+}
@@ -0,0 +1,124 @@
+package promotions
+
+import (
+	"order-system/common"
+	"order-system/models"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var checkoutLines = []orderLine{
+	{OrderID: 1, ProductID: 10, VendorID: 2, Amount: decimal.NewFromInt(30)},
+	{OrderID: 1, ProductID: 11, ParentID: 9, VendorID: 2, Amount: decimal.NewFromInt(10)},
+	{OrderID: 2, ProductID: 20, VendorID: 3, Amount: decimal.NewFromInt(20)},
+}
+
+func TestComputeDiscountsPercentage(t *testing.T) {
+	coupon := models.Coupon{Type: models.CouponPercentage, Value: decimal.NewFromInt(10)}
+
+	discounts, err := computeDiscounts(coupon, nil, checkoutLines)
+	if err != nil || len(discounts) != 2 || !discounts[0].Amount.Equal(decimal.NewFromInt(4)) ||
+		!discounts[1].Amount.Equal(decimal.NewFromInt(2)) {
+		t.Error("actual: v", discounts, err)
+	}
+
+	// a parent product covers its variants, the other vendors are left out
+	vendorId := uint(2)
+	coupon.VendorID = &vendorId
+	discounts, err = computeDiscounts(coupon, []uint{9}, checkoutLines)
+	if err != nil || len(discounts) != 1 || discounts[0].OrderID != 1 || !discounts[0].Amount.Equal(decimal.NewFromInt(1)) {
+		t.Error("actual: v", discounts, err)
+	}
+
+	if _, err := computeDiscounts(coupon, []uint{20}, checkoutLines); err != common.ErrorCouponNotApplicable {
+		t.Log("expected: v", common.ErrorCouponNotApplicable)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestComputeDiscountsFixed(t *testing.T) {
+	coupon := models.Coupon{Type: models.CouponFixed, Value: decimal.NewFromInt(10)}
+
+	// shared by the eligible total of the orders, 40 and 20
+	discounts, err := computeDiscounts(coupon, nil, checkoutLines)
+	if err != nil || len(discounts) != 2 || !discounts[0].Amount.Equal(decimal.RequireFromString("6.67")) ||
+		!discounts[1].Amount.Equal(decimal.RequireFromString("3.33")) {
+		t.Error("actual: v", discounts, err)
+	}
+
+	// up to the eligible total
+	coupon.Value = decimal.NewFromInt(100)
+	discounts, err = computeDiscounts(coupon, []uint{20}, checkoutLines)
+	if err != nil || len(discounts) != 1 || !discounts[0].Amount.Equal(decimal.NewFromInt(20)) {
+		t.Error("actual: v", discounts, err)
+	}
+
+	coupon.MinSpend = decimal.NewFromInt(61)
+	if _, err := computeDiscounts(coupon, nil, checkoutLines); err != common.ErrorCouponNotApplicable {
+		t.Log("expected: v", common.ErrorCouponNotApplicable)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestValidateCoupon(t *testing.T) {
+	zero := 0
+	now := time.Now()
+	valid := models.Coupon{Code: "SUMMER-10", Type: models.CouponPercentage, Value: decimal.NewFromInt(10)}
+
+	if err := validateCoupon(&valid); err != nil {
+		t.Error("actual: v", err)
+	}
+
+	invalid := []models.Coupon{
+		{Code: "summer 10", Type: models.CouponPercentage, Value: decimal.NewFromInt(10)},
+		{Code: "SUMMER", Type: "free", Value: decimal.NewFromInt(10)},
+		{Code: "SUMMER", Type: models.CouponPercentage, Value: decimal.NewFromInt(101)},
+		{Code: "SUMMER", Type: models.CouponFixed, Value: decimal.Zero},
+		{Code: "SUMMER", Type: models.CouponFixed, Value: decimal.NewFromInt(5), MaxUses: &zero},
+		{Code: "SUMMER", Type: models.CouponFixed, Value: decimal.NewFromInt(5), StartsAt: &now, EndsAt: &now},
+	}
+
+	for _, coupon := range invalid {
+		if err := validateCoupon(&coupon); err != common.ErrorInvalidCoupon {
+			t.Log("expected: v", common.ErrorInvalidCoupon)
+			t.Error("actual: v", coupon, err)
+		}
+	}
+}
+
+func TestIsCouponOpen(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	if !isCouponOpen(models.Coupon{Active: true, EndsAt: &later}, now) {
+		t.Error("actual: v", false)
+	}
+
+	if isCouponOpen(models.Coupon{Active: true, StartsAt: &later}, now) || isCouponOpen(models.Coupon{}, now) {
+		t.Error("actual: v", true)
+	}
+
+	// the end is excluded
+	if isCouponOpen(models.Coupon{Active: true, EndsAt: &now}, now) {
+		t.Error("actual: v", true)
+	}
+}
+
+func TestInOrderCurrency(t *testing.T) {
+	coupon := models.Coupon{Type: models.CouponFixed, Value: decimal.NewFromInt(11), MinSpend: decimal.NewFromInt(22)}
+
+	// the orders are in a currency worth 1.1 of the base currency
+	converted := inOrderCurrency(coupon, decimal.RequireFromString("1.1"))
+	if !converted.Value.Equal(decimal.NewFromInt(10)) || !converted.MinSpend.Equal(decimal.NewFromInt(20)) {
+		t.Error("actual: v", converted)
+	}
+
+	// a percentage stays as it is
+	coupon.Type = models.CouponPercentage
+	converted = inOrderCurrency(coupon, decimal.RequireFromString("1.1"))
+	if !converted.Value.Equal(decimal.NewFromInt(11)) || !converted.MinSpend.Equal(decimal.NewFromInt(20)) {
+		t.Error("actual: v", converted)
+	}
+}
@@ -0,0 +1,218 @@
+package promotions
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+var hundred = decimal.NewFromInt(100)
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Create a coupon from its definition, vendorId is the vendor of the
+// eligible products (any vendor when empty) and createdById the vendor or
+// the admin who defines it
+func CreateCoupon(createdById uint, vendorId *uint, payload dto.CouponDto) (*models.Coupon, error) {
+	coupon := &models.Coupon{
+		Code:           normalizeCode(payload.Code),
+		CreatedByID:    createdById,
+		VendorID:       vendorId,
+		Type:           payload.Type,
+		Value:          payload.Value,
+		MinSpend:       payload.MinSpend,
+		MaxUses:        payload.MaxUses,
+		MaxUsesPerUser: payload.MaxUsesPerUser,
+		StartsAt:       payload.StartsAt,
+		EndsAt:         payload.EndsAt,
+		Active:         true,
+		ProductIDs:     uniqueIds(payload.ProductIDs),
+	}
+
+	if err := validateCoupon(coupon); err != nil {
+		return nil, err
+	}
+
+	db := database.GetDBInstance()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		count := int64(0)
+		if err := tx.Model(&models.Coupon{}).Where("code = ?", coupon.Code).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return common.ErrorCouponCodeExists
+		}
+
+		if coupon.VendorID != nil {
+			err := tx.Model(&models.User{}).Where("id = ? and role = ?", *coupon.VendorID, models.Vendor).Count(&count).Error
+			if err != nil {
+				return err
+			}
+
+			if count == 0 {
+				return common.ErrorInvalidCoupon
+			}
+		}
+
+		if len(coupon.ProductIDs) > 0 {
+			query := tx.Model(&models.Product{}).Where("id in (?)", coupon.ProductIDs)
+			if coupon.VendorID != nil {
+				query = query.Where("vendor_id = ?", *coupon.VendorID)
+			}
+
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			}
+
+			if int(count) != len(coupon.ProductIDs) {
+				return common.ErrorInvalidCoupon
+			}
+		}
+
+		if err := tx.Create(coupon).Error; err != nil {
+			return err
+		}
+
+		couponProducts := []models.CouponProduct{}
+		for _, productId := range coupon.ProductIDs {
+			couponProducts = append(couponProducts, models.CouponProduct{CouponID: coupon.ID, ProductID: productId})
+		}
+
+		if len(couponProducts) == 0 {
+			return nil
+		}
+
+		return tx.Create(&couponProducts).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func validateCoupon(coupon *models.Coupon) error {
+	if !couponCodePattern.MatchString(coupon.Code) {
+		return common.ErrorInvalidCoupon
+	}
+
+	if coupon.Type != models.CouponPercentage && coupon.Type != models.CouponFixed {
+		return common.ErrorInvalidCoupon
+	}
+
+	if !coupon.Value.IsPositive() || (coupon.Type == models.CouponPercentage && coupon.Value.GreaterThan(hundred)) {
+		return common.ErrorInvalidCoupon
+	}
+
+	if coupon.MinSpend.IsNegative() {
+		return common.ErrorInvalidCoupon
+	}
+
+	if (coupon.MaxUses != nil && *coupon.MaxUses <= 0) || (coupon.MaxUsesPerUser != nil && *coupon.MaxUsesPerUser <= 0) {
+		return common.ErrorInvalidCoupon
+	}
+
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return common.ErrorInvalidCoupon
+	}
+
+	return nil
+}
+
+// Find the coupons created by a vendor or an admin,
+// with their products and the number of their uses
+func FindCoupons(createdById uint) ([]models.Coupon, error) {
+	db := database.GetDBInstance()
+	coupons := []models.Coupon{}
+
+	if err := db.Where("created_by_id = ?", createdById).Order("id desc").Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+
+	couponIds := []uint{}
+	for _, coupon := range coupons {
+		couponIds = append(couponIds, coupon.ID)
+	}
+
+	if len(couponIds) == 0 {
+		return coupons, nil
+	}
+
+	couponProducts := []models.CouponProduct{}
+	if err := db.Where("coupon_id in (?)", couponIds).Order("product_id").Find(&couponProducts).Error; err != nil {
+		return nil, err
+	}
+
+	uses := []struct {
+		CouponID uint
+		Uses     int64
+	}{}
+	err := db.Model(&models.CouponRedemption{}).Select("coupon_id, count(*) as uses").
+		Where("coupon_id in (?)", couponIds).Group("coupon_id").Scan(&uses).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	productIds := make(map[uint][]uint)
+	for _, couponProduct := range couponProducts {
+		productIds[couponProduct.CouponID] = append(productIds[couponProduct.CouponID], couponProduct.ProductID)
+	}
+
+	usesById := make(map[uint]int64)
+	for _, use := range uses {
+		usesById[use.CouponID] = use.Uses
+	}
+
+	for i := range coupons {
+		coupons[i].ProductIDs = productIds[coupons[i].ID]
+		if coupons[i].ProductIDs == nil {
+			coupons[i].ProductIDs = []uint{}
+		}
+		coupons[i].Uses = usesById[coupons[i].ID]
+	}
+
+	return coupons, nil
+}
+
+// Deactivate a coupon created by a vendor or an admin, it cannot be
+// used anymore and its code is not given to another coupon
+func DeactivateCoupon(createdById uint, couponId uint) error {
+	db := database.GetDBInstance()
+	coupon := models.Coupon{}
+
+	if err := db.Where("created_by_id = ?", createdById).First(&coupon, couponId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrorResourceNotFound
+		}
+		return err
+	}
+
+	return db.Model(&coupon).Update("active", false).Error
+}
+
+func uniqueIds(ids []uint) []uint {
+	result := []uint{}
+	seen := make(map[uint]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,106 @@
+package reservations
+
+import (
+	"fmt"
+	"order-system/config"
+	"order-system/database"
+	"order-system/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultHoldTTL = time.Minute * 15
+
+// Quantity of each product held by active reservations,
+// to be joined with the products to compute their available quantity
+const ActiveHoldsQuery = `
+	select product_id, sum(quantity) as quantity from stock_reservations
+	where status = 'active' and expires_at > now()
+	group by product_id`
+
+// Quantity of each product held by active reservations in each warehouse,
+// to be joined with the warehouse stocks to compute what is left to fulfil an order
+const ActiveWarehouseHoldsQuery = `
+	select product_id, warehouse_id, sum(quantity) as quantity from stock_reservations
+	where status = 'active' and expires_at > now() and warehouse_id is not null
+	group by product_id, warehouse_id`
+
+// How long the stock is held for an order at checkout
+func HoldTTL() time.Duration {
+	ttl := config.GetConfig().StockReservationTTL
+	if ttl <= 0 {
+		return defaultHoldTTL
+	}
+	return ttl
+}
+
+// Hold the stock of the items of the given orders
+func HoldOrders(tx *gorm.DB, orderIds []uint, expiresAt time.Time) error {
+	return tx.Exec(`
+		insert into stock_reservations (created_at, updated_at, product_id, order_id, warehouse_id, quantity, status, expires_at)
+			(select now(), now(), oi.product_id, oi.order_id, o.warehouse_id, oi.quantity, ?, ?
+			from order_items oi
+			inner join orders o on o.id = oi.order_id
+			where oi.order_id in (?))`,
+		models.ReservationActive, expiresAt, orderIds).Error
+}
+
+// Turn the active holds of an order into "out" product transactions
+func Consume(tx *gorm.DB, orderId uint) error {
+	held := []models.StockReservation{}
+	if err := tx.Where("order_id = ? and status = ?", orderId, models.ReservationActive).Find(&held).Error; err != nil {
+		return err
+	}
+
+	if len(held) == 0 {
+		return nil
+	}
+
+	transactionItems := []models.ProductTransaction{}
+	for _, r := range held {
+		transactionItems = append(transactionItems, models.ProductTransaction{
+			Type:        models.TransactionTypeOut,
+			ProductID:   r.ProductID,
+			Quantity:    r.Quantity.Neg(),
+			Description: fmt.Sprintf("order %d placed", orderId),
+			WarehouseID: r.WarehouseID,
+		})
+	}
+
+	if err := tx.Create(&transactionItems).Error; err != nil {
+		return err
+	}
+
+	return setStatus(tx, orderId, models.ReservationActive, models.ReservationConsumed)
+}
+
+// Give the held stock of an order back
+func Release(tx *gorm.DB, orderId uint) error {
+	return setStatus(tx, orderId, models.ReservationActive, models.ReservationReleased)
+}
+
+func FindReservationsOfOrder(tx *gorm.DB, orderId uint) ([]models.StockReservation, error) {
+	reservations := []models.StockReservation{}
+	err := tx.Where("order_id = ?", orderId).Find(&reservations).Error
+
+	return reservations, err
+}
+
+// Find the orders which still have holds that are expired
+func FindExpiredOrderIds(now time.Time) ([]uint, error) {
+	db := database.GetDBInstance()
+	orderIds := []uint{}
+	err := db.Model(&models.StockReservation{}).
+		Distinct("order_id").
+		Where("status = ? and expires_at <= ?", models.ReservationActive, now).
+		Pluck("order_id", &orderIds).Error
+
+	return orderIds, err
+}
+
+func setStatus(tx *gorm.DB, orderId uint, from models.ReservationStatus, to models.ReservationStatus) error {
+	return tx.Model(&models.StockReservation{}).
+		Where("order_id = ? and status = ?", orderId, from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()}).Error
+}
@@ -0,0 +1,63 @@
+package taxes
+
+import (
+	"order-system/config"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Where an order is shipped to
+type Destination struct {
+	Country string
+	Region  string
+}
+
+// An item of an order, Amount is what the buyer
+// pays for it once the discounts are taken off
+type Item struct {
+	ProductID   uint
+	TaxCategory string
+	Amount      decimal.Decimal
+}
+
+// A tax of an order, Rate is a percentage of Taxable
+type Line struct {
+	Name    string
+	Rate    decimal.Decimal
+	Taxable decimal.Decimal
+	Amount  decimal.Decimal
+}
+
+// Computes the taxes of the orders within the transaction of the
+// checkout, the lines are stored with the order so a later change
+// of the engine does not change its total
+type Engine interface {
+	Compute(tx *gorm.DB, destination Destination, items []Item) ([]Line, error)
+}
+
+var engine Engine
+
+func InitEngine(appConfig *config.Config) {
+	if appConfig.TaxEngine == config.TaxEngineNone {
+		SetEngine(NoTaxEngine{})
+		return
+	}
+
+	SetEngine(RulesEngine{})
+}
+
+func SetEngine(taxEngine Engine) {
+	engine = taxEngine
+}
+
+func GetEngine() Engine {
+	return engine
+}
+
+// An engine which never levies any tax
+type NoTaxEngine struct{}
+
+func (NoTaxEngine) Compute(tx *gorm.DB, destination Destination, items []Item) ([]Line, error) {
+	return []Line{}, nil
+}
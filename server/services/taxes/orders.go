@@ -0,0 +1,117 @@
+package taxes
+
+import (
+	"fmt"
+	"order-system/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// an item of an order with the price it is ordered at
+type orderItem struct {
+	OrderID     uint
+	ProductID   uint
+	TaxCategory string
+	Amount      decimal.Decimal
+}
+
+// Compute the taxes of newly created orders with the current engine, the
+// tax lines are stored as adjustments of the orders. The discounts of an
+// order are spread over its items by their amount before the taxes
+func TaxOrders(tx *gorm.DB, orderIds []uint) error {
+	if len(orderIds) == 0 {
+		return nil
+	}
+
+	orders := []models.Order{}
+	if err := tx.Where("id in (?)", orderIds).Order("id").Find(&orders).Error; err != nil {
+		return err
+	}
+
+	items := []orderItem{}
+	err := tx.Raw(`
+		select oi.order_id, oi.product_id, p.tax_category, round(pp.price * oi.exchange_rate, 2) * oi.quantity as amount
+		from order_items oi
+		inner join products p on oi.product_id = p.id
+		inner join product_prices pp on oi.product_price_id = pp.id
+		where oi.order_id in (?)
+		order by oi.order_id, oi.id`, orderIds).Scan(&items).Error
+
+	if err != nil {
+		return err
+	}
+
+	discounts := []struct {
+		OrderID uint
+		Amount  decimal.Decimal
+	}{}
+	err = tx.Model(&models.OrderAdjustment{}).Select("order_id, sum(amount) as amount").
+		Where("order_id in (?) and type = ?", orderIds, models.AdjustmentDiscount).Group("order_id").
+		Scan(&discounts).Error
+
+	if err != nil {
+		return err
+	}
+
+	itemsByOrder := make(map[uint][]Item)
+	for _, item := range items {
+		itemsByOrder[item.OrderID] = append(itemsByOrder[item.OrderID], Item{
+			ProductID:   item.ProductID,
+			TaxCategory: item.TaxCategory,
+			Amount:      item.Amount,
+		})
+	}
+
+	for _, discount := range discounts {
+		itemsByOrder[discount.OrderID] = spreadDiscount(itemsByOrder[discount.OrderID], discount.Amount)
+	}
+
+	adjustments := []models.OrderAdjustment{}
+	for _, order := range orders {
+		destination := Destination{Country: order.ShippingCountry, Region: order.ShippingRegion}
+
+		lines, err := GetEngine().Compute(tx, destination, itemsByOrder[order.ID])
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			rate := line.Rate
+			adjustments = append(adjustments, models.OrderAdjustment{
+				OrderID:     order.ID,
+				Type:        models.AdjustmentTax,
+				Description: fmt.Sprintf("%s %s%%", line.Name, line.Rate.String()),
+				Amount:      line.Amount,
+				Rate:        &rate,
+			})
+		}
+	}
+
+	if len(adjustments) == 0 {
+		return nil
+	}
+
+	return tx.Create(&adjustments).Error
+}
+
+// Take a discount (a negative amount) off the items, each
+// of them gets a share of it by its amount
+func spreadDiscount(items []Item, discount decimal.Decimal) []Item {
+	total := decimal.Zero
+	for _, item := range items {
+		total = total.Add(item.Amount)
+	}
+
+	if !total.IsPositive() {
+		return items
+	}
+
+	result := []Item{}
+	for _, item := range items {
+		item.Amount = item.Amount.Add(discount.Mul(item.Amount).Div(total))
+		result = append(result, item)
+	}
+
+	return result
+}
@@ -0,0 +1,192 @@
+package taxes
+
+import (
+	"errors"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var taxCategoryPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+var regionPattern = regexp.MustCompile(`^[A-Z0-9-]{1,8}$`)
+
+var hundred = decimal.NewFromInt(100)
+
+// The tax category of a product, lower case. An empty
+// category is the default one
+func NormalizeTaxCategory(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if len(category) == 0 {
+		return models.DefaultTaxCategory, nil
+	}
+
+	if !taxCategoryPattern.MatchString(category) {
+		return "", common.ErrorInvalidTaxCategory
+	}
+
+	return category, nil
+}
+
+// The tax rules stored in the database, the taxes of an item are the rule of
+// its country and the rule of its region (e.g. a federal and a state tax).
+// At each level, the rule of the category of the item wins over the rule
+// without a category
+type RulesEngine struct{}
+
+func (RulesEngine) Compute(tx *gorm.DB, destination Destination, items []Item) ([]Line, error) {
+	if len(destination.Country) == 0 || len(items) == 0 {
+		return []Line{}, nil
+	}
+
+	rules := []models.TaxRule{}
+	err := tx.Where("country = ? and (region = '' or region = ?)", destination.Country, destination.Region).
+		Find(&rules).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return applyRules(rules, destination, items), nil
+}
+
+func applyRules(rules []models.TaxRule, destination Destination, items []Item) []Line {
+	// by level (the country then the region) and by tax category
+	levels := []map[string]models.TaxRule{{}, {}}
+	for _, rule := range rules {
+		if rule.Country != destination.Country {
+			continue
+		}
+
+		if len(rule.Region) == 0 {
+			levels[0][rule.TaxCategory] = rule
+		} else if rule.Region == destination.Region {
+			levels[1][rule.TaxCategory] = rule
+		}
+	}
+
+	taxable := make(map[uint]decimal.Decimal)
+	applied := []models.TaxRule{}
+
+	for _, level := range levels {
+		for _, item := range items {
+			rule, ok := level[item.TaxCategory]
+			if !ok {
+				rule, ok = level[""]
+			}
+
+			if !ok {
+				continue
+			}
+
+			if _, seen := taxable[rule.ID]; !seen {
+				applied = append(applied, rule)
+			}
+			taxable[rule.ID] = taxable[rule.ID].Add(item.Amount)
+		}
+	}
+
+	lines := []Line{}
+	for _, rule := range applied {
+		amount := taxable[rule.ID].Mul(rule.Rate).Div(hundred).Round(2)
+		if amount.IsZero() {
+			continue
+		}
+
+		lines = append(lines, Line{
+			Name:    rule.Name,
+			Rate:    rule.Rate,
+			Taxable: taxable[rule.ID],
+			Amount:  amount,
+		})
+	}
+
+	return lines
+}
+
+func CreateTaxRule(payload dto.TaxRuleDto) (*models.TaxRule, error) {
+	rule := &models.TaxRule{
+		Name:    strings.TrimSpace(payload.Name),
+		Country: strings.ToUpper(strings.TrimSpace(payload.Country)),
+		Region:  strings.ToUpper(strings.TrimSpace(payload.Region)),
+		Rate:    payload.Rate,
+	}
+
+	if len(strings.TrimSpace(payload.TaxCategory)) > 0 {
+		category, err := NormalizeTaxCategory(payload.TaxCategory)
+		if err != nil {
+			return nil, err
+		}
+		rule.TaxCategory = category
+	}
+
+	if err := validateTaxRule(rule); err != nil {
+		return nil, err
+	}
+
+	db := database.GetDBInstance()
+	count := int64(0)
+	err := db.Model(&models.TaxRule{}).
+		Where("country = ? and region = ? and tax_category = ?", rule.Country, rule.Region, rule.TaxCategory).
+		Count(&count).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	if count > 0 {
+		return nil, common.ErrorTaxRuleExists
+	}
+
+	if err := db.Create(rule).Error; err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func validateTaxRule(rule *models.TaxRule) error {
+	if len(rule.Name) == 0 || !countryPattern.MatchString(rule.Country) {
+		return common.ErrorInvalidTaxRule
+	}
+
+	if len(rule.Region) > 0 && !regionPattern.MatchString(rule.Region) {
+		return common.ErrorInvalidTaxRule
+	}
+
+	if rule.Rate.IsNegative() || rule.Rate.GreaterThan(hundred) {
+		return common.ErrorInvalidTaxRule
+	}
+
+	return nil
+}
+
+// Find the tax rules, by country and region
+func FindTaxRules() ([]models.TaxRule, error) {
+	rules := []models.TaxRule{}
+	err := database.GetDBInstance().Order("country, region, tax_category").Find(&rules).Error
+
+	return rules, err
+}
+
+// Delete a tax rule, the taxes of the orders
+// already placed are kept as they are
+func DeleteTaxRule(ruleId uint) error {
+	db := database.GetDBInstance()
+	rule := models.TaxRule{}
+
+	if err := db.First(&rule, ruleId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrorResourceNotFound
+		}
+		return err
+	}
+
+	return db.Delete(&rule).Error
+}
@@ -0,0 +1,95 @@
+package taxes
+
+import (
+	"order-system/common"
+	"order-system/models"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func taxRule(id uint, region string, category string, rate int64) models.TaxRule {
+	rule := models.TaxRule{Name: "Tax", Country: "CA", Region: region, TaxCategory: category, Rate: decimal.NewFromInt(rate)}
+	rule.ID = id
+	return rule
+}
+
+var rules = []models.TaxRule{
+	taxRule(1, "", "", 5),
+	taxRule(2, "BC", "", 7),
+	taxRule(3, "BC", "food", 0),
+	taxRule(4, "ON", "", 8),
+}
+
+var items = []Item{
+	{ProductID: 1, TaxCategory: "standard", Amount: decimal.NewFromInt(100)},
+	{ProductID: 2, TaxCategory: "food", Amount: decimal.NewFromInt(50)},
+}
+
+func TestApplyRules(t *testing.T) {
+	// the rule of the country then the rule of the region, food is exempt in the region
+	lines := applyRules(rules, Destination{Country: "CA", Region: "BC"}, items)
+	if len(lines) != 2 || !lines[0].Amount.Equal(decimal.RequireFromString("7.5")) ||
+		!lines[0].Taxable.Equal(decimal.NewFromInt(150)) || !lines[1].Amount.Equal(decimal.NewFromInt(7)) {
+		t.Error("actual: v", lines)
+	}
+
+	lines = applyRules(rules, Destination{Country: "CA"}, items)
+	if len(lines) != 1 || !lines[0].Rate.Equal(decimal.NewFromInt(5)) {
+		t.Error("actual: v", lines)
+	}
+
+	if lines := applyRules(rules, Destination{Country: "US", Region: "BC"}, items); len(lines) != 0 {
+		t.Error("actual: v", lines)
+	}
+}
+
+func TestSpreadDiscount(t *testing.T) {
+	spread := spreadDiscount(items, decimal.NewFromInt(-30))
+	if !spread[0].Amount.Equal(decimal.NewFromInt(80)) || !spread[1].Amount.Equal(decimal.NewFromInt(40)) {
+		t.Error("actual: v", spread)
+	}
+
+	// the items themselves are left as they are
+	if !items[0].Amount.Equal(decimal.NewFromInt(100)) {
+		t.Error("actual: v", items)
+	}
+}
+
+func TestNormalizeTaxCategory(t *testing.T) {
+	if category, err := NormalizeTaxCategory(" Reduced "); err != nil || category != "reduced" {
+		t.Log("expected: v", "reduced")
+		t.Error("actual: v", category, err)
+	}
+
+	if category, _ := NormalizeTaxCategory(""); category != models.DefaultTaxCategory {
+		t.Log("expected: v", models.DefaultTaxCategory)
+		t.Error("actual: v", category)
+	}
+
+	if _, err := NormalizeTaxCategory("not a category"); err != common.ErrorInvalidTaxCategory {
+		t.Log("expected: v", common.ErrorInvalidTaxCategory)
+		t.Error("actual: v", err)
+	}
+}
+
+func TestValidateTaxRule(t *testing.T) {
+	valid := taxRule(0, "BC", "", 7)
+	if err := validateTaxRule(&valid); err != nil {
+		t.Error("actual: v", err)
+	}
+
+	invalid := []models.TaxRule{
+		{Name: "Tax", Country: "Canada", Rate: decimal.NewFromInt(5)},
+		{Name: "", Country: "CA", Rate: decimal.NewFromInt(5)},
+		{Name: "Tax", Country: "CA", Region: "British Columbia", Rate: decimal.NewFromInt(5)},
+		{Name: "Tax", Country: "CA", Rate: decimal.NewFromInt(-1)},
+	}
+
+	for _, rule := range invalid {
+		if err := validateTaxRule(&rule); err != common.ErrorInvalidTaxRule {
+			t.Log("expected: v", common.ErrorInvalidTaxRule)
+			t.Error("actual: v", rule, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package warehouses
+
+import (
+	"order-system/common"
+	"order-system/config"
+	"order-system/services/reservations"
+	"sort"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// The stock of an ordered product in a warehouse of the vendor
+type fulfillmentLine struct {
+	WarehouseID uint            `gorm:"column:warehouse_id"`
+	Priority    int             `gorm:"column:priority"`
+	ProductID   uint            `gorm:"column:product_id"`
+	Requested   decimal.Decimal `gorm:"column:requested"`
+	Available   decimal.Decimal `gorm:"column:available"`
+}
+
+// A warehouse which could fulfil an order
+type FulfillmentCandidate struct {
+	WarehouseID uint
+	Priority    int
+	// the warehouse has enough stock for every item
+	CoversAll  bool
+	TotalStock decimal.Decimal
+}
+
+// A rule picks the warehouse fulfilling an order
+// among the warehouses of the vendor
+type FulfillmentRule func(candidates []FulfillmentCandidate) (uint, bool)
+
+var fulfillmentRules = map[string]FulfillmentRule{
+	config.FulfillmentRulePriority:  PickByPriority,
+	config.FulfillmentRuleMostStock: PickByMostStock,
+}
+
+// Pick the warehouse with the lowest priority value
+// among the ones covering every item of the order
+func PickByPriority(candidates []FulfillmentCandidate) (uint, bool) {
+	return pick(candidates, func(a, b FulfillmentCandidate) bool {
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.WarehouseID < b.WarehouseID
+	})
+}
+
+// Pick the warehouse holding the most stock of the ordered products
+// among the ones covering every item of the order
+func PickByMostStock(candidates []FulfillmentCandidate) (uint, bool) {
+	return pick(candidates, func(a, b FulfillmentCandidate) bool {
+		if !a.TotalStock.Equal(b.TotalStock) {
+			return a.TotalStock.GreaterThan(b.TotalStock)
+		}
+		return a.WarehouseID < b.WarehouseID
+	})
+}
+
+// Only the warehouses covering every item can be picked, the stock
+// of a warehouse must not go negative when the order is paid
+func pick(candidates []FulfillmentCandidate, less func(a, b FulfillmentCandidate) bool) (uint, bool) {
+	covering := []FulfillmentCandidate{}
+	for _, candidate := range candidates {
+		if candidate.CoversAll {
+			covering = append(covering, candidate)
+		}
+	}
+
+	if len(covering) == 0 {
+		return 0, false
+	}
+
+	sort.SliceStable(covering, func(i, j int) bool {
+		return less(covering[i], covering[j])
+	})
+
+	return covering[0].WarehouseID, true
+}
+
+func buildCandidates(lines []fulfillmentLine) []FulfillmentCandidate {
+	candidates := []FulfillmentCandidate{}
+	index := make(map[uint]int)
+
+	for _, line := range lines {
+		i, ok := index[line.WarehouseID]
+		if !ok {
+			i = len(candidates)
+			index[line.WarehouseID] = i
+			candidates = append(candidates, FulfillmentCandidate{
+				WarehouseID: line.WarehouseID,
+				Priority:    line.Priority,
+				CoversAll:   true,
+			})
+		}
+
+		candidates[i].TotalStock = candidates[i].TotalStock.Add(line.Available)
+		if line.Available.LessThan(line.Requested) {
+			candidates[i].CoversAll = false
+		}
+	}
+
+	return candidates
+}
+
+// Pick the warehouse fulfilling an order (with its items already created)
+// using the configured rule, nothing is picked when the vendor has no warehouse.
+// The stock held for other orders is not available, the order is refused
+// when no warehouse has every item in stock
+func PickFulfillingWarehouse(tx *gorm.DB, orderId uint) (*uint, error) {
+	lines := []fulfillmentLine{}
+	err := tx.Raw(`
+		select w.id as warehouse_id, w.priority, oi.product_id, oi.quantity as requested,
+			coalesce(ws.quantity, 0) - coalesce(sr.quantity, 0) as available
+			from orders o
+			inner join warehouses w on w.vendor_id = o.vendor_id and w.deleted_at is null
+			inner join order_items oi on oi.order_id = o.id
+			left join warehouse_stocks ws on ws.warehouse_id = w.id and ws.product_id = oi.product_id
+			left join (`+reservations.ActiveWarehouseHoldsQuery+`) sr on sr.warehouse_id = w.id and sr.product_id = oi.product_id
+		where o.id = ?
+		order by w.id`, orderId).Scan(&lines).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule, ok := fulfillmentRules[config.GetConfig().FulfillmentRule]
+	if !ok {
+		rule = PickByPriority
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	warehouseId, ok := rule(buildCandidates(lines))
+	if !ok {
+		return nil, common.ErrorNoFulfillingWarehouse
+	}
+
+	return &warehouseId, nil
+}
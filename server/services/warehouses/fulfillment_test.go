@@ -0,0 +1,80 @@
+package warehouses
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+var d = decimal.NewFromInt
+
+func TestBuildCandidates(t *testing.T) {
+	lines := []fulfillmentLine{
+		{WarehouseID: 1, Priority: 0, ProductID: 1, Requested: d(2), Available: d(5)},
+		{WarehouseID: 1, Priority: 0, ProductID: 2, Requested: d(3), Available: d(1)},
+		{WarehouseID: 2, Priority: 1, ProductID: 1, Requested: d(2), Available: d(2)},
+		{WarehouseID: 2, Priority: 1, ProductID: 2, Requested: d(3), Available: d(3)},
+	}
+
+	candidates := buildCandidates(lines)
+
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(candidates))
+	}
+
+	if candidates[0].CoversAll || !candidates[0].TotalStock.Equal(d(6)) {
+		t.Errorf("expected warehouse 1 to miss an item with 6 in stock, got %+v", candidates[0])
+	}
+
+	if !candidates[1].CoversAll || !candidates[1].TotalStock.Equal(d(5)) {
+		t.Errorf("expected warehouse 2 to cover the order with 5 in stock, got %+v", candidates[1])
+	}
+}
+
+func TestPickByPriority(t *testing.T) {
+	candidates := []FulfillmentCandidate{
+		{WarehouseID: 1, Priority: 0, CoversAll: false, TotalStock: d(10)},
+		{WarehouseID: 2, Priority: 2, CoversAll: true, TotalStock: d(4)},
+		{WarehouseID: 3, Priority: 1, CoversAll: true, TotalStock: d(3)},
+	}
+
+	warehouseId, ok := PickByPriority(candidates)
+
+	if !ok || warehouseId != 3 {
+		t.Log("expected: v", 3)
+		t.Error("actual: v", warehouseId)
+	}
+}
+
+func TestPickByMostStock(t *testing.T) {
+	candidates := []FulfillmentCandidate{
+		{WarehouseID: 1, Priority: 0, CoversAll: false, TotalStock: d(10)},
+		{WarehouseID: 2, Priority: 2, CoversAll: true, TotalStock: d(4)},
+		{WarehouseID: 3, Priority: 1, CoversAll: true, TotalStock: d(3)},
+	}
+
+	warehouseId, ok := PickByMostStock(candidates)
+
+	if !ok || warehouseId != 2 {
+		t.Log("expected: v", 2)
+		t.Error("actual: v", warehouseId)
+	}
+}
+
+func TestPickWithoutCoveringWarehouse(t *testing.T) {
+	// enough stock overall, but split between the warehouses
+	lines := []fulfillmentLine{
+		{WarehouseID: 1, Priority: 1, ProductID: 1, Requested: d(3), Available: d(2)},
+		{WarehouseID: 2, Priority: 0, ProductID: 1, Requested: d(3), Available: d(2)},
+	}
+
+	for _, rule := range []FulfillmentRule{PickByPriority, PickByMostStock} {
+		if warehouseId, ok := rule(buildCandidates(lines)); ok {
+			t.Error("expected no warehouse to be picked, got", warehouseId)
+		}
+	}
+
+	if _, ok := PickByMostStock(nil); ok {
+		t.Error("expected no warehouse to be picked without candidates")
+	}
+}
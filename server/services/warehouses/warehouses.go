@@ -0,0 +1,143 @@
+package warehouses
+
+import (
+	"errors"
+	"fmt"
+	"order-system/common"
+	"order-system/database"
+	"order-system/handlers/dto"
+	"order-system/models"
+	"order-system/services/products"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func FindWarehousesOfVendor(vendorId uint) ([]models.Warehouse, error) {
+	db := database.GetDBInstance()
+	warehouses := []models.Warehouse{}
+	err := db.Where("vendor_id = ?", vendorId).Order("priority asc, id asc").Find(&warehouses).Error
+
+	return warehouses, err
+}
+
+// Find a warehouse which belongs to the vendor
+func FindWarehouseOfVendor(vendorId uint, warehouseId uint) (models.Warehouse, error) {
+	db := database.GetDBInstance()
+	warehouse := models.Warehouse{}
+	err := db.Where("vendor_id = ? and id = ?", vendorId, warehouseId).First(&warehouse).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return warehouse, common.ErrorResourceNotFound
+	}
+
+	return warehouse, err
+}
+
+func CreateWarehouse(warehouse *models.Warehouse) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if warehouse.IsDefault {
+			if err := clearDefault(tx, warehouse.VendorID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(warehouse).Error
+	})
+}
+
+func UpdateWarehouse(vendorId uint, warehouseId uint, payload dto.WarehouseDto) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if payload.IsDefault {
+			if err := clearDefault(tx, vendorId); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Warehouse{}).
+			Where("vendor_id = ? and id = ?", vendorId, warehouseId).
+			Updates(map[string]interface{}{
+				"name":       payload.Name,
+				"address":    payload.Address,
+				"priority":   payload.Priority,
+				"is_default": payload.IsDefault,
+			}).Error
+	})
+}
+
+// Find the default warehouse of a vendor, the stock changes
+// without any location go there. A vendor without any warehouse
+// gets a default one
+func FindOrCreateDefaultWarehouse(vendorId uint) (models.Warehouse, error) {
+	db := database.GetDBInstance()
+	warehouse := models.Warehouse{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		found, err := products.FindDefaultWarehouse(tx, vendorId, true)
+		if err != nil {
+			return err
+		}
+
+		warehouse = *found
+		return nil
+	})
+
+	return warehouse, err
+}
+
+// Move a quantity of a product from a warehouse to another one,
+// the total stock of the product does not change
+func TransferStock(productId uint, fromWarehouseId uint, toWarehouseId uint, quantity decimal.Decimal, description string) error {
+	db := database.GetDBInstance()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := products.LockProducts(tx, []uint{productId}); err != nil {
+			return err
+		}
+
+		if err := products.ValidateQuantity(tx, productId, quantity); err != nil {
+			return err
+		}
+
+		available := decimal.Zero
+		if err := tx.Raw(`select coalesce(sum(quantity), 0) from warehouse_stocks where product_id = ? and warehouse_id = ?`,
+			productId, fromWarehouseId).Row().Scan(&available); err != nil {
+			return err
+		}
+
+		if available.LessThan(quantity) {
+			return common.ErrorInsufficientQuantity
+		}
+
+		if len(description) == 0 {
+			description = fmt.Sprintf("transfer from warehouse %d to warehouse %d", fromWarehouseId, toWarehouseId)
+		}
+
+		return tx.Create(&[]models.ProductTransaction{
+			{
+				ProductID:   productId,
+				Quantity:    quantity.Neg(),
+				Type:        models.TransactionTypeTransfer,
+				Description: description,
+				WarehouseID: &fromWarehouseId,
+			},
+			{
+				ProductID:   productId,
+				Quantity:    quantity,
+				Type:        models.TransactionTypeTransfer,
+				Description: description,
+				WarehouseID: &toWarehouseId,
+			},
+		}).Error
+	})
+}
+
+func clearDefault(tx *gorm.DB, vendorId uint) error {
+	return tx.Model(&models.Warehouse{}).
+		Where("vendor_id = ? and is_default", vendorId).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,34 @@
+package utils
+
+// Check a GTIN barcode (GTIN-8, UPC-A/GTIN-12, EAN-13 or GTIN-14)
+// with its check digit, the last one
+func IsValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	// the weights alternate 3 and 1 starting from the digit
+	// next to the check digit
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+
+		if (len(code)-2-i)%2 == 0 {
+			sum += digit * 3
+		} else {
+			sum += digit
+		}
+	}
+
+	check := int(code[len(code)-1] - '0')
+	if check < 0 || check > 9 {
+		return false
+	}
+
+	return (10-sum%10)%10 == check
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestIsValidGTIN(t *testing.T) {
+	codes := map[string]bool{
+		"4006381333931":  true,  // EAN-13
+		"036000291452":   true,  // UPC-A
+		"96385074":       true,  // GTIN-8
+		"10614141000415": true,  // GTIN-14
+		"4006381333932":  false, // wrong check digit
+		"400638133393":   false, // UPC-A length but wrong check digit
+		"40063813339a":   false,
+		"123":            false,
+		"":               false,
+	}
+
+	for code, expected := range codes {
+		if actual := IsValidGTIN(code); actual != expected {
+			t.Log("expected: v", code, expected)
+			t.Error("actual: v", code, actual)
+		}
+	}
+}
@@ -10,3 +10,11 @@ func GetModelTableName(model interface{}) string {
 	s, _ := schema.Parse(&model, &sync.Map{}, schema.NamingStrategy{})
 	return s.Table
 }
+
+// A nullable column is stored as null rather than an empty string
+func NilIfEmpty(value string) *string {
+	if len(value) == 0 {
+		return nil
+	}
+	return &value
+}
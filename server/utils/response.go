@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
 	"order-system/common"
 
@@ -25,3 +26,18 @@ func BindAndValidate(c echo.Context, payload interface{}) error {
 func InternalServerError() error {
 	return &echo.HTTPError{}
 }
+
+// The error of a list endpoint, an invalid filter, sort
+// or cursor of the request is rejected with a 400
+func ListError(c echo.Context, err error) error {
+	if errors.Is(err, common.ErrorInvalidFilter) || errors.Is(err, common.ErrorInvalidSort) ||
+		errors.Is(err, common.ErrorInvalidCursor) {
+		return &echo.HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	c.Logger().Error(err)
+	return common.ErrorInternalServerError
+}